@@ -0,0 +1,98 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"html/template"
+	"net/url"
+	"path"
+	"strings"
+)
+
+type (
+	// AssetURLConfig the config of asset url
+	AssetURLConfig struct {
+		// url的前缀，与静态服务的挂载路径一致，如 /static
+		Prefix string
+		// 构建时生成的文件信息，使用其etag作为版本参数（如 /app.css?v=abcd ），
+		// 与静态服务使用相同的Manifest时，版本与响应的ETag一致
+		Manifest Manifest
+		// 构建工具生成的原文件与带hash的文件的对应（如 app.js: js/app.3f2a9c1d.js ），
+		// 优先于Manifest，可使用NewAssetMap生成
+		Assets map[string]string
+		// 版本参数的名称，默认为v
+		QueryKey string
+	}
+	// AssetURLs the helper of cache-busted asset url
+	AssetURLs struct {
+		prefix   string
+		manifest Manifest
+		assets   map[string]string
+		queryKey string
+	}
+)
+
+// NewAssetURLs create an asset url helper
+func NewAssetURLs(config AssetURLConfig) *AssetURLs {
+	queryKey := config.QueryKey
+	if queryKey == "" {
+		queryKey = "v"
+	}
+	assets := make(map[string]string, len(config.Assets))
+	for name, file := range config.Assets {
+		if file != "" {
+			assets[toFSPath(name)] = toFSPath(file)
+		}
+	}
+	return &AssetURLs{
+		prefix:   "/" + strings.Trim(config.Prefix, "/"),
+		manifest: config.Manifest,
+		assets:   assets,
+		queryKey: queryKey,
+	}
+}
+
+// getETagVersion get the version of etag, the weak indicator and quotes are removed
+func getETagVersion(eTag string) string {
+	return strings.Trim(strings.TrimPrefix(eTag, "W/"), `"`)
+}
+
+// AssetURL get the url of asset, the fingerprinted file of Assets is preferred, and then
+// the etag of Manifest is used as the version. The url without version is returned
+// if the file is not found in both of them.
+func (a *AssetURLs) AssetURL(file string) string {
+	file = toFSPath(file)
+	if fingerprinted, ok := a.assets[file]; ok {
+		return path.Join(a.prefix, fingerprinted)
+	}
+	result := path.Join(a.prefix, file)
+	entry, ok := a.manifest[file]
+	if !ok || entry == nil {
+		return result
+	}
+	version := getETagVersion(entry.ETag)
+	if version == "" {
+		return result
+	}
+	return result + "?" + url.QueryEscape(a.queryKey) + "=" + url.QueryEscape(version)
+}
+
+// FuncMap get the func map of template, the name of function is asset,
+// such as {{asset "app.css"}}
+func (a *AssetURLs) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset": a.AssetURL,
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetETagVersion(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("abcd", getETagVersion(`"abcd"`))
+	assert.Equal("400-5cfb1ad2", getETagVersion(`W/"400-5cfb1ad2"`))
+	assert.Equal("", getETagVersion(""))
+}
+
+func TestAssetURL(t *testing.T) {
+	assert := assert.New(t)
+	m, err := NewManifest(bytes.NewBufferString(`{
+		"css/app.css": {"etag": "abcd"},
+		"js/lib.js": {"etag": "W/\"1-2\""},
+		"img/logo.png": {"size": 10}
+	}`))
+	assert.Nil(err)
+	assets := NewAssetURLs(AssetURLConfig{
+		Prefix:   "/static/",
+		Manifest: m,
+		Assets: map[string]string{
+			"app.js": "js/app.3f2a9c1d.js",
+		},
+	})
+	assert.Equal("/static/js/app.3f2a9c1d.js", assets.AssetURL("app.js"))
+	assert.Equal("/static/js/app.3f2a9c1d.js", assets.AssetURL("/app.js"))
+	assert.Equal("/static/css/app.css?v=abcd", assets.AssetURL("/css/app.css"))
+	assert.Equal("/static/js/lib.js?v=1-2", assets.AssetURL("js/lib.js"))
+	// 无etag或不在manifest中
+	assert.Equal("/static/img/logo.png", assets.AssetURL("img/logo.png"))
+	assert.Equal("/static/index.html", assets.AssetURL("index.html"))
+
+	assets = NewAssetURLs(AssetURLConfig{
+		Manifest: m,
+		QueryKey: "hash",
+	})
+	assert.Equal("/css/app.css?hash=abcd", assets.AssetURL("css/app.css"))
+
+	tpl := template.Must(template.New("").Funcs(assets.FuncMap()).Parse(`<link href="{{asset "css/app.css"}}">`))
+	buf := &bytes.Buffer{}
+	assert.Nil(tpl.Execute(buf, nil))
+	assert.Equal(`<link href="/css/app.css?hash=abcd">`, buf.String())
+}
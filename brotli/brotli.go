@@ -0,0 +1,64 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package brotli provides the brotli compressor of runtime compression,
+// the quality is limited because the higher quality is too slow for each request
+package brotli
+
+import (
+	"bytes"
+
+	"github.com/andybalholm/brotli"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+// DefaultQuality the default quality of brotli, the higher quality is too slow for runtime compression
+const DefaultQuality = 4
+
+type compressor struct {
+	quality int
+}
+
+// NewCompressor create a brotli compressor, the default quality is used if quality is 0
+func NewCompressor(quality int) staticServe.Compressor {
+	if quality <= 0 {
+		quality = DefaultQuality
+	}
+	if quality > brotli.BestCompression {
+		quality = brotli.BestCompression
+	}
+	return &compressor{
+		quality: quality,
+	}
+}
+
+// Encoding get the encoding of brotli
+func (bc *compressor) Encoding() string {
+	return "br"
+}
+
+// Compress compress the data by brotli
+func (bc *compressor) Compress(buf []byte) ([]byte, error) {
+	b := bytes.NewBuffer(make([]byte, 0, len(buf)/2))
+	w := brotli.NewWriterLevel(b, bc.quality)
+	_, err := w.Write(buf)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
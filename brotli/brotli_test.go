@@ -0,0 +1,55 @@
+package brotli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+func TestCompressor(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(DefaultQuality, NewCompressor(0).(*compressor).quality)
+	assert.Equal(brotli.BestCompression, NewCompressor(20).(*compressor).quality)
+
+	c := NewCompressor(0)
+	assert.Equal("br", c.Encoding())
+	data := strings.Repeat("hello world", 100)
+	buf, err := c.Compress([]byte(data))
+	assert.Nil(err)
+	assert.True(len(buf) < len(data))
+	result, _ := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(buf)))
+	assert.Equal(data, string(result))
+}
+
+func TestServeBrotli(t *testing.T) {
+	assert := assert.New(t)
+	data := strings.Repeat("console.log('app');", 100)
+	sf := staticServe.NewIOFS(fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data: []byte(data),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", staticServe.New(sf, staticServe.Config{
+		Compressors: []staticServe.Compressor{
+			NewCompressor(0),
+			staticServe.NewGzipCompressor(0),
+		},
+	}))
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip, deflate, br")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("br", resp.Header().Get(elton.HeaderContentEncoding))
+	result, _ := ioutil.ReadAll(brotli.NewReader(resp.Body))
+	assert.Equal(data, string(result))
+}
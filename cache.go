@@ -0,0 +1,117 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type (
+	contentCacheItem struct {
+		modTime time.Time
+		// 文件的大小（Stat获取）
+		fileSize int64
+		buf      []byte
+		eTag     string
+		// 添加至缓存的时间
+		addedAt time.Time
+	}
+	// ContentCache lru cache for file's content, the total size of contents
+	// will not exceed the max size
+	ContentCache struct {
+		maxSize int
+		hits    uint64
+		misses  uint64
+		lru     *lru
+	}
+	// ContentCacheStats stats of content cache
+	ContentCacheStats struct {
+		// 命中次数
+		Hits uint64 `json:"hits"`
+		// 未命中次数
+		Misses uint64 `json:"misses"`
+		// 缓存的文件数
+		Count int `json:"count"`
+		// 缓存的内容总大小（字节）
+		Size int `json:"size"`
+	}
+)
+
+// NewContentCache create a content cache, the max size is the limit of total contents
+func NewContentCache(maxSize int) *ContentCache {
+	return &ContentCache{
+		maxSize: maxSize,
+		lru:     newLRU(maxSize),
+	}
+}
+
+// Get get the content and etag of file, the cache is valid only when
+// the mod time and size are equal
+func (cc *ContentCache) Get(file string, modTime time.Time, size int64) ([]byte, string, bool) {
+	item, ok := cc.get(file, modTime, size)
+	if !ok {
+		return nil, "", false
+	}
+	return item.buf, item.eTag, true
+}
+
+// get get the cache item of file, the cache is valid only when the mod time and size are equal
+func (cc *ContentCache) get(file string, modTime time.Time, size int64) (*contentCacheItem, bool) {
+	// 文件已修改，删除缓存
+	v, ok := cc.lru.get(file, func(value interface{}) bool {
+		item := value.(*contentCacheItem)
+		return item.modTime.Equal(modTime) && item.fileSize == size
+	})
+	if !ok {
+		atomic.AddUint64(&cc.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&cc.hits, 1)
+	return v.(*contentCacheItem), true
+}
+
+// Add add the content and etag(it can be empty) of file to cache,
+// the mod time and size of file are used to validate the cache
+func (cc *ContentCache) Add(file string, modTime time.Time, fileSize int64, buf []byte, eTag string) {
+	size := len(buf)
+	// 限制cap，避免使用方append时修改共享的数据
+	cc.lru.add(file, &contentCacheItem{
+		modTime:  modTime,
+		fileSize: fileSize,
+		buf:      buf[:size:size],
+		eTag:     eTag,
+		addedAt:  time.Now(),
+	}, size)
+}
+
+// Stats get the stats of content cache
+func (cc *ContentCache) Stats() ContentCacheStats {
+	count, size := cc.lru.stats()
+	return ContentCacheStats{
+		Hits:   atomic.LoadUint64(&cc.hits),
+		Misses: atomic.LoadUint64(&cc.misses),
+		Count:  count,
+		Size:   size,
+	}
+}
+
+// Remove remove the cache of file, the variants of file(such as compressed)
+// and the files in the directory are also removed
+func (cc *ContentCache) Remove(file string) {
+	cc.lru.removeKeys(func(key string) bool {
+		return isCacheKeyOf(key, file)
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+type (
+	// CacheControlRule the cache control rule of path
+	CacheControlRule struct {
+		// 路径匹配：不包含/的glob匹配文件名（如 *.html ），包含/的glob匹配完整路径，
+		// 无glob字符的则为路径前缀（如 /assets/ ）
+		Pattern string
+		// 正则匹配完整路径，与Pattern任一匹配即可
+		Regexp *regexp.Regexp
+		// 匹配时使用的Cache-Control，如 no-cache，为空则不设置
+		CacheControl string
+	}
+)
+
+// match check the file(url path) matches the rule
+func (rule *CacheControlRule) match(file string) bool {
+	if rule.Regexp != nil && rule.Regexp.MatchString(file) {
+		return true
+	}
+	return matchPathPattern(rule.Pattern, file)
+}
+
+// matchPathPattern check the file(url path) matches the pattern, the glob pattern
+// without / matches the base name, the glob with / matches the whole path,
+// and the pattern without glob characters matches the prefix of path
+func matchPathPattern(pattern, file string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.HasPrefix(file, pattern)
+	}
+	name := file
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(file)
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// getRuleCacheControl get the cache control of the first matched rule
+func getRuleCacheControl(rules []CacheControlRule, file string) (string, bool) {
+	for i := range rules {
+		if rules[i].match(file) {
+			return rules[i].CacheControl, true
+		}
+	}
+	return "", false
+}
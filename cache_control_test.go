@@ -0,0 +1,81 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestCacheControlRule(t *testing.T) {
+	assert := assert.New(t)
+	rules := []CacheControlRule{
+		{
+			Pattern:      "*.html",
+			CacheControl: "no-cache",
+		},
+		{
+			Pattern:      "/assets/",
+			CacheControl: "public, max-age=31536000",
+		},
+		{
+			Pattern:      "/images/*.png",
+			CacheControl: "public, max-age=3600",
+		},
+		{
+			Regexp:       regexp.MustCompile(`\.map$`),
+			CacheControl: "private",
+		},
+		{},
+	}
+	cases := []struct {
+		file         string
+		cacheControl string
+		matched      bool
+	}{
+		{"/index.html", "no-cache", true},
+		{"/assets/index.html", "no-cache", true},
+		{"/assets/js/app.js", "public, max-age=31536000", true},
+		{"/images/banner.png", "public, max-age=3600", true},
+		{"/images/icons/banner.png", "", false},
+		{"/js/app.js.map", "private", true},
+		{"/js/app.js", "", false},
+	}
+	for _, item := range cases {
+		cacheControl, matched := getRuleCacheControl(rules, item.file)
+		assert.Equal(item.matched, matched, item.file)
+		assert.Equal(item.cacheControl, cacheControl, item.file)
+	}
+}
+
+func TestServeCacheControlRules(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:   staticPath,
+		MaxAge: 60,
+		CacheControlRules: []CacheControlRule{
+			{
+				Pattern:      "*.html",
+				CacheControl: "no-cache",
+			},
+			{
+				Pattern: "/private/",
+			},
+		},
+	}))
+	cases := map[string]string{
+		"/":                 "no-cache",
+		"/index.html":       "no-cache",
+		"/banner.jpg":       "public, max-age=60",
+		"/private/user.jpg": "",
+	}
+	for url, cacheControl := range cases {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(cacheControl, resp.Header().Get(elton.HeaderCacheControl), url)
+	}
+}
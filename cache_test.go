@@ -0,0 +1,130 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestContentCache(t *testing.T) {
+	assert := assert.New(t)
+	modTime := time.Now()
+	cc := NewContentCache(10)
+
+	cc.Add("a", modTime, 4, []byte("abcd"), `"4-abcd"`)
+	cc.Add("b", modTime, 4, []byte("efgh"), "")
+	buf, eTag, ok := cc.Get("a", modTime, 4)
+	assert.True(ok)
+	assert.Equal("abcd", string(buf))
+	assert.Equal(`"4-abcd"`, eTag)
+	assert.Equal(4, cap(buf))
+
+	// 超过限制，淘汰最少使用的b
+	cc.Add("c", modTime, 4, []byte("ijkl"), "")
+	_, _, ok = cc.Get("b", modTime, 4)
+	assert.False(ok)
+	_, _, ok = cc.Get("a", modTime, 4)
+	assert.True(ok)
+	assert.Equal(8, cc.Stats().Size)
+
+	// 修改时间不一致则失效
+	_, _, ok = cc.Get("a", modTime.Add(time.Second), 4)
+	assert.False(ok)
+	_, _, ok = cc.Get("a", modTime, 4)
+	assert.False(ok)
+	assert.Equal(4, cc.Stats().Size)
+
+	// 大小不一致则失效
+	_, _, ok = cc.Get("c", modTime, 5)
+	assert.False(ok)
+	assert.Equal(0, cc.Stats().Size)
+
+	// 超过总大小的不缓存
+	cc.Add("d", modTime, 11, []byte("01234567890"), "")
+	_, _, ok = cc.Get("d", modTime, 11)
+	assert.False(ok)
+
+	// 重复添加替换原有数据
+	cc.Add("c", modTime, 4, []byte("ijkl"), "")
+	cc.Add("c", modTime, 2, []byte("xy"), "")
+	buf, _, _ = cc.Get("c", modTime, 2)
+	assert.Equal("xy", string(buf))
+	assert.Equal(2, cc.Stats().Size)
+
+	assert.Equal(ContentCacheStats{
+		Hits:   3,
+		Misses: 5,
+		Count:  1,
+		Size:   2,
+	}, cc.Stats())
+}
+
+func TestContentCacheConcurrent(t *testing.T) {
+	cc := NewContentCache(100)
+	modTime := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cc.Add("a", modTime, 4, []byte("abcd"), "")
+				cc.Get("a", modTime, 4)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestContentCacheRemove(t *testing.T) {
+	assert := assert.New(t)
+	cc := NewContentCache(100)
+	modTime := time.Now()
+	cc.Add("/local/app.js", modTime, 4, []byte("abcd"), "")
+	cc.Add("/local/app.js:gzip", modTime, 4, []byte("ab"), "")
+	cc.Add("/local/app.json", modTime, 4, []byte("efgh"), "")
+	cc.Add("/local/js/index.js", modTime, 4, []byte("ijkl"), "")
+
+	cc.Remove("/local/app.js")
+	_, _, ok := cc.Get("/local/app.js", modTime, 4)
+	assert.False(ok)
+	_, _, ok = cc.Get("/local/app.js:gzip", modTime, 4)
+	assert.False(ok)
+	_, _, ok = cc.Get("/local/app.json", modTime, 4)
+	assert.True(ok)
+
+	// 删除目录下的所有文件
+	cc.Remove("/local/js")
+	_, _, ok = cc.Get("/local/js/index.js", modTime, 4)
+	assert.False(ok)
+	assert.Equal(4, cc.Stats().Size)
+}
+
+func TestServeCacheStatus(t *testing.T) {
+	assert := assert.New(t)
+	cache := NewContentCache(1024)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:              staticPath,
+		ContentCache:      cache,
+		CacheStatusHeader: "X-Cache",
+		EnableCacheAge:    true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("MISS", resp.Header().Get("X-Cache"))
+	assert.Empty(resp.Header().Get(HeaderAge))
+
+	// 修改添加至缓存的时间
+	cache.lru.items[staticPath+"/index.html"].Value.(*lruEntry).value.(*contentCacheItem).addedAt = time.Now().Add(-10 * time.Second)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("HIT", resp.Header().Get("X-Cache"))
+	assert.Equal("10", resp.Header().Get(HeaderAge))
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCaseIndexTTL the default ttl of directory index for case-insensitive matching
+const defaultCaseIndexTTL = time.Minute
+
+type (
+	caseIndexItem struct {
+		expiredAt time.Time
+		// 小写文件名对应的实际文件名
+		names map[string]string
+	}
+	// caseIndex the cache of directory index, it's used to resolve the file case-insensitively
+	caseIndex struct {
+		dirLister DirLister
+		ttl       time.Duration
+		mutex     sync.RWMutex
+		items     map[string]*caseIndexItem
+	}
+)
+
+// newCaseIndex create a case index
+func newCaseIndex(dirLister DirLister, ttl time.Duration) *caseIndex {
+	if ttl <= 0 {
+		ttl = defaultCaseIndexTTL
+	}
+	return &caseIndex{
+		dirLister: dirLister,
+		ttl:       ttl,
+		items:     make(map[string]*caseIndexItem),
+	}
+}
+
+// lookup get the actual name of the file in dir
+func (ci *caseIndex) lookup(dir, name string) (string, bool) {
+	ci.mutex.RLock()
+	item, ok := ci.items[dir]
+	ci.mutex.RUnlock()
+	now := time.Now()
+	if !ok || now.After(item.expiredAt) {
+		infos, err := ci.dirLister.ReadDir(dir)
+		if err != nil {
+			return "", false
+		}
+		item = &caseIndexItem{
+			expiredAt: now.Add(ci.ttl),
+			names:     make(map[string]string, len(infos)),
+		}
+		for _, info := range infos {
+			key := strings.ToLower(info.Name())
+			// 多个文件名仅大小写不同时，使用首个文件
+			if _, exists := item.names[key]; !exists {
+				item.names[key] = info.Name()
+			}
+		}
+		ci.mutex.Lock()
+		ci.items[dir] = item
+		ci.mutex.Unlock()
+	}
+	actual, ok := item.names[strings.ToLower(name)]
+	return actual, ok
+}
+
+// resolve resolve the file(in root) case-insensitively, the original file is returned
+// if any part of path can't be resolved
+func (ci *caseIndex) resolve(root, file string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == "." {
+		return file
+	}
+	result := root
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		actual, ok := ci.lookup(result, name)
+		if !ok {
+			return file
+		}
+		result = filepath.Join(result, actual)
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestCaseIndex(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "Images"), 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "Images", "Logo.PNG"), []byte("logo"), 0644))
+
+	ci := newCaseIndex(&FS{}, 0)
+	assert.Equal(defaultCaseIndexTTL, ci.ttl)
+	assert.Equal(filepath.Join(root, "Images/Logo.PNG"), ci.resolve(root, filepath.Join(root, "images/logo.png")))
+	assert.Equal(filepath.Join(root, "Images"), ci.resolve(root, filepath.Join(root, "IMAGES")))
+	assert.Equal(filepath.Join(root, "images/banner.png"), ci.resolve(root, filepath.Join(root, "images/banner.png")))
+	assert.Equal(root, ci.resolve(root, root))
+
+	// 目录列表已缓存
+	assert.Nil(os.WriteFile(filepath.Join(root, "App.js"), []byte("app"), 0644))
+	assert.Equal(filepath.Join(root, "app.js"), ci.resolve(root, filepath.Join(root, "app.js")))
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "Images"), 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "Images", "Logo.PNG"), []byte("logo"), 0644))
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:            root,
+		CaseInsensitive: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/images/logo.png", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("logo", resp.Body.String())
+	assert.Equal("image/png", resp.Header().Get(elton.HeaderContentType))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/images/banner.png", nil))
+	assert.Equal(404, resp.Code)
+}
+
+func TestCaseInsensitiveRules(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "internal"), 0755))
+	assert.Nil(os.MkdirAll(filepath.Join(root, "private"), 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "internal", "secret.txt"), []byte("secret"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "private", "report.pdf"), []byte("report"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "staticserve.json"), []byte("{}"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "a.txt.meta.json"), []byte("{}"), 0644))
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:            root,
+		CaseInsensitive: true,
+		IPRules: []IPRule{
+			{
+				Pattern: "/internal/",
+				Allow:   []string{"10.0.0.0/8"},
+			},
+		},
+		SignedURL: &SignedURLConfig{
+			Secret:   []byte("secret"),
+			Patterns: []string{"/private/"},
+		},
+		DirConfigFile:  "staticserve.json",
+		EnableFileMeta: true,
+	}))
+	// 使用大写的路径也需要满足访问规则
+	for file, code := range map[string]int{
+		"/INTERNAL/secret.txt": 403,
+		"/PRIVATE/report.pdf":  403,
+		"/STATICSERVE.JSON":    404,
+		"/A.TXT.META.JSON":     404,
+		"/A.TXT":               200,
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+		assert.Equal(code, resp.Code, file)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"sync"
+)
+
+const defaultCompressMinLength = 1024
+
+// defaultCompressContentTypes the content types which are compressible
+var defaultCompressContentTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/json",
+	"application/manifest+json",
+	"application/wasm",
+	"application/xml",
+	"image/svg+xml",
+}
+
+type (
+	// Compressor compressor of response data
+	Compressor interface {
+		// Encoding get the content encoding, such as gzip
+		Encoding() string
+		// Compress compress the data
+		Compress(buf []byte) ([]byte, error)
+	}
+	gzipCompressor struct {
+		level int
+		pool  sync.Pool
+	}
+)
+
+// NewGzipCompressor create a gzip compressor, the default compression is used if level is 0
+func NewGzipCompressor(level int) Compressor {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return &gzipCompressor{
+		level: level,
+	}
+}
+
+// Encoding get the encoding of gzip
+func (g *gzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+// Compress compress the data by gzip
+func (g *gzipCompressor) Compress(buf []byte) ([]byte, error) {
+	b := bytes.NewBuffer(make([]byte, 0, len(buf)/2))
+	var w *gzip.Writer
+	if v := g.pool.Get(); v != nil {
+		w = v.(*gzip.Writer)
+		w.Reset(b)
+	} else {
+		var err error
+		w, err = gzip.NewWriterLevel(b, g.level)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer g.pool.Put(w)
+	_, err := w.Write(buf)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// getCompressor get the compressor which is accepted by client, the encoding
+// of higher quality is preferred, and then the order of compressors
+func getCompressor(compressors []Compressor, acceptEncoding string) Compressor {
+	encodings := make([]string, len(compressors))
+	for i, compressor := range compressors {
+		encodings[i] = compressor.Encoding()
+	}
+	encoding := NegotiateEncoding(acceptEncoding, encodings)
+	if encoding == "" {
+		return nil
+	}
+	for _, compressor := range compressors {
+		if compressor.Encoding() == encoding {
+			return compressor
+		}
+	}
+	return nil
+}
+
+// appendETagEncoding append the encoding to etag, the etag of different
+// encoding should be different
+func appendETagEncoding(eTag, encoding string) string {
+	if !strings.HasSuffix(eTag, `"`) || len(eTag) < 2 {
+		return eTag
+	}
+	return eTag[:len(eTag)-1] + "-" + encoding + `"`
+}
@@ -0,0 +1,207 @@
+package staticserve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+type mockCompressor struct {
+	encoding string
+}
+
+func (m *mockCompressor) Encoding() string {
+	return m.encoding
+}
+
+func (m *mockCompressor) Compress(buf []byte) ([]byte, error) {
+	return []byte(m.encoding), nil
+}
+
+func TestGzipCompressor(t *testing.T) {
+	assert := assert.New(t)
+	compressor := NewGzipCompressor(0)
+	assert.Equal("gzip", compressor.Encoding())
+	data := strings.Repeat("hello world", 100)
+	for i := 0; i < 2; i++ {
+		buf, err := compressor.Compress([]byte(data))
+		assert.Nil(err)
+		assert.True(len(buf) < len(data))
+		r, err := gzip.NewReader(bytes.NewReader(buf))
+		assert.Nil(err)
+		result, _ := ioutil.ReadAll(r)
+		assert.Equal(data, string(result))
+	}
+
+	_, err := NewGzipCompressor(100).Compress([]byte(data))
+	assert.NotNil(err)
+}
+
+func TestGetCompressor(t *testing.T) {
+	assert := assert.New(t)
+	gzipCompressor := &mockCompressor{encoding: "gzip"}
+	brCompressor := &mockCompressor{encoding: "br"}
+	compressors := []Compressor{
+		brCompressor,
+		gzipCompressor,
+	}
+	assert.Nil(getCompressor(compressors, ""))
+	assert.Equal(brCompressor, getCompressor(compressors, "gzip, br"))
+	assert.Equal(gzipCompressor, getCompressor(compressors, "gzip, br;q=0.8"))
+	assert.Equal(gzipCompressor, getCompressor(compressors, "gzip"))
+}
+
+func TestAppendETagEncoding(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`"abc-gzip"`, appendETagEncoding(`"abc"`, "gzip"))
+	assert.Equal(`W/"a-b-br"`, appendETagEncoding(`W/"a-b"`, "br"))
+	assert.Equal("abc", appendETagEncoding("abc", "gzip"))
+}
+
+func TestIsContentTypeMatchedWildcard(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isContentTypeMatched("text/css; charset=utf-8", defaultCompressContentTypes))
+	assert.True(isContentTypeMatched("image/svg+xml", defaultCompressContentTypes))
+	assert.False(isContentTypeMatched("image/png", defaultCompressContentTypes))
+	assert.False(isContentTypeMatched("text", defaultCompressContentTypes))
+}
+
+func TestServeCompress(t *testing.T) {
+	assert := assert.New(t)
+	data := strings.Repeat("console.log('app');", 100)
+	sf := NewIOFS(fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data: []byte(data),
+		},
+		"small.js": &fstest.MapFile{
+			Data: []byte("console.log('small')"),
+		},
+		"banner.png": &fstest.MapFile{
+			Data: []byte(data),
+		},
+	})
+	e := elton.New()
+	fn := New(sf, Config{
+		Compressors: []Compressor{
+			NewGzipCompressor(0),
+		},
+	})
+	e.GET("/*file", fn)
+	e.HEAD("/*file", fn)
+
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip, br")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("gzip", resp.Header().Get(elton.HeaderContentEncoding))
+	assert.Equal("Accept-Encoding", resp.Header().Get(HeaderVary))
+	assert.True(strings.HasSuffix(resp.Header().Get(elton.HeaderETag), `-gzip"`))
+	assert.Equal(strconv.Itoa(resp.Body.Len()), resp.Header().Get(elton.HeaderContentLength))
+	r, err := gzip.NewReader(resp.Body)
+	assert.Nil(err)
+	buf, _ := ioutil.ReadAll(r)
+	assert.Equal(data, string(buf))
+	gzipETag := resp.Header().Get(elton.HeaderETag)
+
+	// 压缩数据的etag匹配返回304
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	req.Header.Set(elton.HeaderIfNoneMatch, gzipETag)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(304, resp.Code)
+
+	// 客户端不支持压缩
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Empty(resp.Header().Get(elton.HeaderContentEncoding))
+	assert.Equal("Accept-Encoding", resp.Header().Get(HeaderVary))
+	assert.NotEqual(gzipETag, resp.Header().Get(elton.HeaderETag))
+	assert.Equal(data, resp.Body.String())
+
+	// HEAD请求不设置长度
+	req = httptest.NewRequest("HEAD", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("gzip", resp.Header().Get(elton.HeaderContentEncoding))
+	assert.Empty(resp.Header().Get(elton.HeaderContentLength))
+
+	// 数据过小或类型不匹配则不压缩
+	for _, file := range []string{"/small.js", "/banner.png"} {
+		req = httptest.NewRequest("GET", file, nil)
+		req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Empty(resp.Header().Get(elton.HeaderContentEncoding))
+		assert.Empty(resp.Header().Get(HeaderVary))
+	}
+}
+
+type countCompressor struct {
+	Compressor
+	count int
+}
+
+func (cc *countCompressor) Compress(buf []byte) ([]byte, error) {
+	cc.count++
+	return cc.Compressor.Compress(buf)
+}
+
+func TestServeCompressCache(t *testing.T) {
+	assert := assert.New(t)
+	data := strings.Repeat("console.log('app');", 100)
+	fsys := fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data:    []byte(data),
+			ModTime: time.Unix(1600000000, 0),
+		},
+	}
+	compressor := &countCompressor{
+		Compressor: NewGzipCompressor(0),
+	}
+	e := elton.New()
+	e.GET("/*file", New(NewIOFS(fsys), Config{
+		EnableStrongETag:  true,
+		Compressors:       []Compressor{compressor},
+		CompressCacheSize: 10 * 1024,
+	}))
+	eTag := appendETagEncoding(generateETag([]byte(data)), "gzip")
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/app.js", nil)
+		req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("gzip", resp.Header().Get(elton.HeaderContentEncoding))
+		assert.Equal(eTag, resp.Header().Get(elton.HeaderETag))
+		r, err := gzip.NewReader(resp.Body)
+		assert.Nil(err)
+		buf, _ := ioutil.ReadAll(r)
+		assert.Equal(data, string(buf))
+	}
+	assert.Equal(1, compressor.count)
+
+	// 文件修改后重新压缩
+	data = strings.Repeat("console.log('new');", 100)
+	fsys["app.js"].Data = []byte(data)
+	fsys["app.js"].ModTime = time.Unix(1600000001, 0)
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(2, compressor.count)
+	assert.Equal(appendETagEncoding(generateETag([]byte(data)), "gzip"), resp.Header().Get(elton.HeaderETag))
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vicanso/elton"
+)
+
+// HeaderRetryAfter retry after
+const HeaderRetryAfter = "Retry-After"
+
+// ErrTooManyConcurrency the concurrency of serving files exceeds the limit
+var ErrTooManyConcurrency = getStaticServeError("too many concurrent requests of static file", http.StatusServiceUnavailable)
+
+type (
+	// concurrencyLimiter limit the concurrency of serving files
+	concurrencyLimiter struct {
+		ch   chan struct{}
+		wait time.Duration
+	}
+	// releaseCloser close the closer and release the limiter
+	releaseCloser struct {
+		closer  io.Closer
+		release func()
+	}
+)
+
+// newConcurrencyLimiter new a concurrency limiter, the request waits
+// for the wait duration at most if the concurrency exceeds max
+func newConcurrencyLimiter(max int, wait time.Duration) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		ch:   make(chan struct{}, max),
+		wait: wait,
+	}
+}
+
+// acquire acquire a slot of limiter, it returns false if the slot is not available
+// before the wait timeout or the context is done. The release function can be called
+// more than once.
+func (l *concurrencyLimiter) acquire(ctx context.Context) (func(), bool) {
+	release := func() {
+		<-l.ch
+	}
+	select {
+	case l.ch <- struct{}{}:
+	default:
+		if l.wait <= 0 {
+			return nil, false
+		}
+		timer := time.NewTimer(l.wait)
+		defer timer.Stop()
+		select {
+		case l.ch <- struct{}{}:
+		case <-timer.C:
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+	once := sync.Once{}
+	return func() {
+		once.Do(release)
+	}, true
+}
+
+// Close close the original closer and release the limiter
+func (rc *releaseCloser) Close() error {
+	defer rc.release()
+	if rc.closer == nil {
+		return nil
+	}
+	return rc.closer.Close()
+}
+
+// releaseAfterServe release the limiter after the response is served, the reader body
+// is wrapped to release on close(after the data is piped to response). The reader body
+// isn't piped if the outer middleware returns error or replaces the body, so the limiter
+// is also released when the context of request is done.
+func releaseAfterServe(c *elton.Context, release func(), err error) {
+	if err != nil || !c.IsReaderBody() {
+		release()
+		return
+	}
+	released := make(chan struct{})
+	once := sync.Once{}
+	releaseOnce := func() {
+		once.Do(func() {
+			release()
+			close(released)
+		})
+	}
+	r, _ := c.Body.(io.Reader)
+	closer, _ := r.(io.Closer)
+	c.Body = &readCloser{
+		Reader: r,
+		closer: &releaseCloser{
+			closer:  closer,
+			release: releaseOnce,
+		},
+	}
+	// 请求结束时（net/http在处理完成后取消context）确保释放
+	done := c.Context().Done()
+	if done == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-done:
+			releaseOnce()
+		case <-released:
+		}
+	}()
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	assert := assert.New(t)
+	l := newConcurrencyLimiter(1, 0)
+	release, ok := l.acquire(context.Background())
+	assert.True(ok)
+	_, ok = l.acquire(context.Background())
+	assert.False(ok)
+	release()
+	// 多次调用仅释放一次
+	release()
+	release, ok = l.acquire(context.Background())
+	assert.True(ok)
+	assert.Equal(1, len(l.ch))
+
+	// 等待释放
+	l.wait = time.Second
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	release, ok = l.acquire(context.Background())
+	assert.True(ok)
+
+	// 等待超时
+	l.wait = 10 * time.Millisecond
+	_, ok = l.acquire(context.Background())
+	assert.False(ok)
+
+	// context取消
+	l.wait = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, ok = l.acquire(ctx)
+	assert.False(ok)
+	release()
+	assert.Equal(0, len(l.ch))
+}
+
+func TestReleaseAfterServe(t *testing.T) {
+	assert := assert.New(t)
+	count := 0
+	release := func() {
+		count++
+	}
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	releaseAfterServe(c, release, nil)
+	assert.Equal(1, count)
+
+	c.Body = strings.NewReader("abcd")
+	releaseAfterServe(c, release, errors.New("error"))
+	assert.Equal(2, count)
+
+	releaseAfterServe(c, release, nil)
+	assert.Equal(2, count)
+	r, _ := c.Body.(io.Reader)
+	buf, _ := ioutil.ReadAll(r)
+	assert.Equal("abcd", string(buf))
+	closer, ok := c.Body.(io.Closer)
+	assert.True(ok)
+	assert.Nil(closer.Close())
+	assert.Equal(3, count)
+}
+
+func TestReleaseAfterServeContextDone(t *testing.T) {
+	assert := assert.New(t)
+	releasedCh := make(chan struct{}, 2)
+	release := func() {
+		releasedCh <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	c.Body = strings.NewReader("abcd")
+	releaseAfterServe(c, release, nil)
+	assert.Equal(0, len(releasedCh))
+	// reader未关闭，请求结束时释放
+	cancel()
+	select {
+	case <-releasedCh:
+	case <-time.After(time.Second):
+		assert.Fail("limiter is not released")
+	}
+	closer, _ := c.Body.(io.Closer)
+	assert.Nil(closer.Close())
+	assert.Equal(0, len(releasedCh))
+}
+
+func TestServeConcurrencyOuterError(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.Use(func(c *elton.Context) error {
+		err := c.Next()
+		if err != nil {
+			return err
+		}
+		return errors.New("outer error")
+	})
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:            staticPath,
+		MaxConcurrency:  1,
+		ConcurrencyWait: time.Second,
+	}))
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil).WithContext(ctx))
+		cancel()
+		// 外层中间件出错时数据未返回，但并发限制仍需释放
+		assert.Equal(500, resp.Code)
+		assert.Equal("outer error", resp.Body.String())
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vicanso/elton"
+)
+
+const (
+	// HeaderPragma pragma
+	HeaderPragma = "Pragma"
+	// HeaderIfRange if range
+	HeaderIfRange = "If-Range"
+	// HeaderIfMatch if match
+	HeaderIfMatch = "If-Match"
+	// HeaderIfUnmodifiedSince if unmodified since
+	HeaderIfUnmodifiedSince = "If-Unmodified-Since"
+)
+
+// isNoCacheRequest check the request is no-cache(Cache-Control: no-cache or Pragma: no-cache),
+// such as the hard refresh of browser
+func isNoCacheRequest(header http.Header) bool {
+	for _, key := range []string{
+		elton.HeaderCacheControl,
+		HeaderPragma,
+	} {
+		for _, value := range header[key] {
+			for _, item := range strings.Split(value, ",") {
+				if strings.EqualFold(strings.TrimSpace(item), "no-cache") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// getModifiedTime get the modified time of file, it's truncated to second(the resolution of http date)
+// and in UTC, it returns false if the modified time is zero or unix epoch
+func getModifiedTime(info os.FileInfo) (time.Time, bool) {
+	if info == nil {
+		return time.Time{}, false
+	}
+	modTime := info.ModTime()
+	if isZeroTime(modTime) {
+		return time.Time{}, false
+	}
+	return modTime.Truncate(time.Second).UTC(), true
+}
+
+// checkIfRange check the range request should be served(no If-Range or
+// the If-Range matches the validator of response), the etag of If-Range
+// should be strong and the date should exactly match the last modified
+func checkIfRange(reqHeader, header http.Header) bool {
+	ifRange := strings.TrimSpace(reqHeader.Get(HeaderIfRange))
+	if ifRange == "" {
+		return true
+	}
+	// etag
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		eTag := header.Get(elton.HeaderETag)
+		if eTag == "" || strings.HasPrefix(eTag, "W/") || strings.HasPrefix(ifRange, "W/") {
+			return false
+		}
+		return ifRange == eTag
+	}
+	lastModified := header.Get(elton.HeaderLastModified)
+	if lastModified == "" {
+		return false
+	}
+	t, err := http.ParseTime(ifRange)
+	if err != nil {
+		return false
+	}
+	modTime, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return t.Equal(modTime)
+}
+
+// ParseETags parse the comma-separated etag list(such as the value of If-None-Match),
+// the entity tag may contain comma, the invalid entity tag and which after it are ignored
+func ParseETags(value string) []string {
+	var eTags []string
+	for {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			return eTags
+		}
+		prefix := ""
+		if strings.HasPrefix(value, "W/") {
+			prefix = "W/"
+			value = value[2:]
+		}
+		if !strings.HasPrefix(value, `"`) {
+			return eTags
+		}
+		end := strings.Index(value[1:], `"`)
+		if end < 0 {
+			return eTags
+		}
+		end += 2
+		eTags = append(eTags, prefix+value[:end])
+		value = value[end:]
+	}
+}
+
+// WeakETagMatch check the two entity tags match with the weak comparison,
+// the weak indicator(W/) is ignored
+func WeakETagMatch(a, b string) bool {
+	a = strings.TrimPrefix(a, "W/")
+	b = strings.TrimPrefix(b, "W/")
+	return a != "" && a == b
+}
+
+// StrongETagMatch check the two entity tags match with the strong comparison,
+// both of them should not be weak
+func StrongETagMatch(a, b string) bool {
+	if strings.HasPrefix(a, "W/") || strings.HasPrefix(b, "W/") {
+		return false
+	}
+	return a != "" && a == b
+}
+
+// MatchIfMatch check the etag matches the If-Match with the strong comparison,
+// "*" matches any current representation
+func MatchIfMatch(ifMatch, eTag string) bool {
+	if strings.TrimSpace(ifMatch) == "*" {
+		return true
+	}
+	if eTag == "" {
+		return false
+	}
+	for _, item := range ParseETags(ifMatch) {
+		if StrongETagMatch(item, eTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchIfNoneMatch check the etag matches the If-None-Match with the weak comparison,
+// "*" matches any current representation
+func MatchIfNoneMatch(ifNoneMatch, eTag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	if eTag == "" {
+		return false
+	}
+	for _, item := range ParseETags(ifNoneMatch) {
+		if WeakETagMatch(item, eTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotModified check the response is not modified for the conditional request(RFC 7232),
+// If-None-Match is preferred and If-Modified-Since is checked only when it's absent
+func IsNotModified(req *http.Request, header http.Header) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	reqHeader := req.Header
+	ifNoneMatch := strings.Join(reqHeader.Values(elton.HeaderIfNoneMatch), ",")
+	if strings.TrimSpace(ifNoneMatch) != "" {
+		return MatchIfNoneMatch(ifNoneMatch, header.Get(elton.HeaderETag))
+	}
+	ifModifiedSince := reqHeader.Get(elton.HeaderIfModifiedSince)
+	lastModified := header.Get(elton.HeaderLastModified)
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+	t, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modTime, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modTime.After(t)
+}
+
+// IsPreconditionFailed check the precondition of request doesn't hold(RFC 7232),
+// If-Match is preferred and If-Unmodified-Since is checked only when it's absent,
+// the invalid date of If-Unmodified-Since is ignored
+func IsPreconditionFailed(req *http.Request, header http.Header) bool {
+	reqHeader := req.Header
+	ifMatch := strings.Join(reqHeader.Values(HeaderIfMatch), ",")
+	if strings.TrimSpace(ifMatch) != "" {
+		return !MatchIfMatch(ifMatch, header.Get(elton.HeaderETag))
+	}
+	ifUnmodifiedSince := reqHeader.Get(HeaderIfUnmodifiedSince)
+	lastModified := header.Get(elton.HeaderLastModified)
+	if ifUnmodifiedSince == "" || lastModified == "" {
+		return false
+	}
+	t, err := http.ParseTime(ifUnmodifiedSince)
+	if err != nil {
+		return false
+	}
+	modTime, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return modTime.After(t)
+}
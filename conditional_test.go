@@ -0,0 +1,252 @@
+package staticserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestIsNoCacheRequest(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		key    string
+		value  string
+		result bool
+	}{
+		{elton.HeaderCacheControl, "no-cache", true},
+		{elton.HeaderCacheControl, "max-age=0, No-Cache", true},
+		{elton.HeaderCacheControl, "max-age=0", false},
+		{HeaderPragma, "no-cache", true},
+		{HeaderPragma, "", false},
+	}
+	for _, tt := range tests {
+		header := make(http.Header)
+		header.Set(tt.key, tt.value)
+		assert.Equal(tt.result, isNoCacheRequest(header))
+	}
+}
+
+type modTimeFileInfo struct {
+	MockFileStat
+	modTime time.Time
+}
+
+func (mf *modTimeFileInfo) ModTime() time.Time {
+	return mf.modTime
+}
+
+func TestGetModifiedTime(t *testing.T) {
+	assert := assert.New(t)
+	_, ok := getModifiedTime(nil)
+	assert.False(ok)
+
+	for _, modTime := range []time.Time{
+		{},
+		time.Unix(0, 0),
+	} {
+		_, ok = getModifiedTime(&modTimeFileInfo{
+			modTime: modTime,
+		})
+		assert.False(ok)
+	}
+
+	var info os.FileInfo = &modTimeFileInfo{
+		modTime: time.Unix(1559960274, 999999999).In(time.FixedZone("CST", 8*3600)),
+	}
+	modTime, ok := getModifiedTime(info)
+	assert.True(ok)
+	assert.Equal(time.Unix(1559960274, 0).UTC(), modTime)
+	assert.Equal(time.UTC, modTime.Location())
+}
+
+func TestCheckIfRange(t *testing.T) {
+	assert := assert.New(t)
+	lastModified := "Sat, 08 Jun 2019 02:17:54 GMT"
+	tests := []struct {
+		ifRange      string
+		eTag         string
+		lastModified string
+		result       bool
+	}{
+		{"", "", "", true},
+		{`"abc"`, `"abc"`, "", true},
+		{`"abc"`, `"abcd"`, "", false},
+		{`"abc"`, "", "", false},
+		{`W/"abc"`, `W/"abc"`, "", false},
+		{`"abc"`, `W/"abc"`, "", false},
+		{lastModified, "", lastModified, true},
+		{lastModified, "", "Sat, 08 Jun 2019 02:17:55 GMT", false},
+		{lastModified, "", "", false},
+		{"abc", "", lastModified, false},
+		{lastModified, "", "abc", false},
+	}
+	for _, tt := range tests {
+		reqHeader := make(http.Header)
+		reqHeader.Set(HeaderIfRange, tt.ifRange)
+		header := make(http.Header)
+		header.Set(elton.HeaderETag, tt.eTag)
+		header.Set(elton.HeaderLastModified, tt.lastModified)
+		assert.Equal(tt.result, checkIfRange(reqHeader, header), tt.ifRange)
+	}
+}
+
+func TestParseETags(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		value  string
+		result []string
+	}{
+		{"", nil},
+		{"*", nil},
+		{`"abc"`, []string{`"abc"`}},
+		{`"a", W/"b",  "c,d"`, []string{`"a"`, `W/"b"`, `"c,d"`}},
+		{` ,"a",,"b" `, []string{`"a"`, `"b"`}},
+		{`"a", b, "c"`, []string{`"a"`}},
+		{`"a", "b`, []string{`"a"`}},
+		{`W/b`, nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.result, ParseETags(tt.value), tt.value)
+	}
+}
+
+func TestWeakETagMatch(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		a      string
+		b      string
+		result bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`W/"a"`, `W/"a"`, true},
+		{`"a"`, `"b"`, false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.result, WeakETagMatch(tt.a, tt.b))
+	}
+}
+
+func TestMatchIfNoneMatch(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		ifNoneMatch string
+		eTag        string
+		result      bool
+	}{
+		{"*", "", true},
+		{" * ", `"a"`, true},
+		{`"a"`, "", false},
+		{`"a", "b"`, `W/"b"`, true},
+		{`W/"a", "b"`, `"a"`, true},
+		{`"a", "b"`, `"c"`, false},
+		{`"a,b"`, `"a"`, false},
+		{`"a,b"`, `"a,b"`, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.result, MatchIfNoneMatch(tt.ifNoneMatch, tt.eTag), tt.ifNoneMatch)
+	}
+}
+
+func TestIsNotModified(t *testing.T) {
+	assert := assert.New(t)
+	lastModified := "Sat, 08 Jun 2019 02:17:54 GMT"
+	tests := []struct {
+		method          string
+		ifNoneMatch     string
+		ifModifiedSince string
+		eTag            string
+		lastModified    string
+		result          bool
+	}{
+		{"GET", `"abc"`, "", `"abc"`, "", true},
+		{"HEAD", `W/"abc"`, "", `"abc"`, "", true},
+		{"POST", `"abc"`, "", `"abc"`, "", false},
+		{"GET", `"abc"`, "", `"abcd"`, "", false},
+		{"GET", `"abc"`, "", "", "", false},
+		// If-None-Match优先
+		{"GET", `"abc"`, lastModified, `"abcd"`, lastModified, false},
+		{"GET", "", lastModified, "", lastModified, true},
+		{"GET", "", "Sat, 08 Jun 2019 02:17:55 GMT", "", lastModified, true},
+		{"GET", "", "Sat, 08 Jun 2019 02:17:53 GMT", "", lastModified, false},
+		{"GET", "", "abc", "", lastModified, false},
+		{"GET", "", lastModified, "", "abc", false},
+		{"GET", "", lastModified, "", "", false},
+		{"GET", "", "", "", lastModified, false},
+		// etag列表与通配符
+		{"GET", `"a", W/"abc", "b"`, "", `"abc"`, "", true},
+		{"GET", `"a", "b"`, "", `"abc"`, "", false},
+		{"GET", "*", "", `"abc"`, "", true},
+		{"GET", "*", lastModified, "", "Sat, 08 Jun 2019 02:17:55 GMT", true},
+		{"GET", `"a"`, lastModified, "", lastModified, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", nil)
+		req.Header.Set(elton.HeaderIfNoneMatch, tt.ifNoneMatch)
+		req.Header.Set(elton.HeaderIfModifiedSince, tt.ifModifiedSince)
+		header := make(http.Header)
+		header.Set(elton.HeaderETag, tt.eTag)
+		header.Set(elton.HeaderLastModified, tt.lastModified)
+		assert.Equal(tt.result, IsNotModified(req, header))
+	}
+}
+
+func TestMatchIfMatch(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		ifMatch string
+		eTag    string
+		result  bool
+	}{
+		{"*", "", true},
+		{`"a"`, "", false},
+		{`"a", "b"`, `"b"`, true},
+		{`W/"a"`, `"a"`, false},
+		{`"a"`, `W/"a"`, false},
+		{`"a", "b"`, `"c"`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.result, MatchIfMatch(tt.ifMatch, tt.eTag), tt.ifMatch)
+	}
+}
+
+func TestIsPreconditionFailed(t *testing.T) {
+	assert := assert.New(t)
+	lastModified := "Sat, 08 Jun 2019 02:17:54 GMT"
+	tests := []struct {
+		ifMatch           string
+		ifUnmodifiedSince string
+		eTag              string
+		lastModified      string
+		result            bool
+	}{
+		{"", "", `"abc"`, lastModified, false},
+		{`"abc"`, "", `"abc"`, "", false},
+		{`"a", "abc"`, "", `"abc"`, "", false},
+		{`"abcd"`, "", `"abc"`, "", true},
+		{`W/"abc"`, "", `W/"abc"`, "", true},
+		{"*", "", "", "", false},
+		// If-Match优先
+		{`"abc"`, "Sat, 08 Jun 2019 02:17:53 GMT", `"abc"`, lastModified, false},
+		{"", lastModified, "", lastModified, false},
+		{"", "Sat, 08 Jun 2019 02:17:55 GMT", "", lastModified, false},
+		{"", "Sat, 08 Jun 2019 02:17:53 GMT", "", lastModified, true},
+		{"", "abc", "", lastModified, false},
+		{"", lastModified, "", "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(HeaderIfMatch, tt.ifMatch)
+		req.Header.Set(HeaderIfUnmodifiedSince, tt.ifUnmodifiedSince)
+		header := make(http.Header)
+		header.Set(elton.HeaderETag, tt.eTag)
+		header.Set(elton.HeaderLastModified, tt.lastModified)
+		assert.Equal(tt.result, IsPreconditionFailed(req, header))
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderOrigin origin
+	HeaderOrigin = "Origin"
+	// HeaderAccessControlAllowOrigin access-control-allow-origin
+	HeaderAccessControlAllowOrigin = "Access-Control-Allow-Origin"
+	// HeaderAccessControlAllowMethods access-control-allow-methods
+	HeaderAccessControlAllowMethods = "Access-Control-Allow-Methods"
+	// HeaderAccessControlAllowHeaders access-control-allow-headers
+	HeaderAccessControlAllowHeaders = "Access-Control-Allow-Headers"
+	// HeaderAccessControlAllowCredentials access-control-allow-credentials
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	// HeaderAccessControlExposeHeaders access-control-expose-headers
+	HeaderAccessControlExposeHeaders = "Access-Control-Expose-Headers"
+	// HeaderAccessControlMaxAge access-control-max-age
+	HeaderAccessControlMaxAge = "Access-Control-Max-Age"
+	// HeaderAccessControlRequestMethod access-control-request-method
+	HeaderAccessControlRequestMethod = "Access-Control-Request-Method"
+	// HeaderAccessControlRequestHeaders access-control-request-headers
+	HeaderAccessControlRequestHeaders = "Access-Control-Request-Headers"
+)
+
+var defaultCORSAllowMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+}
+
+type (
+	// CORSConfig the cors config of static files
+	CORSConfig struct {
+		// 允许跨域的origin，* 表示所有
+		AllowOrigins []string
+		// 允许的请求方法，默认为GET与HEAD
+		AllowMethods []string
+		// 允许的请求头，为空则使用预检请求的Access-Control-Request-Headers
+		AllowHeaders []string
+		// 允许客户端获取的响应头
+		ExposeHeaders []string
+		// 是否允许携带cookie等凭证，此时Access-Control-Allow-Origin为请求的origin
+		AllowCredentials bool
+		// 预检请求的缓存时长
+		MaxAge time.Duration
+		// 需要跨域访问的路径（匹配规则与CacheControlRule的Pattern一致），如 *.woff2 ，为空则所有文件
+		Patterns []string
+	}
+)
+
+// match check the file(url path) is in the scope of cors
+func (cors *CORSConfig) match(file string) bool {
+	if len(cors.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range cors.Patterns {
+		if matchPathPattern(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWildcard check all origins are allowed
+func (cors *CORSConfig) isWildcard() bool {
+	for _, item := range cors.AllowOrigins {
+		if item == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin get the value of Access-Control-Allow-Origin for the origin,
+// it returns false if the origin is not allowed
+func (cors *CORSConfig) allowOrigin(origin string) (string, bool) {
+	if origin == "" {
+		return "", false
+	}
+	if cors.isWildcard() {
+		if cors.AllowCredentials {
+			return origin, true
+		}
+		return "*", true
+	}
+	for _, item := range cors.AllowOrigins {
+		if strings.EqualFold(item, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// isCORSPreflight check the request is cors preflight request
+func isCORSPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions &&
+		req.Header.Get(HeaderOrigin) != "" &&
+		req.Header.Get(HeaderAccessControlRequestMethod) != ""
+}
+
+// setCORSHeaders set the cors headers of response, it returns false if the origin is not allowed.
+// The Vary: Origin is added unless all origins are allowed without credentials,
+// so that the response of different origins is not shared by the cache.
+func setCORSHeaders(header http.Header, cors *CORSConfig, req *http.Request, file string) bool {
+	if !cors.match(file) {
+		return false
+	}
+	if !cors.isWildcard() || cors.AllowCredentials {
+		addVary(header, HeaderOrigin)
+	}
+	value, ok := cors.allowOrigin(req.Header.Get(HeaderOrigin))
+	if !ok {
+		return false
+	}
+	header.Set(HeaderAccessControlAllowOrigin, value)
+	if cors.AllowCredentials {
+		header.Set(HeaderAccessControlAllowCredentials, "true")
+	}
+	if !isCORSPreflight(req) {
+		if len(cors.ExposeHeaders) != 0 {
+			header.Set(HeaderAccessControlExposeHeaders, strings.Join(cors.ExposeHeaders, ", "))
+		}
+		return true
+	}
+	addVary(header, HeaderAccessControlRequestMethod, HeaderAccessControlRequestHeaders)
+	methods := cors.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSAllowMethods
+	}
+	header.Set(HeaderAccessControlAllowMethods, strings.ToUpper(strings.Join(methods, ", ")))
+	allowHeaders := strings.Join(cors.AllowHeaders, ", ")
+	if allowHeaders == "" {
+		allowHeaders = req.Header.Get(HeaderAccessControlRequestHeaders)
+	}
+	if allowHeaders != "" {
+		header.Set(HeaderAccessControlAllowHeaders, allowHeaders)
+	}
+	if cors.MaxAge > 0 {
+		header.Set(HeaderAccessControlMaxAge, strconv.Itoa(int(cors.MaxAge.Seconds())))
+	}
+	return true
+}
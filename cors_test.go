@@ -0,0 +1,115 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSAllowOrigin(t *testing.T) {
+	assert := assert.New(t)
+	cors := &CORSConfig{
+		AllowOrigins: []string{
+			"https://a.com",
+		},
+	}
+	value, ok := cors.allowOrigin("https://a.com")
+	assert.True(ok)
+	assert.Equal("https://a.com", value)
+	_, ok = cors.allowOrigin("https://b.com")
+	assert.False(ok)
+	_, ok = cors.allowOrigin("")
+	assert.False(ok)
+
+	cors = &CORSConfig{
+		AllowOrigins: []string{
+			"*",
+		},
+	}
+	value, ok = cors.allowOrigin("https://b.com")
+	assert.True(ok)
+	assert.Equal("*", value)
+
+	// 允许凭证时不可使用*
+	cors.AllowCredentials = true
+	value, ok = cors.allowOrigin("https://b.com")
+	assert.True(ok)
+	assert.Equal("https://b.com", value)
+}
+
+func TestSetCORSHeaders(t *testing.T) {
+	assert := assert.New(t)
+	cors := &CORSConfig{
+		AllowOrigins: []string{
+			"https://a.com",
+		},
+		ExposeHeaders: []string{
+			"ETag",
+		},
+		MaxAge:   time.Hour,
+		Patterns: []string{"*.woff2"},
+	}
+
+	req := httptest.NewRequest("GET", "/font.woff2", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	header := make(http.Header)
+	assert.True(setCORSHeaders(header, cors, req, "/font.woff2"))
+	assert.Equal("https://a.com", header.Get(HeaderAccessControlAllowOrigin))
+	assert.Equal("ETag", header.Get(HeaderAccessControlExposeHeaders))
+	assert.Equal("Origin", header.Get(HeaderVary))
+	assert.Empty(header.Get(HeaderAccessControlAllowMethods))
+
+	// 不匹配的文件
+	header = make(http.Header)
+	assert.False(setCORSHeaders(header, cors, req, "/index.html"))
+	assert.Empty(header)
+
+	// 不允许的origin
+	req.Header.Set(HeaderOrigin, "https://b.com")
+	header = make(http.Header)
+	assert.False(setCORSHeaders(header, cors, req, "/font.woff2"))
+	assert.Empty(header.Get(HeaderAccessControlAllowOrigin))
+	assert.Equal("Origin", header.Get(HeaderVary))
+
+	// 预检请求
+	req = httptest.NewRequest("OPTIONS", "/font.woff2", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	req.Header.Set(HeaderAccessControlRequestMethod, "GET")
+	req.Header.Set(HeaderAccessControlRequestHeaders, "X-Requested-With")
+	assert.True(isCORSPreflight(req))
+	header = make(http.Header)
+	assert.True(setCORSHeaders(header, cors, req, "/font.woff2"))
+	assert.Equal("GET, HEAD", header.Get(HeaderAccessControlAllowMethods))
+	assert.Equal("X-Requested-With", header.Get(HeaderAccessControlAllowHeaders))
+	assert.Equal("3600", header.Get(HeaderAccessControlMaxAge))
+	assert.Empty(header.Get(HeaderAccessControlExposeHeaders))
+	assert.Equal("Origin, Access-Control-Request-Method, Access-Control-Request-Headers", header.Get(HeaderVary))
+
+	// 通配且不允许凭证时无需Vary
+	cors = &CORSConfig{
+		AllowOrigins: []string{"*"},
+	}
+	req = httptest.NewRequest("GET", "/app.wasm", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	header = make(http.Header)
+	assert.True(setCORSHeaders(header, cors, req, "/app.wasm"))
+	assert.Equal("*", header.Get(HeaderAccessControlAllowOrigin))
+	assert.Empty(header.Get(HeaderVary))
+}
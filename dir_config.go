@@ -0,0 +1,160 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type (
+	// DirConfig the configuration of directory(such as .staticserve.json), it overrides
+	// the configuration for the files of directory and its sub directories
+	DirConfig struct {
+		// 响应头，与Header合并（相同的则覆盖）
+		Header map[string]string `json:"header,omitempty"`
+		// Cache-Control，优先于MaxAge等缓存配置（CacheControlRules除外）
+		CacheControl string `json:"cacheControl,omitempty"`
+		// 目录的index文件，按顺序查找
+		Index []string `json:"index,omitempty"`
+		// 是否生成目录列表
+		DirectoryListing *bool `json:"directoryListing,omitempty"`
+	}
+	// dirConfigs the configurations of directories, the key is the url path of directory
+	dirConfigs struct {
+		staticFile StaticFile
+		root       string
+		name       string
+		mu         sync.RWMutex
+		configs    map[string]*DirConfig
+	}
+)
+
+// newDirConfigs load all configuration files of root, the static file should support reading directory
+func newDirConfigs(staticFile StaticFile, root, name string) (*dirConfigs, error) {
+	dirLister, ok := getDirLister(staticFile)
+	if !ok {
+		return nil, errors.New("directory config requires static file that can read directory")
+	}
+	files, err := listFiles(dirLister, root)
+	if err != nil {
+		return nil, err
+	}
+	dc := &dirConfigs{
+		staticFile: staticFile,
+		root:       root,
+		name:       name,
+		configs:    make(map[string]*DirConfig),
+	}
+	for _, file := range files {
+		if path.Base(file) != name {
+			continue
+		}
+		if err := dc.load(file); err != nil {
+			return nil, err
+		}
+	}
+	return dc, nil
+}
+
+// load load the configuration file(url path)
+func (dc *dirConfigs) load(file string) error {
+	buf, err := dc.staticFile.Get(filepath.Join(dc.root, file))
+	if err != nil {
+		return err
+	}
+	conf := &DirConfig{}
+	if err := json.Unmarshal(buf, conf); err != nil {
+		return fmt.Errorf("invalid directory config %s: %w", file, err)
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.configs[path.Dir(file)] = conf
+	return nil
+}
+
+// refresh reload the configuration when the file(os path) is changed, the configurations
+// of the directory are removed if it's not exists. The invalid configuration file is ignored
+// and the former configuration is kept.
+func (dc *dirConfigs) refresh(file string) {
+	urlPath := relativePath(dc.root, file)
+	if dc.staticFile.Exists(file) {
+		if path.Base(urlPath) == dc.name {
+			_ = dc.load(urlPath)
+		}
+		return
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	for dir := range dc.configs {
+		if path.Join(dir, dc.name) == urlPath || dir == urlPath || strings.HasPrefix(dir, urlPath+"/") {
+			delete(dc.configs, dir)
+		}
+	}
+}
+
+// isConfigFile check the file is the configuration file
+func (dc *dirConfigs) isConfigFile(file string) bool {
+	// 忽略大小写，避免在大小写不敏感的文件系统中可访问
+	return strings.EqualFold(filepath.Base(file), dc.name)
+}
+
+// get get the merged configuration of directory(url path), the configuration of
+// sub directory overrides the parent's. It returns nil if no configuration.
+func (dc *dirConfigs) get(dir string) *DirConfig {
+	dir = path.Clean("/" + dir)
+	dirs := []string{dir}
+	for dir != "/" {
+		dir = path.Dir(dir)
+		dirs = append(dirs, dir)
+	}
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	var result *DirConfig
+	for i := len(dirs) - 1; i >= 0; i-- {
+		conf, ok := dc.configs[dirs[i]]
+		if !ok {
+			continue
+		}
+		if result == nil {
+			result = &DirConfig{}
+		}
+		if len(conf.Header) != 0 {
+			header := make(map[string]string, len(result.Header)+len(conf.Header))
+			for k, v := range result.Header {
+				header[k] = v
+			}
+			for k, v := range conf.Header {
+				header[k] = v
+			}
+			result.Header = header
+		}
+		if conf.CacheControl != "" {
+			result.CacheControl = conf.CacheControl
+		}
+		if len(conf.Index) != 0 {
+			result.Index = conf.Index
+		}
+		if conf.DirectoryListing != nil {
+			result.DirectoryListing = conf.DirectoryListing
+		}
+	}
+	return result
+}
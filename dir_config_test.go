@@ -0,0 +1,131 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestDirConfigs(t *testing.T) {
+	assert := assert.New(t)
+	fsys := fstest.MapFS{
+		"index.html":               {Data: []byte("index")},
+		".staticserve.json":        {Data: []byte(`{"header": {"X-Root": "1", "X-Dir": "root"}, "cacheControl": "no-cache"}`)},
+		"docs/.staticserve.json":   {Data: []byte(`{"header": {"X-Dir": "docs"}, "index": ["README.html"], "directoryListing": true}`)},
+		"docs/README.html":         {Data: []byte("readme")},
+		"docs/api/v1.html":         {Data: []byte("v1")},
+		"assets/app.js":            {Data: []byte("app")},
+		"assets/.staticserve.json": {Data: []byte(`{"cacheControl": "public, max-age=31536000"}`)},
+		"assets/img/logo.png":      {Data: []byte("png")},
+	}
+	dc, err := newDirConfigs(NewIOFS(fsys), "", ".staticserve.json")
+	assert.Nil(err)
+	assert.Equal(3, len(dc.configs))
+
+	conf := dc.get("/docs/api")
+	assert.Equal(map[string]string{"X-Root": "1", "X-Dir": "docs"}, conf.Header)
+	assert.Equal("no-cache", conf.CacheControl)
+	assert.Equal([]string{"README.html"}, conf.Index)
+	assert.True(*conf.DirectoryListing)
+
+	conf = dc.get("/assets/img")
+	assert.Equal("root", conf.Header["X-Dir"])
+	assert.Equal("public, max-age=31536000", conf.CacheControl)
+	assert.Nil(conf.DirectoryListing)
+
+	assert.True(dc.isConfigFile("/docs/.staticserve.json"))
+	assert.False(dc.isConfigFile("/docs/README.html"))
+
+	fsys["invalid/.staticserve.json"] = &fstest.MapFile{Data: []byte(`{"header": 1}`)}
+	_, err = newDirConfigs(NewIOFS(fsys), "", ".staticserve.json")
+	assert.NotNil(err)
+
+	_, err = newDirConfigs(&MockStaticFile{}, "", ".staticserve.json")
+	assert.NotNil(err)
+}
+
+func TestDirConfigsRefresh(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	configFile := filepath.Join(root, "docs", ".staticserve.json")
+	assert.Nil(os.WriteFile(configFile, []byte(`{"cacheControl": "no-cache"}`), 0644))
+	dc, err := newDirConfigs(&FS{}, root, ".staticserve.json")
+	assert.Nil(err)
+	assert.Equal("no-cache", dc.get("/docs").CacheControl)
+
+	assert.Nil(os.WriteFile(configFile, []byte(`{"cacheControl": "no-store"}`), 0644))
+	dc.refresh(configFile)
+	assert.Equal("no-store", dc.get("/docs").CacheControl)
+
+	// 无效的配置忽略
+	assert.Nil(os.WriteFile(configFile, []byte(`{`), 0644))
+	dc.refresh(configFile)
+	assert.Equal("no-store", dc.get("/docs").CacheControl)
+
+	// 非配置文件
+	dc.refresh(filepath.Join(root, "docs"))
+	assert.Equal("no-store", dc.get("/docs").CacheControl)
+
+	assert.Nil(os.RemoveAll(filepath.Join(root, "docs")))
+	dc.refresh(filepath.Join(root, "docs"))
+	assert.Nil(dc.get("/docs"))
+}
+
+func TestServeDirConfig(t *testing.T) {
+	assert := assert.New(t)
+	fsys := fstest.MapFS{
+		".staticserve.json":       {Data: []byte(`{"header": {"X-Dir": "root"}}`)},
+		"index.html":              {Data: []byte("index")},
+		"docs/.staticserve.json":  {Data: []byte(`{"header": {"X-Dir": "docs"}, "cacheControl": "no-cache", "index": ["README.html"]}`)},
+		"docs/README.html":        {Data: []byte("readme")},
+		"files/a.txt":             {Data: []byte("a")},
+		"files/.staticserve.json": {Data: []byte(`{"directoryListing": true}`)},
+	}
+	e := elton.New()
+	e.GET("/*file", NewFromFS(fsys, Config{
+		MaxAge:        60,
+		DirConfigFile: ".staticserve.json",
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("root", resp.Header().Get("X-Dir"))
+	assert.Equal("public, max-age=60", resp.Header().Get("Cache-Control"))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("readme", resp.Body.String())
+	assert.Equal("docs", resp.Header().Get("X-Dir"))
+	assert.Equal("no-cache", resp.Header().Get("Cache-Control"))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/files/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Contains(resp.Body.String(), "a.txt")
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/.staticserve.json", nil))
+	assert.Equal(404, resp.Code)
+}
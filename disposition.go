@@ -0,0 +1,92 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// HeaderContentDisposition content disposition
+const HeaderContentDisposition = "Content-Disposition"
+
+// isAttrChar check the char is attr-char of RFC 5987
+func isAttrChar(ch byte) bool {
+	if (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", ch) != -1
+}
+
+// getAttachmentDisposition get the content disposition of attachment, the filename
+// is encoded by RFC 5987 if it includes non-ascii or special chars
+func getAttachmentDisposition(filename string) string {
+	if filename == "" {
+		return "attachment"
+	}
+	fallback := make([]byte, 0, len(filename))
+	encoded := strings.Builder{}
+	needEncode := false
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(filename); i++ {
+		ch := filename[i]
+		// 非ascii或引号等字符使用_替换
+		if ch < 0x20 || ch >= 0x7f || ch == '"' || ch == '\\' {
+			fallback = append(fallback, '_')
+			needEncode = true
+		} else {
+			fallback = append(fallback, ch)
+		}
+		if isAttrChar(ch) {
+			encoded.WriteByte(ch)
+		} else {
+			encoded.WriteByte('%')
+			encoded.WriteByte(hex[ch>>4])
+			encoded.WriteByte(hex[ch&0x0f])
+		}
+	}
+	disposition := `attachment; filename="` + string(fallback) + `"`
+	if needEncode {
+		disposition += "; filename*=UTF-8''" + encoded.String()
+	}
+	return disposition
+}
+
+// getAttachmentFilename get the filename of attachment, the second result is false if
+// the file should not be downloaded as attachment
+func getAttachmentFilename(config *Config, reqURL *url.URL, file string) (string, bool) {
+	filename := path.Base(file)
+	if config.AttachmentQueryKey != "" && reqURL.RawQuery != "" {
+		query := reqURL.Query()
+		if _, ok := query[config.AttachmentQueryKey]; ok {
+			// 自定义的文件名仅使用其base name
+			name := path.Base(strings.ReplaceAll(query.Get(config.AttachmentQueryKey), "\\", "/"))
+			if name != "." && name != ".." && name != "/" {
+				filename = name
+			}
+			return filename, true
+		}
+	}
+	if config.Attachment {
+		return filename, true
+	}
+	for _, pattern := range config.AttachmentPatterns {
+		if matchPathPattern(pattern, file) {
+			return filename, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestGetAttachmentDisposition(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("attachment", getAttachmentDisposition(""))
+	assert.Equal(`attachment; filename="report.pdf"`, getAttachmentDisposition("report.pdf"))
+	assert.Equal(`attachment; filename="my report.pdf"`, getAttachmentDisposition("my report.pdf"))
+	assert.Equal(`attachment; filename="______.pdf"; filename*=UTF-8''%E6%8A%A5%E5%91%8A.pdf`, getAttachmentDisposition("报告.pdf"))
+	assert.Equal(`attachment; filename="a_b_.txt"; filename*=UTF-8''a%22b%5C.txt`, getAttachmentDisposition(`a"b\.txt`))
+}
+
+func TestGetAttachmentFilename(t *testing.T) {
+	assert := assert.New(t)
+	newURL := func(rawQuery string) *url.URL {
+		return &url.URL{
+			Path:     "/files/report.pdf",
+			RawQuery: rawQuery,
+		}
+	}
+	conf := &Config{
+		AttachmentQueryKey: "download",
+		AttachmentPatterns: []string{"*.zip", "/downloads/"},
+	}
+	_, ok := getAttachmentFilename(conf, newURL(""), "/files/report.pdf")
+	assert.False(ok)
+
+	filename, ok := getAttachmentFilename(conf, newURL("download"), "/files/report.pdf")
+	assert.True(ok)
+	assert.Equal("report.pdf", filename)
+
+	filename, _ = getAttachmentFilename(conf, newURL("download=../2021.pdf"), "/files/report.pdf")
+	assert.Equal("2021.pdf", filename)
+	filename, _ = getAttachmentFilename(conf, newURL("download=.."), "/files/report.pdf")
+	assert.Equal("report.pdf", filename)
+
+	filename, ok = getAttachmentFilename(conf, newURL("a=1"), "/files/app.zip")
+	assert.True(ok)
+	assert.Equal("app.zip", filename)
+	_, ok = getAttachmentFilename(conf, newURL(""), "/downloads/report.pdf")
+	assert.True(ok)
+
+	_, ok = getAttachmentFilename(&Config{
+		Attachment: true,
+	}, newURL(""), "/files/report.pdf")
+	assert.True(ok)
+}
+
+func TestServeAttachment(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:               staticPath,
+		AttachmentQueryKey: "download",
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/banner.jpg?download=横幅.jpg", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal(`attachment; filename="______.jpg"; filename*=UTF-8''%E6%A8%AA%E5%B9%85.jpg`, resp.Header().Get(HeaderContentDisposition))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/banner.jpg", nil))
+	assert.Empty(resp.Header().Get(HeaderContentDisposition))
+}
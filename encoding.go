@@ -0,0 +1,113 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const encodingIdentity = "identity"
+
+type (
+	// AcceptEncoding the encoding and quality of Accept-Encoding
+	AcceptEncoding struct {
+		Encoding string
+		Quality  float64
+	}
+)
+
+// ParseAcceptEncoding parse the Accept-Encoding header, the result is sorted by quality(desc),
+// the encoding is lower case and the invalid quality is treated as 0
+func ParseAcceptEncoding(header string) []AcceptEncoding {
+	result := make([]AcceptEncoding, 0)
+	for _, item := range strings.Split(header, ",") {
+		arr := strings.Split(item, ";")
+		name := strings.ToLower(strings.TrimSpace(arr[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range arr[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) < 2 || (param[0] != 'q' && param[0] != 'Q') || param[1] != '=' {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(param[2:]), 64)
+			if err != nil || v < 0 {
+				v = 0
+			}
+			if v > 1 {
+				v = 1
+			}
+			q = v
+		}
+		result = append(result, AcceptEncoding{
+			Encoding: name,
+			Quality:  q,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Quality > result[j].Quality
+	})
+	return result
+}
+
+// getEncodingQuality get the quality of encoding, the second result is false if
+// the encoding(or *) is not specified
+func getEncodingQuality(accepts []AcceptEncoding, encoding string) (float64, bool) {
+	wildcard := -1.0
+	for _, item := range accepts {
+		if item.Encoding == encoding {
+			return item.Quality, true
+		}
+		if item.Encoding == "*" && wildcard < 0 {
+			wildcard = item.Quality
+		}
+	}
+	if wildcard >= 0 {
+		return wildcard, true
+	}
+	return 0, false
+}
+
+// NegotiateEncoding pick the best encoding from the supported encodings(ordered by
+// preference of server) according to the Accept-Encoding header. The encoding of higher
+// quality is preferred, and then the order of supported. It returns empty string if no
+// encoding is acceptable or identity is preferred(e.g. "gzip;q=0.5, identity").
+func NegotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" || len(supported) == 0 {
+		return ""
+	}
+	accepts := ParseAcceptEncoding(acceptEncoding)
+	result := ""
+	max := 0.0
+	for _, encoding := range supported {
+		q, _ := getEncodingQuality(accepts, strings.ToLower(encoding))
+		if q > max {
+			max = q
+			result = encoding
+		}
+	}
+	if result == "" {
+		return ""
+	}
+	// identity仅在明确指定且q值更高时使用
+	if q, ok := getEncodingQuality(accepts, encodingIdentity); ok && q > max {
+		return ""
+	}
+	return result
+}
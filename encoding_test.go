@@ -0,0 +1,50 @@
+package staticserve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptEncoding(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]AcceptEncoding{}, ParseAcceptEncoding(""))
+	assert.Equal([]AcceptEncoding{
+		{"br", 1},
+		{"deflate", 1},
+		{"gzip", 0.8},
+		{"*", 0.1},
+		{"identity", 0},
+	}, ParseAcceptEncoding("GZIP; Q=0.8, br, identity;q=0, deflate, *;q=0.1"))
+	assert.Equal([]AcceptEncoding{
+		{"br", 1},
+		{"gzip", 0},
+		{"zstd", 0},
+	}, ParseAcceptEncoding("gzip;q=abc, zstd;q=-1, br;q=2, ,"))
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert := assert.New(t)
+	supported := []string{"br", "gzip"}
+	cases := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"", ""},
+		{"gzip, deflate, br", "br"},
+		{"gzip, br;q=0.5", "gzip"},
+		{"gzip;q=0, br;q=0", ""},
+		{"deflate", ""},
+		{"*", "br"},
+		{"gzip;q=0, *;q=0.5", "br"},
+		{"br;q=0, *", "gzip"},
+		// identity的q值更高时不压缩
+		{"gzip;q=0.5, identity", ""},
+		{"gzip, identity;q=0", "gzip"},
+		{"gzip;q=0.5", "gzip"},
+	}
+	for _, item := range cases {
+		assert.Equal(item.encoding, NegotiateEncoding(item.acceptEncoding, supported), item.acceptEncoding)
+	}
+	assert.Empty(NegotiateEncoding("gzip", nil))
+}
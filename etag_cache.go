@@ -0,0 +1,68 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"time"
+)
+
+type (
+	eTagCacheItem struct {
+		modTime  time.Time
+		fileSize int64
+		eTag     string
+	}
+	// eTagCache lru cache for the strong etag of file, the count of
+	// files will not exceed the max size
+	eTagCache struct {
+		lru *lru
+	}
+)
+
+// newETagCache create an etag cache
+func newETagCache(maxSize int) *eTagCache {
+	return &eTagCache{
+		lru: newLRU(maxSize),
+	}
+}
+
+// Get get the etag of file, the cache is valid only when the mod time and size are equal
+func (ec *eTagCache) Get(file string, modTime time.Time, fileSize int64) (string, bool) {
+	// 文件已修改，删除缓存
+	v, ok := ec.lru.get(file, func(value interface{}) bool {
+		item := value.(*eTagCacheItem)
+		return item.modTime.Equal(modTime) && item.fileSize == fileSize
+	})
+	if !ok {
+		return "", false
+	}
+	return v.(*eTagCacheItem).eTag, true
+}
+
+// Add add the etag of file to cache
+func (ec *eTagCache) Add(file string, modTime time.Time, fileSize int64, eTag string) {
+	ec.lru.add(file, &eTagCacheItem{
+		modTime:  modTime,
+		fileSize: fileSize,
+		eTag:     eTag,
+	}, 1)
+}
+
+// remove remove the etag cache of file and the files in the directory
+func (ec *eTagCache) remove(file string) {
+	ec.lru.removeKeys(func(key string) bool {
+		return isCacheKeyOf(key, file)
+	})
+}
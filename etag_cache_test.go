@@ -0,0 +1,123 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestETagCache(t *testing.T) {
+	assert := assert.New(t)
+	modTime := time.Now()
+	ec := newETagCache(2)
+
+	ec.Add("a", modTime, 1, `"a"`)
+	ec.Add("b", modTime, 1, `"b"`)
+	eTag, ok := ec.Get("a", modTime, 1)
+	assert.True(ok)
+	assert.Equal(`"a"`, eTag)
+
+	// 超过数量限制，淘汰最少使用的b
+	ec.Add("c", modTime, 1, `"c"`)
+	_, ok = ec.Get("b", modTime, 1)
+	assert.False(ok)
+
+	// 修改时间或大小不一致则失效
+	_, ok = ec.Get("a", modTime, 2)
+	assert.False(ok)
+	_, ok = ec.Get("c", modTime.Add(time.Second), 1)
+	assert.False(ok)
+	assert.Equal(0, ec.lru.ll.Len())
+
+	ec.Add("a", modTime, 1, `"a"`)
+	ec.Add("a", modTime, 1, `"a1"`)
+	eTag, _ = ec.Get("a", modTime, 1)
+	assert.Equal(`"a1"`, eTag)
+	assert.Equal(1, ec.lru.ll.Len())
+}
+
+type getCountStaticFile struct {
+	MockStaticFile
+	getCount int
+}
+
+func (sf *getCountStaticFile) Get(file string) ([]byte, error) {
+	sf.getCount++
+	return sf.MockStaticFile.Get(file)
+}
+
+func TestETagCacheSize(t *testing.T) {
+	assert := assert.New(t)
+	sf := &getCountStaticFile{}
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Path:             staticPath,
+		EnableStrongETag: true,
+		ETagCacheSize:    10,
+	}))
+	eTag := generateETag([]byte("<html>xxx</html>"))
+	for i := 0; i < 3; i++ {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(eTag, resp.Header().Get(elton.HeaderETag))
+	}
+	// 仅首次读取文件内容计算etag
+	assert.Equal(1, sf.getCount)
+}
+
+func TestETagCacheRemove(t *testing.T) {
+	assert := assert.New(t)
+	ec := newETagCache(10)
+	modTime := time.Now()
+	ec.Add("/local/app.js", modTime, 4, `"4-abcd"`)
+	ec.Add("/local/js/index.js", modTime, 4, `"4-efgh"`)
+
+	ec.remove("/local/app.js")
+	_, ok := ec.Get("/local/app.js", modTime, 4)
+	assert.False(ok)
+	_, ok = ec.Get("/local/js/index.js", modTime, 4)
+	assert.True(ok)
+
+	ec.remove("/local")
+	_, ok = ec.Get("/local/js/index.js", modTime, 4)
+	assert.False(ok)
+}
+
+func TestServeHeadStrongETag(t *testing.T) {
+	assert := assert.New(t)
+	for _, size := range []int{0, 10} {
+		sf := &getCountStaticFile{}
+		fn := New(sf, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			ETagCacheSize:    size,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		e.HEAD("/*file", fn)
+
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("HEAD", "/index.html", nil))
+		assert.Equal(200, resp.Code)
+		assert.Empty(resp.Body.String())
+		headETag := resp.Header().Get(elton.HeaderETag)
+		assert.NotEmpty(headETag)
+
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(headETag, resp.Header().Get(elton.HeaderETag))
+
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("HEAD", "/index.html", nil))
+		assert.Equal(headETag, resp.Header().Get(elton.HeaderETag))
+		if size != 0 {
+			// HEAD请求计算的etag也写入缓存
+			assert.Equal(1, sf.getCount)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+type (
+	// ServeInfo the information of serving static file
+	ServeInfo struct {
+		// 文件路径（相对于Path，已处理index、fallback等），未找到文件时为请求的路径
+		File string
+		// 响应数据的长度，未知时（如压缩的流）为-1
+		Size int64
+		// 响应状态码
+		Status int
+		// 是否查询了内存缓存（内容缓存或压缩数据缓存），未查询时CacheHit无意义
+		CacheUsed bool
+		// 是否从内存缓存（内容缓存或压缩数据缓存）中获取
+		CacheHit bool
+		// 响应数据的编码（Content-Encoding），如 br 、 gzip
+		Encoding string
+		// 处理时长（不包括数据以流的形式返回的时长）
+		Elapsed time.Duration
+	}
+)
+
+// getResponseSize get the size of response body, it returns -1 if unknown
+func getResponseSize(c *elton.Context) int64 {
+	if c.BodyBuffer != nil {
+		return int64(c.BodyBuffer.Len())
+	}
+	if value := c.GetHeader(elton.HeaderContentLength); value != "" {
+		if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return size
+		}
+	}
+	if c.Body == nil {
+		return 0
+	}
+	return -1
+}
+
+// getResponseStatus get the status of response
+func getResponseStatus(c *elton.Context, err error) int {
+	if err != nil {
+		if he, ok := err.(*hes.Error); ok && he.StatusCode != 0 {
+			return he.StatusCode
+		}
+		return http.StatusInternalServerError
+	}
+	if c.StatusCode != 0 {
+		return c.StatusCode
+	}
+	return http.StatusOK
+}
+
+// emitServe call the OnServe or OnError hook with the serve info
+func emitServe(c *elton.Context, config *Config, info *ServeInfo, startedAt time.Time, err error) {
+	info.Elapsed = time.Since(startedAt)
+	info.Status = getResponseStatus(c, err)
+	info.Encoding = c.GetHeader(elton.HeaderContentEncoding)
+	if err != nil {
+		info.Size = -1
+		if config.OnError != nil {
+			config.OnError(c, *info, err)
+		}
+		return
+	}
+	info.Size = getResponseSize(c)
+	if config.OnServe != nil {
+		config.OnServe(c, *info)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestGetResponseSize(t *testing.T) {
+	assert := assert.New(t)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(int64(0), getResponseSize(c))
+	c.Body = strings.NewReader("abcd")
+	assert.Equal(int64(-1), getResponseSize(c))
+	c.SetHeader(elton.HeaderContentLength, "4")
+	assert.Equal(int64(4), getResponseSize(c))
+	c.BodyBuffer = bytes.NewBufferString("abc")
+	assert.Equal(int64(3), getResponseSize(c))
+}
+
+func TestGetResponseStatus(t *testing.T) {
+	assert := assert.New(t)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, getResponseStatus(c, nil))
+	c.StatusCode = 304
+	assert.Equal(304, getResponseStatus(c, nil))
+	assert.Equal(404, getResponseStatus(c, ErrNotFound))
+	assert.Equal(500, getResponseStatus(c, errors.New("abc")))
+}
+
+func TestServeEvent(t *testing.T) {
+	assert := assert.New(t)
+	var serveInfos []ServeInfo
+	var errorInfos []ServeInfo
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:             staticPath,
+		ContentCacheSize: 2048,
+		Compressors: []Compressor{
+			NewGzipCompressor(0),
+		},
+		CompressMinLength: 1,
+		OnServe: func(c *elton.Context, info ServeInfo) {
+			serveInfos = append(serveInfos, info)
+		},
+		OnError: func(c *elton.Context, info ServeInfo, err error) {
+			assert.Equal(ErrNotFound, err)
+			errorInfos = append(errorInfos, info)
+		},
+	}))
+	for _, file := range []string{
+		"/",
+		"/index.html",
+		"/notfound.html",
+	} {
+		req := httptest.NewRequest("GET", file, nil)
+		if file == "/" {
+			req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+	}
+	assert.Equal(2, len(serveInfos))
+	assert.Equal("/index.html", serveInfos[0].File)
+	assert.Equal(200, serveInfos[0].Status)
+	assert.Equal("gzip", serveInfos[0].Encoding)
+	assert.Empty(serveInfos[1].Encoding)
+	assert.Equal(int64(16), serveInfos[1].Size)
+	assert.False(serveInfos[0].CacheHit)
+	assert.True(serveInfos[0].Elapsed > 0)
+	assert.True(serveInfos[1].CacheHit)
+
+	assert.Equal(1, len(errorInfos))
+	assert.Equal("/notfound.html", errorInfos[0].File)
+	assert.Equal(404, errorInfos[0].Status)
+	assert.Equal(int64(-1), errorInfos[0].Size)
+}
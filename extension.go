@@ -0,0 +1,53 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// newExtensionSet create the set of extensions, the extension is converted to
+// lower case and the "." prefix is added if not exists
+func newExtensionSet(extensions []string) map[string]bool {
+	if len(extensions) == 0 {
+		return nil
+	}
+	result := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if ext[0] != '.' {
+			ext = "." + ext
+		}
+		result[ext] = true
+	}
+	return result
+}
+
+// isExtensionAllowed check the extension of file is allowed, the deny list has
+// higher priority, and the file without extension is not allowed if the allow list is not empty
+func isExtensionAllowed(file string, allows, denies map[string]bool) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	if denies[ext] {
+		return false
+	}
+	if len(allows) != 0 {
+		return allows[ext]
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExtensionSet(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(newExtensionSet(nil))
+	assert.Equal(map[string]bool{
+		".js":  true,
+		".css": true,
+	}, newExtensionSet([]string{".JS", "css", ""}))
+}
+
+func TestIsExtensionAllowed(t *testing.T) {
+	assert := assert.New(t)
+	denies := newExtensionSet([]string{".go", ".env", ".bak"})
+	assert.True(isExtensionAllowed("/local/app.js", nil, denies))
+	assert.True(isExtensionAllowed("/local/LICENSE", nil, denies))
+	assert.False(isExtensionAllowed("/local/main.go", nil, denies))
+	assert.False(isExtensionAllowed("/local/index.html.BAK", nil, denies))
+
+	allows := newExtensionSet([]string{".js", ".css", ".html"})
+	assert.True(isExtensionAllowed("/local/app.js", allows, denies))
+	assert.False(isExtensionAllowed("/local/app.png", allows, denies))
+	assert.False(isExtensionAllowed("/local/LICENSE", allows, denies))
+
+	// 禁止的优先
+	allows = newExtensionSet([]string{".js", ".bak"})
+	assert.False(isExtensionAllowed("/local/app.bak", allows, denies))
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileMetaSuffix the suffix of sidecar metadata file, such as report.pdf.meta.json
+const fileMetaSuffix = ".meta.json"
+
+type (
+	// FileMeta the metadata of file(the sidecar file <file>.meta.json),
+	// it overrides the configuration for the specific file
+	FileMeta struct {
+		// 响应头，优先于Header、HeaderRules与目录配置的响应头
+		Header map[string]string `json:"header,omitempty"`
+		// Content-Type，优先于根据扩展名获取的类型
+		ContentType string `json:"contentType,omitempty"`
+		// Content-Disposition的类型（attachment或inline），设置了Filename时默认为attachment
+		Disposition string `json:"disposition,omitempty"`
+		// 下载的文件名
+		Filename string `json:"filename,omitempty"`
+		// Cache-Control，优先于其它所有的缓存配置
+		CacheControl string `json:"cacheControl,omitempty"`
+	}
+	fileMetaItem struct {
+		modTime time.Time
+		size    int64
+		meta    *FileMeta
+	}
+	// fileMetas the metadata of files, it's loaded lazily and reloaded if
+	// the modified time or size of sidecar file is changed
+	fileMetas struct {
+		mu    sync.RWMutex
+		items map[string]*fileMetaItem
+	}
+)
+
+func newFileMetas() *fileMetas {
+	return &fileMetas{
+		items: make(map[string]*fileMetaItem),
+	}
+}
+
+// isFileMeta check the file is the sidecar metadata file
+func isFileMeta(file string) bool {
+	// 忽略大小写，避免在大小写不敏感的文件系统中可访问
+	return len(file) >= len(fileMetaSuffix) && strings.EqualFold(file[len(file)-len(fileMetaSuffix):], fileMetaSuffix)
+}
+
+// get get the metadata of file from the static file, it returns nil if the sidecar file is not exists
+func (fm *fileMetas) get(staticFile StaticFile, file string) (*FileMeta, error) {
+	metaFile := file + fileMetaSuffix
+	info := staticFile.Stat(metaFile)
+	if info == nil || info.IsDir() {
+		fm.mu.Lock()
+		delete(fm.items, file)
+		fm.mu.Unlock()
+		return nil, nil
+	}
+	fm.mu.RLock()
+	item, ok := fm.items[file]
+	fm.mu.RUnlock()
+	if ok && item.match(info) {
+		return item.meta, nil
+	}
+	buf, err := staticFile.Get(metaFile)
+	if err != nil {
+		return nil, getStaticServeError(err.Error(), http.StatusInternalServerError)
+	}
+	meta := &FileMeta{}
+	if err := json.Unmarshal(buf, meta); err != nil {
+		return nil, getStaticServeError("invalid file meta: "+err.Error(), http.StatusInternalServerError)
+	}
+	fm.mu.Lock()
+	fm.items[file] = &fileMetaItem{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		meta:    meta,
+	}
+	fm.mu.Unlock()
+	return meta, nil
+}
+
+// match check the cached item matches the stat of sidecar file
+func (item *fileMetaItem) match(info os.FileInfo) bool {
+	return item.modTime.Equal(info.ModTime()) && item.size == info.Size()
+}
+
+// getDisposition get the value of Content-Disposition, it's empty if not specified
+func (meta *FileMeta) getDisposition() string {
+	disposition := strings.ToLower(meta.Disposition)
+	if disposition == "" && meta.Filename != "" {
+		disposition = "attachment"
+	}
+	switch disposition {
+	case "attachment":
+		return getAttachmentDisposition(meta.Filename)
+	case "inline":
+		value := getAttachmentDisposition(meta.Filename)
+		return "inline" + strings.TrimPrefix(value, "attachment")
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+func TestFileMetaDisposition(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty((&FileMeta{}).getDisposition())
+	assert.Equal(`attachment; filename="report.pdf"`, (&FileMeta{
+		Filename: "report.pdf",
+	}).getDisposition())
+	assert.Equal("attachment", (&FileMeta{
+		Disposition: "attachment",
+	}).getDisposition())
+	assert.Equal(`inline; filename="report.pdf"`, (&FileMeta{
+		Disposition: "Inline",
+		Filename:    "report.pdf",
+	}).getDisposition())
+	assert.Equal("inline", (&FileMeta{
+		Disposition: "inline",
+	}).getDisposition())
+}
+
+func TestFileMetas(t *testing.T) {
+	assert := assert.New(t)
+	mapFS := fstest.MapFS{
+		"report.pdf": &fstest.MapFile{},
+		"report.pdf.meta.json": &fstest.MapFile{
+			Data:    []byte(`{"cacheControl":"no-store"}`),
+			ModTime: time.Unix(1, 0),
+		},
+		"broken.pdf.meta.json": &fstest.MapFile{
+			Data: []byte(`{`),
+		},
+	}
+	sf := NewIOFS(mapFS)
+	fm := newFileMetas()
+
+	meta, err := fm.get(sf, "report.pdf")
+	assert.Nil(err)
+	assert.Equal("no-store", meta.CacheControl)
+
+	// 修改时间不变则使用缓存
+	mapFS["report.pdf.meta.json"].Data = []byte(`{"cacheControl":"no-cache"}`)
+	meta, _ = fm.get(sf, "report.pdf")
+	assert.Equal("no-store", meta.CacheControl)
+
+	mapFS["report.pdf.meta.json"].Data = []byte(`{"cacheControl":"max-age=60"}`)
+	mapFS["report.pdf.meta.json"].ModTime = time.Unix(2, 0)
+	meta, _ = fm.get(sf, "report.pdf")
+	assert.Equal("max-age=60", meta.CacheControl)
+
+	delete(mapFS, "report.pdf.meta.json")
+	meta, err = fm.get(sf, "report.pdf")
+	assert.Nil(err)
+	assert.Nil(meta)
+	assert.Empty(fm.items)
+
+	_, err = fm.get(sf, "broken.pdf")
+	assert.NotNil(err)
+	assert.Equal(500, err.(*hes.Error).StatusCode)
+}
+
+func TestServeFileMeta(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"files/report.bin": &fstest.MapFile{
+			Data: []byte("report"),
+		},
+		"files/report.bin.meta.json": &fstest.MapFile{
+			Data: []byte(`{
+				"header": {"X-Retention": "30d", "Vary": "Cookie"},
+				"contentType": "application/pdf",
+				"filename": "年度报告.pdf",
+				"cacheControl": "private, max-age=10"
+			}`),
+		},
+		"files/other.txt": &fstest.MapFile{
+			Data: []byte("other"),
+		},
+		"files/broken.txt": &fstest.MapFile{
+			Data: []byte("broken"),
+		},
+		"files/broken.txt.meta.json": &fstest.MapFile{
+			Data: []byte(`{`),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		EnableFileMeta: true,
+		MaxAge:         60,
+		EnableExpires:  true,
+		Header: map[string]string{
+			"X-Retention": "1d",
+		},
+	}))
+
+	req := httptest.NewRequest("GET", "/files/report.bin", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("report", resp.Body.String())
+	assert.Equal("application/pdf", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("30d", resp.Header().Get("X-Retention"))
+	assert.Equal("Cookie", resp.Header().Get(HeaderVary))
+	assert.Equal(`attachment; filename="____________.pdf"; filename*=UTF-8''%E5%B9%B4%E5%BA%A6%E6%8A%A5%E5%91%8A.pdf`, resp.Header().Get(HeaderContentDisposition))
+	assert.Equal("private, max-age=10", resp.Header().Get(elton.HeaderCacheControl))
+	assert.Empty(resp.Header().Get(HeaderExpires))
+
+	req = httptest.NewRequest("GET", "/files/other.txt", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("1d", resp.Header().Get("X-Retention"))
+	assert.Equal("public, max-age=60", resp.Header().Get(elton.HeaderCacheControl))
+	assert.Empty(resp.Header().Get(HeaderContentDisposition))
+
+	// 元数据文件不可访问
+	req = httptest.NewRequest("GET", "/files/report.bin.meta.json", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(404, resp.Code)
+
+	req = httptest.NewRequest("GET", "/files/broken.txt", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(500, resp.Code)
+}
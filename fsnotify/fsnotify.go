@@ -0,0 +1,143 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fsnotify provides the watcher of static files by fsnotify, the caches of
+// the changed files are removed. The directories are watched recursively.
+package fsnotify
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+type (
+	// Watcher the watcher of static files, it can be used by multi middlewares
+	Watcher struct {
+		watcher   *fsnotify.Watcher
+		mutex     sync.RWMutex
+		handlers  []*handler
+		once      sync.Once
+		closeOnce sync.Once
+		done      chan struct{}
+	}
+	handler struct {
+		root     string
+		onChange func(string)
+	}
+)
+
+var _ staticServe.Watcher = (*Watcher)(nil)
+
+// New create a watcher of static files, it should be closed if not used
+func New() (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		watcher: watcher,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// addDir watch the directory and all its sub directories
+func (w *Watcher) addDir(dir string) error {
+	return filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.watcher.Add(file)
+	})
+}
+
+// Watch watch the root directory(including sub directories), onChange is called
+// with the path of the changed file
+func (w *Watcher) Watch(root string, onChange func(string)) error {
+	root = filepath.Clean(root)
+	err := w.addDir(root)
+	if err != nil {
+		return err
+	}
+	w.mutex.Lock()
+	w.handlers = append(w.handlers, &handler{
+		root:     root,
+		onChange: onChange,
+	})
+	w.mutex.Unlock()
+	w.once.Do(func() {
+		go w.run()
+	})
+	return nil
+}
+
+// isInRoot check the file is in the root directory
+func isInRoot(root, file string) bool {
+	return file == root || strings.HasPrefix(file, root+string(filepath.Separator)) || root == "."
+}
+
+// emit call the handlers of the file
+func (w *Watcher) emit(file string) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	for _, h := range w.handlers {
+		if isInRoot(h.root, file) {
+			h.onChange(file)
+		}
+	}
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// 仅修改权限的忽略
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// 新建的目录也需要监听
+			if event.Op&fsnotify.Create != 0 {
+				_ = w.addDir(event.Name)
+			}
+			w.emit(filepath.Clean(event.Name))
+		case _, ok := <-w.watcher.Errors:
+			// 监听出错时（如事件过多）无法处理，仅依赖缓存的修改时间与大小校验
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Close stop watching
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
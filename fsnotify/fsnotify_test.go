@@ -0,0 +1,74 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsnotify
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsInRoot(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isInRoot("/local", "/local"))
+	assert.True(isInRoot("/local", "/local/app.js"))
+	assert.True(isInRoot(".", "app.js"))
+	assert.False(isInRoot("/local", "/local-secrets/token"))
+}
+
+func TestWatcher(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	w, err := New()
+	assert.Nil(err)
+	defer w.Close()
+
+	mutex := sync.Mutex{}
+	files := make(map[string]bool)
+	err = w.Watch(root, func(file string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		files[file] = true
+	})
+	assert.Nil(err)
+	has := func(file string) func() bool {
+		return func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return files[file]
+		}
+	}
+
+	file := filepath.Join(root, "index.html")
+	assert.Nil(os.WriteFile(file, []byte("index"), 0644))
+	assert.Eventually(has(file), time.Second, 10*time.Millisecond)
+
+	// 新建目录下的文件
+	dir := filepath.Join(root, "js")
+	assert.Nil(os.Mkdir(dir, 0755))
+	assert.Eventually(has(dir), time.Second, 10*time.Millisecond)
+	file = filepath.Join(dir, "app.js")
+	assert.Nil(os.WriteFile(file, []byte("app"), 0644))
+	assert.Eventually(has(file), time.Second, 10*time.Millisecond)
+
+	assert.NotNil(w.Watch(filepath.Join(root, "notfound"), func(string) {}))
+
+	assert.Nil(w.Close())
+	assert.Nil(w.Close())
+}
@@ -1,9 +1,18 @@
 module github.com/vicanso/elton-static-serve
 
-go 1.12
+go 1.16
 
 require (
-	github.com/stretchr/testify v1.5.1
+	github.com/andybalholm/brotli v1.0.4
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/klauspost/compress v1.13.6
+	github.com/prometheus/client_golang v1.11.1
+	github.com/stretchr/testify v1.7.0
 	github.com/vicanso/elton v0.3.0
 	github.com/vicanso/hes v0.2.1
+	github.com/yuin/goldmark v1.4.13
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
 )
@@ -0,0 +1,45 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package goldmark provides the markdown renderer of goldmark,
+// the options of goldmark(such as the extensions) can be specified
+package goldmark
+
+import (
+	"bytes"
+
+	staticServe "github.com/vicanso/elton-static-serve"
+	"github.com/yuin/goldmark"
+)
+
+type renderer struct {
+	md goldmark.Markdown
+}
+
+// NewRenderer create a markdown renderer of goldmark, the default goldmark is used if no option
+func NewRenderer(opts ...goldmark.Option) staticServe.MarkdownRenderer {
+	return &renderer{
+		md: goldmark.New(opts...),
+	}
+}
+
+// Render convert the markdown to html
+func (r *renderer) Render(source []byte) ([]byte, error) {
+	b := bytes.NewBuffer(make([]byte, 0, len(source)*2))
+	err := r.md.Convert(source, b)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
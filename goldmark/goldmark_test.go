@@ -0,0 +1,46 @@
+package goldmark
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestRenderer(t *testing.T) {
+	assert := assert.New(t)
+	buf, err := NewRenderer().Render([]byte("# Hello\n\nworld"))
+	assert.Nil(err)
+	assert.Equal("<h1>Hello</h1>\n<p>world</p>\n", string(buf))
+
+	buf, err = NewRenderer(goldmark.WithExtensions(extension.Strikethrough)).Render([]byte("~~old~~"))
+	assert.Nil(err)
+	assert.Equal("<p><del>old</del></p>\n", string(buf))
+}
+
+func TestServeMarkdown(t *testing.T) {
+	assert := assert.New(t)
+	sf := staticServe.NewIOFS(fstest.MapFS{
+		"docs/README.md": &fstest.MapFile{
+			Data: []byte("# Guide\n\nhello"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", staticServe.New(sf, staticServe.Config{
+		Markdown: &staticServe.MarkdownConfig{
+			Renderer: NewRenderer(),
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/README.md", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("text/html; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+	assert.True(strings.Contains(resp.Body.String(), "<title>Guide</title>"))
+	assert.True(strings.Contains(resp.Body.String(), "<h1>Guide</h1>"))
+}
@@ -0,0 +1,183 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// HeaderVary vary
+	HeaderVary = "Vary"
+	// HeaderAcceptRanges accept ranges
+	HeaderAcceptRanges = "Accept-Ranges"
+	// HeaderAllow allow
+	HeaderAllow = "Allow"
+	// HeaderAccept accept
+	HeaderAccept = "Accept"
+	// HeaderXContentTypeOptions x-content-type-options
+	HeaderXContentTypeOptions = "X-Content-Type-Options"
+	// HeaderXFrameOptions x-frame-options
+	HeaderXFrameOptions = "X-Frame-Options"
+	// HeaderReferrerPolicy referrer-policy
+	HeaderReferrerPolicy = "Referrer-Policy"
+	// HeaderExpires expires
+	HeaderExpires = "Expires"
+	// HeaderAge age
+	HeaderAge = "Age"
+	// HeaderCrossOriginOpenerPolicy cross-origin-opener-policy
+	HeaderCrossOriginOpenerPolicy = "Cross-Origin-Opener-Policy"
+	// HeaderCrossOriginEmbedderPolicy cross-origin-embedder-policy
+	HeaderCrossOriginEmbedderPolicy = "Cross-Origin-Embedder-Policy"
+)
+
+type (
+	// HeaderRule the response header rule of path
+	HeaderRule struct {
+		// 路径匹配，与CacheControlRule的Pattern一致
+		Pattern string
+		// 正则匹配完整路径
+		Regexp *regexp.Regexp
+		// 文件扩展名匹配，如 .woff2 、 .html
+		Extensions []string
+		// 匹配时设置的响应头
+		Header map[string]string
+	}
+	// SecurityHeaders the values of security headers, the default value is used if it's empty,
+	// and the header will not be set if it's "-"
+	SecurityHeaders struct {
+		// 默认为nosniff
+		ContentTypeOptions string
+		// 默认为SAMEORIGIN
+		FrameOptions string
+		// 默认为strict-origin-when-cross-origin
+		ReferrerPolicy string
+	}
+)
+
+// match check the file(url path) matches the rule, any one of Pattern, Regexp
+// and Extensions matched is ok
+func (rule *HeaderRule) match(file string) bool {
+	if rule.Regexp != nil && rule.Regexp.MatchString(file) {
+		return true
+	}
+	if len(rule.Extensions) != 0 {
+		ext := path.Ext(file)
+		for _, item := range rule.Extensions {
+			if ext != "" && strings.EqualFold(item, ext) {
+				return true
+			}
+		}
+	}
+	return matchPathPattern(rule.Pattern, file)
+}
+
+// getRuleHeaders get the headers of all matched rules,
+// the value of later rule overrides the former
+func getRuleHeaders(rules []HeaderRule, file string) []map[string]string {
+	var result []map[string]string
+	for i := range rules {
+		if rules[i].match(file) && len(rules[i].Header) != 0 {
+			result = append(result, rules[i].Header)
+		}
+	}
+	return result
+}
+
+// defaultSecurityHeaders the default values of security headers
+var defaultSecurityHeaders = SecurityHeaders{
+	ContentTypeOptions: "nosniff",
+	FrameOptions:       "SAMEORIGIN",
+	ReferrerPolicy:     "strict-origin-when-cross-origin",
+}
+
+// crossOriginIsolationHeaders the headers of cross-origin isolation for html,
+// which enables SharedArrayBuffer(such as the threaded wasm)
+var crossOriginIsolationHeaders = map[string]string{
+	HeaderCrossOriginOpenerPolicy:   "same-origin",
+	HeaderCrossOriginEmbedderPolicy: "require-corp",
+}
+
+// getSecurityHeaders get the security headers, the default value is used if not specified
+func getSecurityHeaders(headers SecurityHeaders) map[string]string {
+	result := make(map[string]string)
+	add := func(key, value, defaultValue string) {
+		if value == "" {
+			value = defaultValue
+		}
+		if value != "-" {
+			result[key] = value
+		}
+	}
+	add(HeaderXContentTypeOptions, headers.ContentTypeOptions, defaultSecurityHeaders.ContentTypeOptions)
+	add(HeaderXFrameOptions, headers.FrameOptions, defaultSecurityHeaders.FrameOptions)
+	add(HeaderReferrerPolicy, headers.ReferrerPolicy, defaultSecurityHeaders.ReferrerPolicy)
+	return result
+}
+
+// mergeHeaderValues merge the values into the comma-separated header,
+// the duplicate values(case-insensitive) will be ignored
+func mergeHeaderValues(header http.Header, key string, values ...string) {
+	if header == nil || len(values) == 0 {
+		return
+	}
+	result := make([]string, 0, len(values))
+	exists := make(map[string]bool)
+	add := func(value string) {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			k := strings.ToLower(item)
+			if exists[k] {
+				continue
+			}
+			exists[k] = true
+			result = append(result, item)
+		}
+	}
+	for _, value := range header[http.CanonicalHeaderKey(key)] {
+		add(value)
+	}
+	for _, value := range values {
+		add(value)
+	}
+	if len(result) == 0 {
+		return
+	}
+	header.Set(key, strings.Join(result, ", "))
+}
+
+// addVary add the values to the Vary header of response, all content negotiation
+// features should use it instead of setting the header directly, so that the values
+// set by other features or middlewares are kept. The Vary header is "*" if any value is "*".
+func addVary(header http.Header, values ...string) {
+	if header == nil {
+		return
+	}
+	for _, value := range append(header[HeaderVary], values...) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.TrimSpace(item) == "*" {
+				header.Set(HeaderVary, "*")
+				return
+			}
+		}
+	}
+	mergeHeaderValues(header, HeaderVary, values...)
+}
@@ -0,0 +1,91 @@
+package staticserve
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeHeaderValues(t *testing.T) {
+	assert := assert.New(t)
+	header := make(http.Header)
+
+	mergeHeaderValues(header, HeaderVary)
+	assert.Empty(header.Get(HeaderVary))
+
+	mergeHeaderValues(header, HeaderVary, "Accept-Encoding")
+	assert.Equal("Accept-Encoding", header.Get(HeaderVary))
+
+	header.Add(HeaderVary, "Origin, accept-encoding")
+	mergeHeaderValues(header, HeaderVary, "Origin", "Accept")
+	assert.Equal("Accept-Encoding, Origin, Accept", header.Get(HeaderVary))
+	assert.Equal(1, len(header[HeaderVary]))
+
+	mergeHeaderValues(nil, HeaderVary, "Origin")
+}
+
+func TestAddVary(t *testing.T) {
+	assert := assert.New(t)
+	header := make(http.Header)
+	addVary(header, "Accept-Encoding")
+	addVary(header, "Accept", "accept-encoding")
+	assert.Equal("Accept-Encoding, Accept", header.Get(HeaderVary))
+
+	addVary(header, "*")
+	assert.Equal("*", header.Get(HeaderVary))
+	addVary(header, "Origin")
+	assert.Equal("*", header.Get(HeaderVary))
+
+	addVary(nil, "Origin")
+}
+
+func TestGetSecurityHeaders(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(map[string]string{
+		HeaderXContentTypeOptions: "nosniff",
+		HeaderXFrameOptions:       "SAMEORIGIN",
+		HeaderReferrerPolicy:      "strict-origin-when-cross-origin",
+	}, getSecurityHeaders(SecurityHeaders{}))
+	assert.Equal(map[string]string{
+		HeaderXContentTypeOptions: "nosniff",
+		HeaderXFrameOptions:       "DENY",
+	}, getSecurityHeaders(SecurityHeaders{
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "-",
+	}))
+}
+
+func TestGetRuleHeaders(t *testing.T) {
+	assert := assert.New(t)
+	rules := []HeaderRule{
+		{
+			Extensions: []string{".woff", ".woff2"},
+			Header: map[string]string{
+				"Cross-Origin-Resource-Policy": "cross-origin",
+			},
+		},
+		{
+			Pattern: "*.html",
+			Header: map[string]string{
+				"Content-Security-Policy": "default-src 'self'",
+			},
+		},
+		{
+			Regexp: regexp.MustCompile(`^/admin/`),
+			Header: map[string]string{
+				"Content-Security-Policy": "default-src 'none'",
+			},
+		},
+	}
+	assert.Equal([]map[string]string{
+		rules[0].Header,
+	}, getRuleHeaders(rules, "/fonts/a.WOFF2"))
+	assert.Equal([]map[string]string{
+		rules[1].Header,
+		rules[2].Header,
+	}, getRuleHeaders(rules, "/admin/index.html"))
+	assert.Empty(getRuleHeaders(rules, "/app.js"))
+	assert.Empty(getRuleHeaders(rules, "/woff"))
+}
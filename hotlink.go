@@ -0,0 +1,107 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// HeaderReferer referer
+const HeaderReferer = "Referer"
+
+// defaultHotlinkExtensions the default extensions of hotlink protection
+var defaultHotlinkExtensions = []string{
+	".avif",
+	".gif",
+	".jpeg",
+	".jpg",
+	".png",
+	".svg",
+	".webp",
+	".mp4",
+	".webm",
+}
+
+type (
+	// HotlinkConfig the config of referer based hotlink protection
+	HotlinkConfig struct {
+		// 允许的referer host，如 example.com，*.example.com 匹配所有子域名，与请求的host相同的referer也允许
+		AllowHosts []string
+		// 是否允许无referer的请求（如直接打开或隐私策略不发送referer）
+		AllowEmptyReferer bool
+		// 需要防盗链的文件扩展名，默认为常用的图片与视频
+		Extensions []string
+		// 盗链时返回的文件（相对于Path），为空则返回403
+		Placeholder string
+	}
+)
+
+// getHostname get the hostname without port
+func getHostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// isHostMatched check the host matches the pattern, the pattern *.example.com
+// matches all sub domains of example.com
+func isHostMatched(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return strings.EqualFold(pattern, host)
+}
+
+// match check the file should be protected
+func (hotlink *HotlinkConfig) match(file string) bool {
+	exts := hotlink.Extensions
+	if len(exts) == 0 {
+		exts = defaultHotlinkExtensions
+	}
+	ext := filepath.Ext(file)
+	for _, item := range exts {
+		if ext != "" && strings.EqualFold(item, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowed check the request is allowed by the referer
+func (hotlink *HotlinkConfig) isAllowed(req *http.Request) bool {
+	referer := req.Header.Get(HeaderReferer)
+	if referer == "" {
+		return hotlink.AllowEmptyReferer
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(getHostname(u.Host))
+	if host == strings.ToLower(getHostname(req.Host)) {
+		return true
+	}
+	for _, item := range hotlink.AllowHosts {
+		if isHostMatched(strings.ToLower(item), host) {
+			return true
+		}
+	}
+	return false
+}
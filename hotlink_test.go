@@ -0,0 +1,59 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsHostMatched(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isHostMatched("example.com", "example.com"))
+	assert.True(isHostMatched("*.example.com", "cdn.example.com"))
+	assert.False(isHostMatched("*.example.com", "example.com"))
+	assert.False(isHostMatched("*.example.com", "badexample.com"))
+	assert.False(isHostMatched("example.com", "a.com"))
+}
+
+func TestHotlinkConfig(t *testing.T) {
+	assert := assert.New(t)
+	hotlink := &HotlinkConfig{
+		AllowHosts: []string{
+			"*.example.com",
+		},
+	}
+	assert.True(hotlink.match("/banner.JPG"))
+	assert.False(hotlink.match("/index.html"))
+	hotlink.Extensions = []string{".html"}
+	assert.True(hotlink.match("/index.html"))
+
+	req := httptest.NewRequest("GET", "http://static.com/banner.jpg", nil)
+	assert.False(hotlink.isAllowed(req))
+	hotlink.AllowEmptyReferer = true
+	assert.True(hotlink.isAllowed(req))
+
+	req.Header.Set(HeaderReferer, "https://www.example.com/index.html")
+	assert.True(hotlink.isAllowed(req))
+	// 与请求的host相同
+	req.Header.Set(HeaderReferer, "http://static.com:8080/")
+	assert.True(hotlink.isAllowed(req))
+	req.Header.Set(HeaderReferer, "https://a.com/")
+	assert.False(hotlink.isAllowed(req))
+	req.Header.Set(HeaderReferer, "abc")
+	assert.False(hotlink.isAllowed(req))
+}
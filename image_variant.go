@@ -0,0 +1,53 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+// imageVariantExts the file extension of image variant
+var imageVariantExts = map[string]string{
+	"avif": ".avif",
+	"webp": ".webp",
+}
+
+// imageVariantContentTypes the content type of image variant
+var imageVariantContentTypes = map[string]string{
+	"avif": "image/avif",
+	"webp": "image/webp",
+}
+
+// getImageVariant get the image variant which is exists and accepted, the variant of
+// higher quality is preferred, and then the order of variants. The wildcard(image/* or */*)
+// is ignored because browsers always send it. The second result is true if any variant exists.
+func getImageVariant(staticFile StaticFile, file, accept string, variants []string) (string, bool) {
+	// Accept与Accept-Encoding的格式一致，因此使用相同的解析
+	accepts := ParseAcceptEncoding(accept)
+	result := ""
+	max := 0.0
+	exists := false
+	for _, variant := range variants {
+		ext := imageVariantExts[variant]
+		if ext == "" || !staticFile.Exists(file+ext) {
+			continue
+		}
+		exists = true
+		contentType := imageVariantContentTypes[variant]
+		for _, item := range accepts {
+			if item.Encoding == contentType && item.Quality > max {
+				max = item.Quality
+				result = variant
+			}
+		}
+	}
+	return result, exists
+}
@@ -0,0 +1,96 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestGetImageVariant(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"photo.jpg":      &fstest.MapFile{},
+		"photo.jpg.avif": &fstest.MapFile{},
+		"photo.jpg.webp": &fstest.MapFile{},
+		"logo.png":       &fstest.MapFile{},
+		"logo.png.webp":  &fstest.MapFile{},
+	})
+	variants := []string{"avif", "webp", "jxl"}
+	chrome := "image/avif,image/webp,image/apng,image/*,*/*;q=0.8"
+
+	variant, ok := getImageVariant(sf, "/photo.jpg", chrome, variants)
+	assert.True(ok)
+	assert.Equal("avif", variant)
+
+	variant, _ = getImageVariant(sf, "/photo.jpg", "image/avif;q=0.5,image/webp", variants)
+	assert.Equal("webp", variant)
+
+	variant, ok = getImageVariant(sf, "/photo.jpg", "image/*,*/*;q=0.8", variants)
+	assert.True(ok)
+	assert.Empty(variant)
+
+	variant, _ = getImageVariant(sf, "/logo.png", chrome, variants)
+	assert.Equal("webp", variant)
+
+	variant, ok = getImageVariant(sf, "/banner.jpg", chrome, variants)
+	assert.False(ok)
+	assert.Empty(variant)
+}
+
+func TestServeImageVariants(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"photo.jpg": &fstest.MapFile{
+			Data: []byte("jpeg"),
+		},
+		"photo.jpg.avif": &fstest.MapFile{
+			Data: []byte("avif"),
+		},
+		"app.js": &fstest.MapFile{
+			Data: []byte("js"),
+		},
+		"app.js.avif": &fstest.MapFile{
+			Data: []byte("avif"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		ImageVariants: []string{"avif", "webp"},
+		MaxAge:        60,
+		CacheControlRules: []CacheControlRule{
+			{
+				Pattern:      "*.jpg",
+				CacheControl: "public, max-age=3600",
+			},
+		},
+	}))
+
+	req := httptest.NewRequest("GET", "/photo.jpg", nil)
+	req.Header.Set(HeaderAccept, "image/avif,image/webp,*/*;q=0.8")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("avif", resp.Body.String())
+	assert.Equal("image/avif", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("Accept", resp.Header().Get(HeaderVary))
+	// 缓存规则使用原始的文件匹配
+	assert.Equal("public, max-age=3600", resp.Header().Get(elton.HeaderCacheControl))
+
+	req = httptest.NewRequest("GET", "/photo.jpg", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("jpeg", resp.Body.String())
+	assert.Equal("image/jpeg", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("Accept", resp.Header().Get(HeaderVary))
+
+	// 非图片不处理
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(HeaderAccept, "image/avif")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("js", resp.Body.String())
+	assert.Empty(resp.Header().Get(HeaderVary))
+}
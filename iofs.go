@@ -0,0 +1,108 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vicanso/elton"
+)
+
+type (
+	// IOFS static file of io/fs.FS, such as embed.FS
+	IOFS struct {
+		fsys fs.FS
+	}
+)
+
+// NewIOFS create a static file of io/fs.FS
+func NewIOFS(fsys fs.FS) *IOFS {
+	return &IOFS{
+		fsys: fsys,
+	}
+}
+
+// NewFromFS create a static serve middleware use io/fs.FS
+func NewFromFS(fsys fs.FS, config Config) elton.Handler {
+	return New(NewIOFS(fsys), config)
+}
+
+// toFSPath convert the file to the path of fs.FS(unrooted and slash-separated)
+func toFSPath(file string) string {
+	file = strings.TrimPrefix(path.Clean(filepath.ToSlash(file)), "/")
+	if file == "" {
+		return "."
+	}
+	return file
+}
+
+// Exists check the file exists
+func (f *IOFS) Exists(file string) bool {
+	_, err := fs.Stat(f.fsys, toFSPath(file))
+	return err == nil
+}
+
+// Stat get stat of file
+func (f *IOFS) Stat(file string) os.FileInfo {
+	info, err := fs.Stat(f.fsys, toFSPath(file))
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// Get get the file's content
+func (f *IOFS) Get(file string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, toFSPath(file))
+}
+
+// NewReader new a reader for file
+func (f *IOFS) NewReader(file string) (io.Reader, error) {
+	return f.fsys.Open(toFSPath(file))
+}
+
+// NewReadSeeker new a read seeker for file, it returns ErrNotSeekable
+// if the file of fs.FS doesn't implement io.Seeker
+func (f *IOFS) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	fsFile, err := f.fsys.Open(toFSPath(file))
+	if err != nil {
+		return nil, err
+	}
+	rs, ok := fsFile.(io.ReadSeeker)
+	if !ok {
+		_ = fsFile.Close()
+		return nil, ErrNotSeekable
+	}
+	return rs, nil
+}
+
+// ReadDirEntries read the entries of directory
+func (f *IOFS) ReadDirEntries(dir string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.fsys, toFSPath(dir))
+}
+
+// ReadDir read the file infos of directory
+func (f *IOFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := f.ReadDirEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	return dirEntriesToInfos(entries), nil
+}
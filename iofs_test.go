@@ -0,0 +1,148 @@
+package staticserve
+
+import (
+	"io/fs"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func newTestMapFS() fstest.MapFS {
+	modTime := time.Unix(1559960274, 0)
+	return fstest.MapFS{
+		"index.html": &fstest.MapFile{
+			Data:    []byte("<html>index</html>"),
+			ModTime: modTime,
+		},
+		"assets/app.js": &fstest.MapFile{
+			Data:    []byte("console.log('app')"),
+			ModTime: modTime,
+		},
+	}
+}
+
+type notSeekFS struct {
+	fs.FS
+}
+type notSeekFile struct {
+	fs.File
+}
+
+func (f *notSeekFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &notSeekFile{
+		File: file,
+	}, nil
+}
+
+func TestToFSPath(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(".", toFSPath(""))
+	assert.Equal(".", toFSPath("/"))
+	assert.Equal("index.html", toFSPath("/index.html"))
+	assert.Equal("assets/app.js", toFSPath("assets/app.js"))
+	assert.Equal("assets", toFSPath("/assets/"))
+}
+
+func TestIOFS(t *testing.T) {
+	assert := assert.New(t)
+	f := NewIOFS(newTestMapFS())
+
+	assert.True(f.Exists("/index.html"))
+	assert.False(f.Exists("/notfound.html"))
+
+	assert.Equal(int64(18), f.Stat("/index.html").Size())
+	assert.True(f.Stat("/assets").IsDir())
+	assert.Nil(f.Stat("/notfound.html"))
+
+	buf, err := f.Get("/index.html")
+	assert.Nil(err)
+	assert.Equal("<html>index</html>", string(buf))
+
+	r, err := f.NewReader("/assets/app.js")
+	assert.Nil(err)
+	assert.NotNil(r)
+
+	rs, err := f.NewReadSeeker("/index.html")
+	assert.Nil(err)
+	size, ok := getSeekerSize(rs)
+	assert.True(ok)
+	assert.Equal(int64(18), size)
+	_, err = f.NewReadSeeker("/notfound.html")
+	assert.NotNil(err)
+	// 文件不支持seek
+	_, err = NewIOFS(&notSeekFS{
+		FS: newTestMapFS(),
+	}).NewReadSeeker("/index.html")
+	assert.Equal(ErrNotSeekable, err)
+
+	infos, err := f.ReadDir("/")
+	assert.Nil(err)
+	assert.Equal(2, len(infos))
+	_, err = f.ReadDir("/notfound")
+	assert.NotNil(err)
+}
+
+func TestNewFromFS(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", NewFromFS(newTestMapFS(), Config{
+		EnableRange: true,
+	}))
+	for url, content := range map[string]string{
+		"/":              "<html>index</html>",
+		"/assets/app.js": "console.log('app')",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(content, resp.Body.String())
+		assert.Equal("Sat, 08 Jun 2019 02:17:54 GMT", resp.Header().Get(elton.HeaderLastModified))
+	}
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.html", nil))
+	assert.Equal(404, resp.Code)
+
+	// 通过Path指定子目录
+	e = elton.New()
+	e.GET("/*file", NewFromFS(newTestMapFS(), Config{
+		Path: "assets",
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(200, resp.Code)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/../index.html", nil))
+	assert.NotEqual(200, resp.Code)
+}
+
+func TestModifiedTime(t *testing.T) {
+	assert := assert.New(t)
+	fsys := fstest.MapFS{
+		"index.html": &fstest.MapFile{
+			Data: []byte("<html>index</html>"),
+		},
+	}
+	e := elton.New()
+	e.GET("/*file", NewFromFS(fsys, Config{}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Empty(resp.Header().Get(elton.HeaderLastModified))
+
+	e = elton.New()
+	e.GET("/*file", NewFromFS(fsys, Config{
+		ModifiedTime: time.Unix(1559960274, 0),
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal("Sat, 08 Jun 2019 02:17:54 GMT", resp.Header().Get(elton.HeaderLastModified))
+	assert.Equal(`W/"12-5cfb1ad2"`, resp.Header().Get(elton.HeaderETag))
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrIPNotAllowed the ip of client is not allowed
+var ErrIPNotAllowed = getStaticServeError("ip is not allowed", http.StatusForbidden)
+
+type (
+	// IPRule the ip restriction rule of path
+	IPRule struct {
+		// 路径匹配，与CacheControlRule的Pattern一致，如 /internal/ 、 *.map
+		Pattern string
+		// 允许访问的ip或CIDR，如 10.0.0.0/8 ，不为空时仅允许此列表访问
+		Allow []string
+		// 禁止访问的ip或CIDR，优先于Allow
+		Deny []string
+	}
+	ipRule struct {
+		pattern string
+		allow   []*net.IPNet
+		deny    []*net.IPNet
+	}
+)
+
+// parseIPNets parse the ip or CIDR list
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ipNet)
+	}
+	return result, nil
+}
+
+// newIPRules new the ip rules, it returns error if the ip or CIDR is invalid
+func newIPRules(rules []IPRule) ([]*ipRule, error) {
+	result := make([]*ipRule, 0, len(rules))
+	for _, rule := range rules {
+		allow, err := parseIPNets(rule.Allow)
+		if err != nil {
+			return nil, err
+		}
+		deny, err := parseIPNets(rule.Deny)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &ipRule{
+			pattern: rule.Pattern,
+			allow:   allow,
+			deny:    deny,
+		})
+	}
+	return result, nil
+}
+
+func containsIP(ipNets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range ipNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAllowed check the ip is allowed by the rule
+func (rule *ipRule) isAllowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if containsIP(rule.deny, ip) {
+		return false
+	}
+	if len(rule.allow) != 0 {
+		return containsIP(rule.allow, ip)
+	}
+	return true
+}
+
+// isIPAllowed check the ip is allowed by all matched rules
+func isIPAllowed(rules []*ipRule, file, ip string) bool {
+	clientIP := net.ParseIP(getHostname(ip))
+	for _, rule := range rules {
+		if matchPathPattern(rule.pattern, file) && !rule.isAllowed(clientIP) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIPRules(t *testing.T) {
+	assert := assert.New(t)
+	_, err := newIPRules([]IPRule{
+		{
+			Pattern: "/internal/",
+			Allow:   []string{"10.0.0.0/33"},
+		},
+	})
+	assert.NotNil(err)
+	_, err = newIPRules([]IPRule{
+		{
+			Pattern: "/internal/",
+			Deny:    []string{"abc"},
+		},
+	})
+	assert.NotNil(err)
+}
+
+func TestIsIPAllowed(t *testing.T) {
+	assert := assert.New(t)
+	rules, err := newIPRules([]IPRule{
+		{
+			Pattern: "/internal/",
+			Allow: []string{
+				"10.0.0.0/8",
+				"::1",
+			},
+			Deny: []string{
+				"10.0.0.1",
+			},
+		},
+		{
+			Pattern: "*.map",
+			Allow: []string{
+				"192.168.0.0/16",
+			},
+		},
+	})
+	assert.Nil(err)
+
+	assert.True(isIPAllowed(rules, "/internal/index.html", "10.1.1.1"))
+	assert.True(isIPAllowed(rules, "/internal/index.html", "10.1.1.1:3000"))
+	assert.True(isIPAllowed(rules, "/internal/index.html", "::1"))
+	assert.False(isIPAllowed(rules, "/internal/index.html", "10.0.0.1"))
+	assert.False(isIPAllowed(rules, "/internal/index.html", "1.1.1.1"))
+	assert.False(isIPAllowed(rules, "/internal/index.html", ""))
+	// 匹配的规则均需要允许
+	assert.False(isIPAllowed(rules, "/internal/app.js.map", "10.1.1.1"))
+	assert.True(isIPAllowed(rules, "/app.js.map", "192.168.1.1"))
+	assert.True(isIPAllowed(rules, "/index.html", "1.1.1.1"))
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// HeaderAcceptLanguage accept-language
+	HeaderAcceptLanguage = "Accept-Language"
+	// HeaderContentLanguage content-language
+	HeaderContentLanguage = "Content-Language"
+)
+
+// getLanguageFile get the file of language variant, the language is inserted
+// before the extension, such as index.en.html for index.html
+func getLanguageFile(file, lang string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + lang + ext
+}
+
+// matchLanguage check the language range of Accept-Language matches the language tag(lower case),
+// such as zh matches zh-cn, and en-us matches en if exact is false
+func matchLanguage(langRange, tag string, exact bool) bool {
+	if langRange == tag {
+		return true
+	}
+	if exact {
+		return false
+	}
+	return strings.HasPrefix(tag, langRange+"-") || strings.HasPrefix(langRange, tag+"-")
+}
+
+// getLanguageVariant get the language variant which is exists and accepted, the language of higher
+// quality is preferred, and then the exact match and the order of languages. The default language
+// is used if none is accepted. The second result is true if any variant exists.
+func getLanguageVariant(staticFile StaticFile, file, acceptLanguage string, languages []string, defaultLanguage string) (string, bool) {
+	existsLanguages := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		if staticFile.Exists(getLanguageFile(file, lang)) {
+			existsLanguages = append(existsLanguages, lang)
+		}
+	}
+	if len(existsLanguages) == 0 {
+		return "", false
+	}
+	// Accept-Language与Accept-Encoding的格式一致，因此使用相同的解析
+	for _, item := range ParseAcceptEncoding(acceptLanguage) {
+		if item.Quality <= 0 || item.Encoding == "*" {
+			continue
+		}
+		for _, exact := range []bool{true, false} {
+			for _, lang := range existsLanguages {
+				if matchLanguage(item.Encoding, strings.ToLower(lang), exact) {
+					return lang, true
+				}
+			}
+		}
+	}
+	for _, lang := range existsLanguages {
+		if lang == defaultLanguage {
+			return lang, true
+		}
+	}
+	return "", true
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestGetLanguageFile(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("/docs/index.en.html", getLanguageFile("/docs/index.html", "en"))
+	assert.Equal("/docs/README.zh-CN", getLanguageFile("/docs/README", "zh-CN"))
+}
+
+func TestGetLanguageVariant(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"docs/index.html":       &fstest.MapFile{},
+		"docs/index.en.html":    &fstest.MapFile{},
+		"docs/index.zh-CN.html": &fstest.MapFile{},
+		"docs/index.en-GB.html": &fstest.MapFile{},
+	})
+	languages := []string{"en", "zh-CN", "en-GB", "ja"}
+
+	lang, ok := getLanguageVariant(sf, "/docs/index.html", "zh-CN,zh;q=0.9,en;q=0.8", languages, "en")
+	assert.True(ok)
+	assert.Equal("zh-CN", lang)
+
+	// 前缀匹配
+	lang, _ = getLanguageVariant(sf, "/docs/index.html", "zh", languages, "en")
+	assert.Equal("zh-CN", lang)
+	lang, _ = getLanguageVariant(sf, "/docs/index.html", "en-US", languages, "")
+	assert.Equal("en", lang)
+
+	// 优先完全匹配
+	lang, _ = getLanguageVariant(sf, "/docs/index.html", "en-GB", languages, "")
+	assert.Equal("en-GB", lang)
+
+	// 按q值优先
+	lang, _ = getLanguageVariant(sf, "/docs/index.html", "en;q=0.5,zh-cn", languages, "")
+	assert.Equal("zh-CN", lang)
+
+	// 文件不存在的语言不匹配，使用默认语言
+	lang, ok = getLanguageVariant(sf, "/docs/index.html", "ja,*;q=0.5", languages, "en")
+	assert.True(ok)
+	assert.Equal("en", lang)
+
+	lang, ok = getLanguageVariant(sf, "/docs/index.html", "fr", languages, "")
+	assert.True(ok)
+	assert.Empty(lang)
+
+	lang, ok = getLanguageVariant(sf, "/docs/about.html", "en", languages, "en")
+	assert.False(ok)
+	assert.Empty(lang)
+}
+
+func TestServeLanguages(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"docs/index.html": &fstest.MapFile{
+			Data: []byte("default"),
+		},
+		"docs/index.zh-CN.html": &fstest.MapFile{
+			Data: []byte("中文"),
+		},
+		"docs/about.html": &fstest.MapFile{
+			Data: []byte("about"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Languages:       []string{"en", "zh-CN"},
+		DefaultLanguage: "en",
+	}))
+
+	req := httptest.NewRequest("GET", "/docs/index.html", nil)
+	req.Header.Set(HeaderAcceptLanguage, "zh-CN,zh;q=0.9,en;q=0.8")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("中文", resp.Body.String())
+	assert.Equal("zh-CN", resp.Header().Get(HeaderContentLanguage))
+	assert.Equal("Accept-Language", resp.Header().Get(HeaderVary))
+	assert.Equal("text/html; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+
+	// 无匹配时使用原文件
+	req = httptest.NewRequest("GET", "/docs/index.html", nil)
+	req.Header.Set(HeaderAcceptLanguage, "fr")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("default", resp.Body.String())
+	assert.Equal("en", resp.Header().Get(HeaderContentLanguage))
+	assert.Equal("Accept-Language", resp.Header().Get(HeaderVary))
+
+	// 无语言文件不设置Vary
+	req = httptest.NewRequest("GET", "/docs/about.html", nil)
+	req.Header.Set(HeaderAcceptLanguage, "zh-CN")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("about", resp.Body.String())
+	assert.Equal("en", resp.Header().Get(HeaderContentLanguage))
+	assert.Empty(resp.Header().Get(HeaderVary))
+}
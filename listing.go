@@ -0,0 +1,169 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vicanso/elton"
+)
+
+type (
+	// DirListingEntry the entry of directory listing
+	DirListingEntry struct {
+		Name    string    `json:"name"`
+		URL     string    `json:"url"`
+		Size    int64     `json:"size"`
+		ModTime time.Time `json:"modTime"`
+		IsDir   bool      `json:"isDir"`
+	}
+	// DirListing the directory listing
+	DirListing struct {
+		// Path the url path of directory
+		Path string `json:"path"`
+		// Parent the url path of parent directory, it's empty for root
+		Parent  string             `json:"parent,omitempty"`
+		Entries []*DirListingEntry `json:"entries"`
+	}
+)
+
+var defaultListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of {{.Path}}</title>
+</head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<thead><tr><th>Name</th><th>Size</th><th>Modified</th></tr></thead>
+<tbody>
+{{if .Parent}}<tr><td><a href="{{.Parent}}">../</a></td><td>-</td><td>-</td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if .IsDir}}-{{else}}{{.Size}}{{end}}</td><td>{{.ModTime.UTC.Format "2006-01-02 15:04:05"}}</td></tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
+
+// newDirListing create a directory listing, the directories are sorted before files
+func newDirListing(urlPath string, infos []os.FileInfo, filter func(os.FileInfo) bool) *DirListing {
+	urlPath = path.Clean("/" + urlPath)
+	dirPath := urlPath
+	if !strings.HasSuffix(dirPath, "/") {
+		dirPath += "/"
+	}
+	listing := &DirListing{
+		Path:    dirPath,
+		Entries: make([]*DirListingEntry, 0, len(infos)),
+	}
+	if urlPath != "/" {
+		parent := path.Dir(urlPath)
+		if parent != "/" {
+			parent += "/"
+		}
+		listing.Parent = parent
+	}
+	for _, info := range infos {
+		if filter != nil && !filter(info) {
+			continue
+		}
+		entry := &DirListingEntry{
+			Name:    info.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   info.IsDir(),
+		}
+		link := dirPath + info.Name()
+		if entry.IsDir {
+			link += "/"
+		}
+		entry.URL = (&url.URL{Path: link}).String()
+		listing.Entries = append(listing.Entries, entry)
+	}
+	entries := listing.Entries
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return listing
+}
+
+// renderHTML render the directory listing as html, the default template will be used if tpl is nil
+func (listing *DirListing) renderHTML(tpl *template.Template) ([]byte, error) {
+	if tpl == nil {
+		tpl = defaultListingTemplate
+	}
+	buf := new(bytes.Buffer)
+	err := tpl.Execute(buf, listing)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// acceptsJSON check the client prefers json to html
+func acceptsJSON(accept string) bool {
+	for _, item := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(item, ";", 2)[0])
+		switch strings.ToLower(mediaType) {
+		case "application/json":
+			return true
+		case "text/html":
+			return false
+		}
+	}
+	return false
+}
+
+// serveDirListing serve the listing of directory, it returns false if the directory can't be read.
+// The listing is responded as json if forceJSON is true or the client prefers json.
+func serveDirListing(c *elton.Context, dirLister DirLister, dir string, filter func(os.FileInfo) bool, forceJSON bool, tpl *template.Template) (bool, error) {
+	infos, err := dirLister.ReadDir(dir)
+	if err != nil {
+		return false, nil
+	}
+	listing := newDirListing(c.Request.URL.Path, infos, filter)
+	var buf []byte
+	contentType := "text/html; charset=utf-8"
+	if !forceJSON {
+		addVary(c.Headers, HeaderAccept)
+	}
+	if forceJSON || acceptsJSON(c.GetRequestHeader("Accept")) {
+		contentType = elton.MIMEApplicationJSON
+		buf, err = json.Marshal(listing)
+	} else {
+		buf, err = listing.renderHTML(tpl)
+	}
+	if err != nil {
+		return true, getStaticServeError(err.Error(), http.StatusInternalServerError)
+	}
+	c.SetHeader(elton.HeaderContentType, contentType)
+	// 目录列表为动态生成，不缓存
+	c.NoCache()
+	c.BodyBuffer = bytes.NewBuffer(buf)
+	return true, nil
+}
@@ -0,0 +1,358 @@
+package staticserve
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
+)
+
+type listingFileInfo struct {
+	MockFileStat
+	name  string
+	isDir bool
+}
+
+func (info *listingFileInfo) Name() string {
+	return info.name
+}
+
+func (info *listingFileInfo) IsDir() bool {
+	return info.isDir
+}
+
+func TestNewDirListing(t *testing.T) {
+	assert := assert.New(t)
+	infos := []os.FileInfo{
+		&listingFileInfo{name: "b.js"},
+		&listingFileInfo{name: "a b.css"},
+		&listingFileInfo{name: "lib", isDir: true},
+		&listingFileInfo{name: ".env"},
+	}
+	listing := newDirListing("/assets", infos, func(info os.FileInfo) bool {
+		return info.Name() != ".env"
+	})
+	assert.Equal("/assets/", listing.Path)
+	assert.Equal("/", listing.Parent)
+	assert.Equal(3, len(listing.Entries))
+	assert.Equal("lib", listing.Entries[0].Name)
+	assert.Equal("/assets/lib/", listing.Entries[0].URL)
+	assert.Equal("/assets/a%20b.css", listing.Entries[1].URL)
+	assert.Equal("b.js", listing.Entries[2].Name)
+
+	listing = newDirListing("/assets/lib/", nil, nil)
+	assert.Equal("/assets/", listing.Parent)
+
+	listing = newDirListing("/", nil, nil)
+	assert.Empty(listing.Parent)
+
+	buf, err := newDirListing("/assets", infos, nil).renderHTML(nil)
+	assert.Nil(err)
+	assert.Contains(string(buf), `<a href="/assets/lib/">lib/</a>`)
+	assert.Contains(string(buf), `<a href="/">../</a>`)
+
+	tpl := template.Must(template.New("custom").Parse(`{{.Path}}:{{range .Entries}}{{.Name}},{{end}}`))
+	buf, err = newDirListing("/assets", infos, nil).renderHTML(tpl)
+	assert.Nil(err)
+	assert.Equal("/assets/:lib,.env,a b.css,b.js,", string(buf))
+
+	tpl = template.Must(template.New("error").Parse(`{{.NotExists}}`))
+	_, err = newDirListing("/assets", infos, nil).renderHTML(tpl)
+	assert.NotNil(err)
+}
+
+func TestAcceptsJSON(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(acceptsJSON("application/json"))
+	assert.True(acceptsJSON("application/json;q=0.9, text/html"))
+	assert.False(acceptsJSON("text/html,application/xhtml+xml,application/json;q=0.9"))
+	assert.False(acceptsJSON("*/*"))
+	assert.False(acceptsJSON(""))
+}
+
+func TestServeDirListing(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	err := os.Mkdir(filepath.Join(root, "sub"), 0755)
+	assert.Nil(err)
+	for _, name := range []string{
+		"app.js",
+		".secret",
+	} {
+		err = ioutil.WriteFile(filepath.Join(root, name), []byte(name), 0644)
+		assert.Nil(err)
+	}
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		DenyDot:                true,
+		EnableDirectoryListing: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("text/html; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("no-cache", resp.Header().Get(elton.HeaderCacheControl))
+	assert.Contains(resp.Body.String(), "app.js")
+	assert.Contains(resp.Body.String(), `<a href="/sub/">sub/</a>`)
+	assert.NotContains(resp.Body.String(), ".secret")
+
+	assert.Equal("Accept", resp.Header().Get(HeaderVary))
+
+	// 根据Accept返回json
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(elton.MIMEApplicationJSON, resp.Header().Get(elton.HeaderContentType))
+	listing := &DirListing{}
+	err = json.Unmarshal(resp.Body.Bytes(), listing)
+	assert.Nil(err)
+	assert.Equal("/", listing.Path)
+	assert.Equal(2, len(listing.Entries))
+	assert.Equal("sub", listing.Entries[0].Name)
+	assert.True(listing.Entries[0].IsDir)
+	assert.Equal("app.js", listing.Entries[1].Name)
+	assert.Equal(int64(6), listing.Entries[1].Size)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/not-exists/", nil))
+	assert.Equal(404, resp.Code)
+
+	// 配置使用json
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		DirectoryListingJSON:   true,
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(elton.MIMEApplicationJSON, resp.Header().Get(elton.HeaderContentType))
+	assert.Empty(resp.Header().Get(HeaderVary))
+
+	// 自定义模板
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                     root,
+		EnableDirectoryListing:   true,
+		DirectoryListingTemplate: template.Must(template.New("custom").Parse(`<p>{{len .Entries}} entries</p>`)),
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal("<p>3 entries</p>", resp.Body.String())
+
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                     root,
+		EnableDirectoryListing:   true,
+		DirectoryListingTemplate: template.Must(template.New("error").Parse(`{{.NotExists}}`)),
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(500, resp.Code)
+
+	// 未启用目录列表
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path: root,
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(404, resp.Code)
+}
+
+func TestServeDirListingAccess(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "private"), 0755))
+	assert.Nil(os.MkdirAll(filepath.Join(root, "public"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "private", "report.pdf"), []byte("report"), 0644))
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "public", "app.js"), []byte("app"), 0644))
+
+	authorized := make([]string, 0)
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		Authorize: func(c *elton.Context, file string) error {
+			authorized = append(authorized, file)
+			if strings.HasPrefix(file, "/private") {
+				return hes.NewWithStatusCode("unauthorized", 401)
+			}
+			return nil
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/private/", nil))
+	assert.Equal(401, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/public/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Contains(resp.Body.String(), "app.js")
+	assert.Equal([]string{"/private", "/public"}, authorized)
+
+	// 仅允许访问manifest中的文件时，无manifest文件的目录不可列出
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		ManifestOnly:           true,
+		Manifest:               NewManifestFromPaths("public/app.js"),
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/private/", nil))
+	assert.Equal(404, resp.Code)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/public/", nil))
+	assert.Equal(200, resp.Code)
+}
+
+func TestServeDirListingFilter(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "assets"), 0755))
+	assert.Nil(os.MkdirAll(filepath.Join(root, "internal"), 0755))
+	for _, name := range []string{
+		"app.js",
+		"app.js.br",
+		"app.js.map",
+		"archive.tar.gz",
+		"secret.env",
+		"report.pdf",
+		"report.pdf.meta.json",
+		"staticserve.json",
+		"assets/logo.png",
+	} {
+		content := name
+		if strings.HasSuffix(name, ".json") {
+			content = "{}"
+		}
+		assert.Nil(ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+	getNames := func(e *elton.Elton, url string) []string {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Accept", "application/json")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		listing := &DirListing{}
+		assert.Nil(json.Unmarshal(resp.Body.Bytes(), listing))
+		names := make([]string, 0)
+		for _, entry := range listing.Entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		DenyExtensions:         []string{".env"},
+		Precompressed:          []string{"br", "gzip"},
+		SourceMapPolicy:        SourceMapDeny,
+		EnableFileMeta:         true,
+		DirConfigFile:          "staticserve.json",
+		IPRules: []IPRule{
+			{
+				Pattern: "/internal/",
+				Allow:   []string{"10.0.0.0/8"},
+			},
+		},
+	}))
+	// 不可访问的文件不展示
+	assert.Equal([]string{"assets", "app.js", "archive.tar.gz", "report.pdf"}, getNames(e, "/"))
+
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		ManifestOnly:           true,
+		Manifest:               NewManifestFromPaths("report.pdf", "assets/logo.png"),
+	}))
+	assert.Equal([]string{"assets", "report.pdf"}, getNames(e, "/"))
+	assert.Equal([]string{"logo.png"}, getNames(e, "/assets/"))
+}
+
+func TestServeDirListingSignedURL(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "internal"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "internal", "secret.txt"), []byte("secret"), 0644))
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "app.js"), []byte("app"), 0644))
+	signed := &SignedURLConfig{
+		Secret:   []byte("secret"),
+		Patterns: []string{"/internal/"},
+	}
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		SignedURL:              signed,
+	}))
+
+	// 未签名的目录请求不可列出
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/internal/", nil))
+	assert.Equal(403, resp.Code)
+	assert.NotContains(resp.Body.String(), "secret.txt")
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", signed.Sign("/internal/", time.Now().Add(time.Minute)), nil))
+	assert.Equal(200, resp.Code)
+	// 列表中的地址未签名，因此需要签名的文件不展示
+	assert.NotContains(resp.Body.String(), "secret.txt")
+
+	// 需要签名的目录不展示
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Contains(resp.Body.String(), "app.js")
+	assert.NotContains(resp.Body.String(), "internal")
+}
+
+func TestServeDirListingIPRules(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "internal"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "internal", "secret.txt"), []byte("secret"), 0644))
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:                   root,
+		EnableDirectoryListing: true,
+		IPRules: []IPRule{
+			{
+				Pattern: "/internal/",
+				Allow:   []string{"10.0.0.0/8"},
+			},
+		},
+	}))
+
+	// 不允许的客户端不可列出目录
+	req := httptest.NewRequest("GET", "/internal/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(403, resp.Code)
+	assert.NotContains(resp.Body.String(), "secret.txt")
+
+	req = httptest.NewRequest("GET", "/internal/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Contains(resp.Body.String(), "secret.txt")
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"container/list"
+	"sync"
+)
+
+type (
+	lruEntry struct {
+		key   string
+		value interface{}
+		size  int
+	}
+	// lru the lru cache of key value, the total size of values will not exceed
+	// the max size(the size of value is 1 for the count limit)
+	lru struct {
+		mutex   sync.Mutex
+		maxSize int
+		size    int
+		ll      *list.List
+		items   map[string]*list.Element
+	}
+)
+
+// newLRU create a lru cache
+func newLRU(maxSize int) *lru {
+	return &lru{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// get get the value of key, the value is removed if it's invalid(valid returns false)
+func (l *lru) get(key string, valid func(value interface{}) bool) (interface{}, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	ele, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := ele.Value.(*lruEntry)
+	if valid != nil && !valid(entry.value) {
+		l.removeElement(ele)
+		return nil, false
+	}
+	l.ll.MoveToFront(ele)
+	return entry.value, true
+}
+
+// add add the value of key, it's ignored if the size is larger than max size
+func (l *lru) add(key string, value interface{}, size int) {
+	if size > l.maxSize {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.addLocked(key, value, size)
+}
+
+// update update the value of key with the former value(nil if not exists) atomically
+func (l *lru) update(key string, fn func(value interface{}) (interface{}, int)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var former interface{}
+	if ele, ok := l.items[key]; ok {
+		former = ele.Value.(*lruEntry).value
+	}
+	value, size := fn(former)
+	if size > l.maxSize {
+		return
+	}
+	l.addLocked(key, value, size)
+}
+
+func (l *lru) addLocked(key string, value interface{}, size int) {
+	if ele, ok := l.items[key]; ok {
+		l.removeElement(ele)
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{
+		key:   key,
+		value: value,
+		size:  size,
+	})
+	l.size += size
+	for l.size > l.maxSize {
+		l.removeElement(l.ll.Back())
+	}
+}
+
+// removeElement remove the element from cache
+func (l *lru) removeElement(ele *list.Element) {
+	entry := ele.Value.(*lruEntry)
+	l.ll.Remove(ele)
+	delete(l.items, entry.key)
+	l.size -= entry.size
+}
+
+// removeKeys remove the values whose key matches
+func (l *lru) removeKeys(match func(key string) bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for key, ele := range l.items {
+		if match(key) {
+			l.removeElement(ele)
+		}
+	}
+}
+
+// purge remove all values
+func (l *lru) purge() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.ll.Init()
+	l.items = make(map[string]*list.Element)
+	l.size = 0
+}
+
+// stats get the count and the total size of values
+func (l *lru) stats() (int, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.ll.Len(), l.size
+}
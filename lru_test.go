@@ -0,0 +1,60 @@
+package staticserve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	assert := assert.New(t)
+	l := newLRU(10)
+
+	l.add("a", 1, 4)
+	l.add("b", 2, 4)
+	// 超过大小限制的忽略
+	l.add("c", 3, 11)
+	count, size := l.stats()
+	assert.Equal(2, count)
+	assert.Equal(8, size)
+
+	value, ok := l.get("a", nil)
+	assert.True(ok)
+	assert.Equal(1, value)
+
+	// 超过大小限制，淘汰最少使用的b
+	l.add("d", 4, 4)
+	_, ok = l.get("b", nil)
+	assert.False(ok)
+
+	// 校验失败则删除
+	_, ok = l.get("a", func(value interface{}) bool {
+		return value.(int) != 1
+	})
+	assert.False(ok)
+	count, size = l.stats()
+	assert.Equal(1, count)
+	assert.Equal(4, size)
+
+	l.update("d", func(value interface{}) (interface{}, int) {
+		return value.(int) + 1, 2
+	})
+	value, _ = l.get("d", nil)
+	assert.Equal(5, value)
+	_, size = l.stats()
+	assert.Equal(2, size)
+
+	l.add("/a/1", 1, 1)
+	l.add("/b/1", 1, 1)
+	l.removeKeys(func(key string) bool {
+		return strings.HasPrefix(key, "/a/")
+	})
+	count, _ = l.stats()
+	assert.Equal(2, count)
+
+	l.purge()
+	count, size = l.stats()
+	assert.Equal(0, count)
+	assert.Equal(0, size)
+}
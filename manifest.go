@@ -0,0 +1,136 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type (
+	// ManifestEntry the info of file generated at build time
+	ManifestEntry struct {
+		ETag    string    `json:"etag,omitempty"`
+		Size    int64     `json:"size,omitempty"`
+		ModTime time.Time `json:"mtime,omitempty"`
+	}
+	// Manifest the manifest of files(path → info) generated at build time,
+	// the path is relative to the static path, e.g. js/app.js
+	Manifest map[string]*ManifestEntry
+)
+
+// NewManifest create a manifest from json, such as
+// {"js/app.js": {"etag": "abcd", "size": 1024, "mtime": "2021-01-01T00:00:00Z"}}
+func NewManifest(r io.Reader) (Manifest, error) {
+	m := make(Manifest)
+	err := json.NewDecoder(r).Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	result := make(Manifest, len(m))
+	for file, entry := range m {
+		if entry == nil {
+			continue
+		}
+		// 统一使用带引号的etag
+		if entry.ETag != "" && !strings.HasPrefix(entry.ETag, `"`) && !strings.HasPrefix(entry.ETag, "W/") {
+			entry.ETag = `"` + entry.ETag + `"`
+		}
+		result[toFSPath(file)] = entry
+	}
+	return result, nil
+}
+
+// NewManifestFromPaths create a manifest of paths without file info, it's used for ManifestOnly
+func NewManifestFromPaths(paths ...string) Manifest {
+	m := make(Manifest, len(paths))
+	for _, file := range paths {
+		m[toFSPath(file)] = &ManifestEntry{}
+	}
+	return m
+}
+
+// NewAssetMap create the map of source file to output file from the json generated by build tool,
+// such as {"app.js": "js/app.3f2a9c1d.js"}, it's used for AssetURLConfig.Assets
+func NewAssetMap(r io.Reader) (map[string]string, error) {
+	assets := make(map[string]string)
+	err := json.NewDecoder(r).Decode(&assets)
+	if err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+// NewAssetManifest create a manifest of the output files from the json generated by build tool,
+// such as {"app.js": "js/app.3f2a9c1d.js"}. The manifest only contains paths, it's used for ManifestOnly
+func NewAssetManifest(r io.Reader) (Manifest, error) {
+	assets, err := NewAssetMap(r)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(assets))
+	for _, file := range assets {
+		if file != "" {
+			paths = append(paths, file)
+		}
+	}
+	return NewManifestFromPaths(paths...), nil
+}
+
+// LoadManifest load the manifest from json file
+func LoadManifest(file string) (Manifest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewManifest(f)
+}
+
+// has check the file is in the manifest
+func (m Manifest) has(file string) bool {
+	_, ok := m[toFSPath(file)]
+	return ok
+}
+
+// hasDir check the manifest has any file of the directory
+func (m Manifest) hasDir(dir string) bool {
+	dir = toFSPath(dir)
+	if dir == "." {
+		return len(m) != 0
+	}
+	for file := range m {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup get the entry of file, it returns nil if the size of file is not
+// equal to the manifest(the manifest is outdated)
+func (m Manifest) lookup(file string, info os.FileInfo) *ManifestEntry {
+	entry, ok := m[toFSPath(file)]
+	if !ok {
+		return nil
+	}
+	if entry.Size > 0 && info != nil && info.Size() != entry.Size {
+		return nil
+	}
+	return entry
+}
@@ -0,0 +1,121 @@
+package staticserve
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestManifest(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewManifest(bytes.NewBufferString("abc"))
+	assert.NotNil(err)
+
+	m, err := NewManifest(bytes.NewBufferString(`{
+		"/index.html": {"etag": "abcd", "mtime": "2021-01-01T00:00:00Z"},
+		"js/app.js": {"etag": "W/\"1234\"", "size": 1024},
+		"css/app.css": null
+	}`))
+	assert.Nil(err)
+	assert.Equal(2, len(m))
+	assert.Equal(`"abcd"`, m["index.html"].ETag)
+	assert.Equal(`W/"1234"`, m["js/app.js"].ETag)
+
+	assert.NotNil(m.lookup("/index.html", nil))
+	assert.NotNil(m.lookup("/js/app.js", &MockFileStat{}))
+	m["js/app.js"].Size = 10
+	assert.Nil(m.lookup("/js/app.js", &MockFileStat{}))
+	assert.Nil(m.lookup("/css/app.css", nil))
+
+	file := filepath.Join(t.TempDir(), "manifest.json")
+	assert.Nil(ioutil.WriteFile(file, []byte(`{"index.html": {"etag": "abcd"}}`), 0644))
+	m, err = LoadManifest(file)
+	assert.Nil(err)
+	assert.Equal(`"abcd"`, m["index.html"].ETag)
+	_, err = LoadManifest(filepath.Join(t.TempDir(), "notfound.json"))
+	assert.NotNil(err)
+}
+
+func TestServeManifest(t *testing.T) {
+	assert := assert.New(t)
+	m, err := NewManifest(bytes.NewBufferString(`{
+		"index.html": {"etag": "abcd", "mtime": "2021-01-01T00:00:00Z"},
+		"banner.jpg": {"etag": "1234", "size": 1}
+	}`))
+	assert.Nil(err)
+	sf := &getCountStaticFile{}
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Path:             staticPath,
+		EnableStrongETag: true,
+		Manifest:         m,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal(`"abcd"`, resp.Header().Get(elton.HeaderETag))
+	assert.Equal("Fri, 01 Jan 2021 00:00:00 GMT", resp.Header().Get(elton.HeaderLastModified))
+	// 使用manifest的etag，无需读取文件内容
+	assert.Equal(0, sf.getCount)
+
+	// 文件大小与manifest不一致，运行时计算
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/banner.jpg", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal(generateETag([]byte("image data")), resp.Header().Get(elton.HeaderETag))
+	assert.Equal(1, sf.getCount)
+}
+
+func TestNewAssetMap(t *testing.T) {
+	assert := assert.New(t)
+	assets, err := NewAssetMap(bytes.NewBufferString(`{"app.js": "js/app.3f2a9c1d.js"}`))
+	assert.Nil(err)
+	assert.Equal(map[string]string{
+		"app.js": "js/app.3f2a9c1d.js",
+	}, assets)
+
+	_, err = NewAssetMap(bytes.NewBufferString(`[]`))
+	assert.NotNil(err)
+}
+
+func TestNewAssetManifest(t *testing.T) {
+	assert := assert.New(t)
+	m, err := NewAssetManifest(bytes.NewBufferString(`{
+		"app.js": "js/app.3f2a9c1d.js",
+		"app.css": "/css/app.5e6f7a8b.css",
+		"empty": ""
+	}`))
+	assert.Nil(err)
+	assert.Equal(2, len(m))
+	assert.True(m.has("/js/app.3f2a9c1d.js"))
+	assert.True(m.has("/css/app.5e6f7a8b.css"))
+	assert.False(m.has("/app.js"))
+
+	_, err = NewAssetManifest(bytes.NewBufferString(`[]`))
+	assert.NotNil(err)
+}
+
+func TestServeManifestOnly(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:         staticPath,
+		Manifest:     NewManifestFromPaths("index.html", "/js/app.js"),
+		ManifestOnly: true,
+	}))
+	for _, file := range []string{"/", "/index.html", "/js/app.js"} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+		assert.Equal(200, resp.Code, file)
+	}
+	for _, file := range []string{"/banner.jpg", "/js/app.js.bak"} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+		assert.Equal(404, resp.Code, file)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bufio"
+	"bytes"
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// MarkdownRenderer the renderer converts markdown to html, such as the goldmark subpackage
+	MarkdownRenderer interface {
+		Render([]byte) ([]byte, error)
+	}
+	// MarkdownConfig the config of markdown rendering
+	MarkdownConfig struct {
+		// markdown的渲染
+		Renderer MarkdownRenderer
+		// 包装渲染后html的模板，模板数据为*MarkdownPage
+		Template *template.Template
+		// markdown文件的扩展名，默认为 .md 与 .markdown
+		Extensions []string
+	}
+	// MarkdownPage the data of markdown template
+	MarkdownPage struct {
+		// 标题（首个一级标题，无则使用文件名）
+		Title string
+		// 文件路径（相对于Path）
+		File string
+		// 渲染后的html
+		Content template.HTML
+	}
+)
+
+var defaultMarkdownExtensions = []string{".md", ".markdown"}
+
+var defaultMarkdownTemplate = template.Must(template.New("markdown").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+{{.Content}}
+</body>
+</html>
+`))
+
+// match check the file is markdown
+func (mc *MarkdownConfig) match(file string) bool {
+	extensions := mc.Extensions
+	if len(extensions) == 0 {
+		extensions = defaultMarkdownExtensions
+	}
+	ext := filepath.Ext(file)
+	for _, item := range extensions {
+		if strings.EqualFold(item, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMarkdownTitle get the title of markdown, it's the first level-one heading
+func getMarkdownTitle(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
+
+// render render the markdown to html page
+func (mc *MarkdownConfig) render(file string, content []byte) ([]byte, error) {
+	buf, err := mc.Renderer.Render(content)
+	if err != nil {
+		return nil, err
+	}
+	title := getMarkdownTitle(content)
+	if title == "" {
+		title = filepath.Base(file)
+	}
+	tpl := mc.Template
+	if tpl == nil {
+		tpl = defaultMarkdownTemplate
+	}
+	b := &bytes.Buffer{}
+	err = tpl.Execute(b, &MarkdownPage{
+		Title: title,
+		File:  file,
+		// 渲染后的html由renderer保证安全
+		Content: template.HTML(buf),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"errors"
+	"html/template"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+type mockMarkdownRenderer struct{}
+
+func (r *mockMarkdownRenderer) Render(buf []byte) ([]byte, error) {
+	if strings.Contains(string(buf), "error") {
+		return nil, errors.New("render fail")
+	}
+	return []byte("<p>" + template.HTMLEscapeString(string(buf)) + "</p>"), nil
+}
+
+func TestMarkdownConfigMatch(t *testing.T) {
+	assert := assert.New(t)
+	mc := &MarkdownConfig{}
+	assert.True(mc.match("/docs/README.md"))
+	assert.True(mc.match("/docs/guide.MARKDOWN"))
+	assert.False(mc.match("/docs/index.html"))
+
+	mc.Extensions = []string{".mdx"}
+	assert.True(mc.match("/docs/guide.mdx"))
+	assert.False(mc.match("/docs/README.md"))
+}
+
+func TestGetMarkdownTitle(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("Guide", getMarkdownTitle([]byte("intro\n## Sub\n# Guide \n# Other")))
+	assert.Empty(getMarkdownTitle([]byte("## Sub")))
+}
+
+func TestServeMarkdown(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"README.md": &fstest.MapFile{
+			Data: []byte("hello"),
+		},
+		"error.md": &fstest.MapFile{
+			Data: []byte("error"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		EnableStrongETag: true,
+		Markdown: &MarkdownConfig{
+			Renderer: &mockMarkdownRenderer{},
+			Template: template.Must(template.New("").Parse(`<title>{{.Title}}</title>{{.File}}{{.Content}}`)),
+		},
+		// 渲染后的html仍可转换
+		Transform: func(c *elton.Context, file string, content []byte) ([]byte, error) {
+			return append(content, []byte("<footer></footer>")...), nil
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/README.md", nil))
+	assert.Equal(200, resp.Code)
+	body := "<title>README.md</title>/README.md<p>hello</p><footer></footer>"
+	assert.Equal(body, resp.Body.String())
+	assert.Equal("text/html; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal(generateETag([]byte(body)), resp.Header().Get(elton.HeaderETag))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/error.md", nil))
+	assert.Equal(500, resp.Code)
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"time"
+)
+
+const defaultNotFoundCacheSize = 10000
+
+type (
+	// notFoundCache lru cache for the files which are not found,
+	// the count of files will not exceed the max size
+	notFoundCache struct {
+		ttl time.Duration
+		lru *lru
+	}
+)
+
+// newNotFoundCache create a not found cache
+func newNotFoundCache(ttl time.Duration, maxSize int) *notFoundCache {
+	if maxSize <= 0 {
+		maxSize = defaultNotFoundCacheSize
+	}
+	return &notFoundCache{
+		ttl: ttl,
+		lru: newLRU(maxSize),
+	}
+}
+
+// Has check the file is not found recently
+func (nc *notFoundCache) Has(file string) bool {
+	// 已过期，删除缓存
+	_, ok := nc.lru.get(file, func(value interface{}) bool {
+		return !time.Now().After(value.(time.Time))
+	})
+	return ok
+}
+
+// Add add the file which is not found
+func (nc *notFoundCache) Add(file string) {
+	nc.lru.add(file, time.Now().Add(nc.ttl), 1)
+}
+
+// purge remove all not found files, the new file may be the index of directory
+// or the fallback, so all files are removed
+func (nc *notFoundCache) purge() {
+	nc.lru.purge()
+}
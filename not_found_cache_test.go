@@ -0,0 +1,76 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestNotFoundCache(t *testing.T) {
+	assert := assert.New(t)
+	nc := newNotFoundCache(50*time.Millisecond, 2)
+	assert.Equal(2, nc.lru.maxSize)
+	assert.Equal(defaultNotFoundCacheSize, newNotFoundCache(time.Second, 0).lru.maxSize)
+
+	nc.Add("a")
+	nc.Add("b")
+	assert.True(nc.Has("a"))
+	// 超过数量限制，淘汰最少使用的b
+	nc.Add("c")
+	assert.False(nc.Has("b"))
+	assert.True(nc.Has("a"))
+	assert.True(nc.Has("c"))
+	nc.Add("c")
+	assert.Equal(2, nc.lru.ll.Len())
+
+	// 过期后失效
+	time.Sleep(60 * time.Millisecond)
+	assert.False(nc.Has("a"))
+	assert.Equal(1, len(nc.lru.items))
+}
+
+func TestNotFoundCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+	cf := &countStaticFile{}
+	e := elton.New()
+	e.GET("/*file", New(cf, Config{
+		Path:             staticPath,
+		NotFoundCacheTTL: time.Minute,
+	}))
+	for i := 0; i < 3; i++ {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.html", nil))
+		assert.Equal(404, resp.Code)
+	}
+	assert.Equal(1, cf.existsCount)
+	assert.Equal(1, cf.statCount)
+
+	// fallback的文件存在则不缓存
+	cf = &countStaticFile{}
+	e = elton.New()
+	e.GET("/*file", New(cf, Config{
+		Path:             staticPath,
+		NotFoundCacheTTL: time.Minute,
+		Fallback:         "index.html",
+	}))
+	for i := 0; i < 2; i++ {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.html", nil))
+		assert.Equal(200, resp.Code)
+	}
+	assert.Equal(4, cf.existsCount)
+}
+
+func TestNotFoundCachePurge(t *testing.T) {
+	assert := assert.New(t)
+	nc := newNotFoundCache(time.Minute, 10)
+	nc.Add("/local/a.js")
+	nc.Add("/local/js")
+	nc.purge()
+	assert.False(nc.Has("/local/a.js"))
+	assert.False(nc.Has("/local/js"))
+	assert.Equal(0, nc.lru.ll.Len())
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/vicanso/elton"
+)
+
+type (
+	// FileOpener the v2 interface of static file, the stat and content of file are
+	// got from the opened fs.File, so that only one backend call is needed for each file
+	// of request(the handler of NewFromOpener opens the file once for Exists, Stat and reader).
+	// Use NewOpenerFile to convert it to StaticFile.
+	FileOpener interface {
+		Open(string) (fs.File, error)
+	}
+	// OpenerFile the static file of FileOpener
+	OpenerFile struct {
+		opener FileOpener
+	}
+	// openerRequestFile the static file of FileOpener for one request, each file is opened once
+	// and the stat and the reader are got from the same fs.File. The files which are not used
+	// as reader are closed by close.
+	openerRequestFile struct {
+		*OpenerFile
+		mutex  sync.Mutex
+		closed bool
+		files  map[string]*openerResult
+	}
+	openerResult struct {
+		// 未被使用为reader的文件，使用后为nil
+		file fs.File
+		info os.FileInfo
+	}
+	// staticFileOpener the FileOpener of StaticFile
+	staticFileOpener struct {
+		staticFile StaticFile
+	}
+	// openedFile the fs.File of StaticFile
+	openedFile struct {
+		io.Reader
+		info os.FileInfo
+	}
+)
+
+// NewOpenerFile create a static file of FileOpener
+func NewOpenerFile(opener FileOpener) *OpenerFile {
+	return &OpenerFile{
+		opener: opener,
+	}
+}
+
+// NewFromOpener create a static serve middleware use FileOpener
+func NewFromOpener(opener FileOpener, config Config) elton.Handler {
+	return New(NewOpenerFile(opener), config)
+}
+
+// Exists check the file exists
+func (f *OpenerFile) Exists(file string) bool {
+	return f.Stat(file) != nil
+}
+
+// Stat get stat of file
+func (f *OpenerFile) Stat(file string) os.FileInfo {
+	fsFile, err := f.opener.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer fsFile.Close()
+	info, err := fsFile.Stat()
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// withRequest create the static file for one request, it should be closed after the request
+func (f *OpenerFile) withRequest() *openerRequestFile {
+	return &openerRequestFile{
+		OpenerFile: f,
+		files:      make(map[string]*openerResult),
+	}
+}
+
+// open open the file once, the result is shared by Exists, Stat and NewReader
+func (rf *openerRequestFile) open(file string) *openerResult {
+	rf.mutex.Lock()
+	result, ok := rf.files[file]
+	rf.mutex.Unlock()
+	if ok {
+		return result
+	}
+	result = &openerResult{}
+	// 不在锁中打开文件，避免超时的调用阻塞close
+	fsFile, err := rf.opener.Open(file)
+	if err == nil {
+		result.file = fsFile
+		if info, err := fsFile.Stat(); err == nil {
+			result.info = info
+		}
+	}
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	if former, ok := rf.files[file]; ok || rf.closed {
+		if fsFile != nil {
+			_ = fsFile.Close()
+		}
+		if ok {
+			return former
+		}
+		return &openerResult{
+			info: result.info,
+		}
+	}
+	rf.files[file] = result
+	return result
+}
+
+// take take the opened file as reader, it returns nil if the file is not opened or already taken
+func (rf *openerRequestFile) take(file string, seekable bool) fs.File {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	result, ok := rf.files[file]
+	if !ok || result.file == nil {
+		return nil
+	}
+	if _, ok := result.file.(io.ReadSeeker); seekable && !ok {
+		return nil
+	}
+	fsFile := result.file
+	result.file = nil
+	return fsFile
+}
+
+// Exists check the file exists
+func (rf *openerRequestFile) Exists(file string) bool {
+	return rf.Stat(file) != nil
+}
+
+// Stat get stat of the opened file
+func (rf *openerRequestFile) Stat(file string) os.FileInfo {
+	info := rf.open(file).info
+	if info == nil {
+		return nil
+	}
+	return info
+}
+
+// Get get the file's content
+func (rf *openerRequestFile) Get(file string) ([]byte, error) {
+	r, err := rf.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(r)
+	return io.ReadAll(r)
+}
+
+// NewReader new a reader for file, the opened file is used if exists
+func (rf *openerRequestFile) NewReader(file string) (io.Reader, error) {
+	if fsFile := rf.take(file, false); fsFile != nil {
+		return fsFile, nil
+	}
+	return rf.OpenerFile.NewReader(file)
+}
+
+// NewReadSeeker new a read seeker for file, the opened file is used if exists,
+// it returns ErrNotSeekable if the opened file is not seekable(it's kept for NewReader)
+func (rf *openerRequestFile) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	if fsFile := rf.take(file, true); fsFile != nil {
+		return fsFile.(io.ReadSeeker), nil
+	}
+	rf.mutex.Lock()
+	result, ok := rf.files[file]
+	rf.mutex.Unlock()
+	if ok && result.file != nil {
+		return nil, ErrNotSeekable
+	}
+	return rf.OpenerFile.NewReadSeeker(file)
+}
+
+// close close the opened files which are not used as reader
+func (rf *openerRequestFile) close() {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	rf.closed = true
+	for _, result := range rf.files {
+		if result.file != nil {
+			_ = result.file.Close()
+			result.file = nil
+		}
+	}
+}
+
+// Get get the file's content
+func (f *OpenerFile) Get(file string) ([]byte, error) {
+	fsFile, err := f.opener.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fsFile.Close()
+	return io.ReadAll(fsFile)
+}
+
+// NewReader new a reader for file, the reader is fs.File and it should be closed
+func (f *OpenerFile) NewReader(file string) (io.Reader, error) {
+	return f.opener.Open(file)
+}
+
+// NewReadSeeker new a read seeker for file, it returns ErrNotSeekable
+// if the opened file doesn't implement io.Seeker
+func (f *OpenerFile) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	fsFile, err := f.opener.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	rs, ok := fsFile.(io.ReadSeeker)
+	if !ok {
+		_ = fsFile.Close()
+		return nil, ErrNotSeekable
+	}
+	return rs, nil
+}
+
+// ReadDirEntries read the entries of directory, the opened directory should implement fs.ReadDirFile
+func (f *OpenerFile) ReadDirEntries(dir string) ([]fs.DirEntry, error) {
+	fsFile, err := f.opener.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer fsFile.Close()
+	dirFile, ok := fsFile.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{
+			Op:   "readdir",
+			Path: dir,
+			Err:  errors.New("not implemented"),
+		}
+	}
+	return dirFile.ReadDir(-1)
+}
+
+// ReadDir read the file infos of directory, the opened directory should implement fs.ReadDirFile
+func (f *OpenerFile) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := f.ReadDirEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	return dirEntriesToInfos(entries), nil
+}
+
+// NewFileOpener convert the static file to FileOpener, the static file
+// which already implements FileOpener is returned directly
+func NewFileOpener(staticFile StaticFile) FileOpener {
+	if opener, ok := staticFile.(FileOpener); ok {
+		return opener
+	}
+	if f, ok := staticFile.(*OpenerFile); ok {
+		return f.opener
+	}
+	return &staticFileOpener{
+		staticFile: staticFile,
+	}
+}
+
+// Open open the file of static file, the reader is created by NewReadSeeker if supported
+func (o *staticFileOpener) Open(file string) (fs.File, error) {
+	if !o.staticFile.Exists(file) {
+		return nil, &fs.PathError{
+			Op:   "open",
+			Path: file,
+			Err:  fs.ErrNotExist,
+		}
+	}
+	info := o.staticFile.Stat(file)
+	var r io.Reader
+	var err error
+	if rsf, ok := o.staticFile.(ReadSeekerFile); ok {
+		r, err = rsf.NewReadSeeker(file)
+	}
+	if r == nil {
+		r, err = o.staticFile.NewReader(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &openedFile{
+		Reader: r,
+		info:   info,
+	}, nil
+}
+
+// Stat get stat of file, it returns error if the static file doesn't support stat
+func (f *openedFile) Stat() (fs.FileInfo, error) {
+	if f.info == nil {
+		return nil, errors.New("stat is not supported")
+	}
+	return f.info, nil
+}
+
+// Close close the reader if it's a closer
+func (f *openedFile) Close() error {
+	if closer, ok := f.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+type osOpener struct{}
+
+func (o *osOpener) Open(file string) (fs.File, error) {
+	return os.Open(file)
+}
+
+func TestOpenerFile(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "js"), 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "js", "app.js"), []byte("app"), 0644))
+	file := filepath.Join(root, "js", "app.js")
+
+	opener := &osOpener{}
+	sf := NewOpenerFile(opener)
+	assert.True(sf.Exists(file))
+	assert.False(sf.Exists(filepath.Join(root, "notfound.js")))
+	assert.Equal(int64(3), sf.Stat(file).Size())
+	assert.Nil(sf.Stat(filepath.Join(root, "notfound.js")))
+
+	buf, err := sf.Get(file)
+	assert.Nil(err)
+	assert.Equal("app", string(buf))
+
+	r, err := sf.NewReader(file)
+	assert.Nil(err)
+	buf, _ = io.ReadAll(r)
+	assert.Equal("app", string(buf))
+	closeReader(r)
+
+	rs, err := sf.NewReadSeeker(file)
+	assert.Nil(err)
+	offset, _ := rs.Seek(1, io.SeekStart)
+	assert.Equal(int64(1), offset)
+	closeReader(rs)
+
+	infos, err := sf.ReadDir(root)
+	assert.Nil(err)
+	assert.Equal(1, len(infos))
+	assert.Equal("js", infos[0].Name())
+
+	e := elton.New()
+	e.GET("/*file", NewFromOpener(opener, Config{
+		Path: root,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("app", resp.Body.String())
+}
+
+func TestNewFileOpener(t *testing.T) {
+	assert := assert.New(t)
+	opener := &osOpener{}
+	assert.Equal(opener, NewFileOpener(NewOpenerFile(opener)))
+
+	fsOpener := NewFileOpener(&MockStaticFile{})
+	f, err := fsOpener.Open(staticPath + "/index.html")
+	assert.Nil(err)
+	info, err := f.Stat()
+	assert.Nil(err)
+	assert.Equal("file", info.Name())
+	buf, _ := io.ReadAll(f)
+	assert.Equal("<html>xxx</html>", string(buf))
+	assert.Nil(f.Close())
+
+	_, err = fsOpener.Open(staticPath + "/notfound.html")
+	assert.ErrorIs(err, fs.ErrNotExist)
+}
+
+type countOpener struct {
+	osOpener
+	count int32
+}
+
+func (o *countOpener) Open(file string) (fs.File, error) {
+	atomic.AddInt32(&o.count, 1)
+	return o.osOpener.Open(file)
+}
+
+func TestServeOpenerOpenOnce(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(root, "app.js"), []byte("app"), 0644))
+
+	opener := &countOpener{}
+	e := elton.New()
+	e.GET("/*file", NewFromOpener(opener, Config{
+		Path: root,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("app", resp.Body.String())
+	// Exists、Stat与reader使用同一个打开的文件
+	assert.Equal(int32(1), atomic.LoadInt32(&opener.count))
+
+	// 未使用为reader的文件在请求结束时关闭
+	rf := NewOpenerFile(opener).withRequest()
+	assert.NotNil(rf.Stat(filepath.Join(root, "app.js")))
+	result := rf.files[filepath.Join(root, "app.js")]
+	f := result.file.(*os.File)
+	rf.close()
+	assert.Nil(result.file)
+	assert.NotNil(f.Close())
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel provides the opentelemetry tracing of static serve,
+// the span of each request records the file, status and cache hit
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName the name of tracer
+const InstrumentationName = "github.com/vicanso/elton-static-serve"
+
+// SpanName the name of static serve span
+const SpanName = "static.serve"
+
+// Tracing the tracing of static serve, a span is created for each request by the middleware,
+// and the hooks of config record the serve info to the span
+type Tracing struct {
+	backend string
+}
+
+// New create the tracing, the type of static file is recorded as the backend attribute
+func New(staticFile staticServe.StaticFile) *Tracing {
+	return &Tracing{
+		backend: fmt.Sprintf("%T", staticFile),
+	}
+}
+
+// getTracer get the tracer from the span of request context,
+// the global tracer provider is used if the span is not valid
+func getTracer(c *elton.Context) trace.Tracer {
+	span := trace.SpanFromContext(c.Context())
+	provider := otel.GetTracerProvider()
+	if span.SpanContext().IsValid() {
+		provider = span.TracerProvider()
+	}
+	return provider.Tracer(InstrumentationName)
+}
+
+type spanKey struct{}
+
+// Middleware start the span at the beginning of request and end it after the handlers,
+// the span is set to the request context, it should be used before static serve
+func (t *Tracing) Middleware() elton.Handler {
+	return func(c *elton.Context) error {
+		ctx, span := getTracer(c).Start(c.Context(), SpanName, trace.WithAttributes(
+			attribute.String("static.backend", t.backend),
+		))
+		c.WithContext(context.WithValue(ctx, spanKey{}, span))
+		defer span.End()
+		return c.Next()
+	}
+}
+
+// getSpan get the span started by middleware, nil is returned if it's not started
+func getSpan(c *elton.Context) trace.Span {
+	span, _ := c.Context().Value(spanKey{}).(trace.Span)
+	return span
+}
+
+// setAttributes set the attributes of serve info to the span
+func setAttributes(span trace.Span, info staticServe.ServeInfo) {
+	span.SetAttributes(
+		attribute.String("static.file", info.File),
+		attribute.Int64("static.size", info.Size),
+		attribute.Bool("static.cache_hit", info.CacheHit),
+		attribute.String("static.encoding", info.Encoding),
+		attribute.Int("http.status_code", info.Status),
+	)
+}
+
+// OnServe record the successful response to the span, it can be used as Config.OnServe
+func (t *Tracing) OnServe(c *elton.Context, info staticServe.ServeInfo) {
+	span := getSpan(c)
+	if span == nil {
+		return
+	}
+	setAttributes(span, info)
+}
+
+// OnError record the error response to the span, it can be used as Config.OnError
+func (t *Tracing) OnError(c *elton.Context, info staticServe.ServeInfo, err error) {
+	span := getSpan(c)
+	if span == nil {
+		return
+	}
+	setAttributes(span, info)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Apply set the hooks of config, the original hooks are still called
+func (t *Tracing) Apply(config *staticServe.Config) {
+	onServe := config.OnServe
+	config.OnServe = func(c *elton.Context, info staticServe.ServeInfo) {
+		t.OnServe(c, info)
+		if onServe != nil {
+			onServe(c, info)
+		}
+	}
+	onError := config.OnError
+	config.OnError = func(c *elton.Context, info staticServe.ServeInfo, err error) {
+		t.OnError(c, info, err)
+		if onError != nil {
+			onError(c, info, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type mockStaticFile struct{}
+type mockFileInfo struct {
+	os.FileInfo
+}
+
+func (m *mockStaticFile) Exists(file string) bool {
+	return file == "/index.html"
+}
+
+func (m *mockStaticFile) Get(file string) ([]byte, error) {
+	return []byte("<html>index</html>"), nil
+}
+
+func (m *mockStaticFile) Stat(file string) os.FileInfo {
+	if !m.Exists(file) {
+		return nil
+	}
+	return &mockFileInfo{}
+}
+
+func (m *mockStaticFile) NewReader(file string) (io.Reader, error) {
+	return bytes.NewReader([]byte("<html>index</html>")), nil
+}
+
+func (fi *mockFileInfo) Name() string {
+	return "index.html"
+}
+
+func (fi *mockFileInfo) Size() int64 {
+	return 18
+}
+
+func (fi *mockFileInfo) IsDir() bool {
+	return false
+}
+
+func (fi *mockFileInfo) ModTime() time.Time {
+	return time.Unix(1600000000, 0)
+}
+
+func getAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	result := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		result[kv.Key] = kv.Value
+	}
+	return result
+}
+
+func TestTracing(t *testing.T) {
+	assert := assert.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	sf := &mockStaticFile{}
+	spanStarted := false
+	config := staticServe.Config{
+		OnServe: func(c *elton.Context, info staticServe.ServeInfo) {
+			// 处理请求时span已开始
+			spanStarted = trace.SpanFromContext(c.Context()).SpanContext().IsValid()
+		},
+	}
+	tracing := New(sf)
+	tracing.Apply(&config)
+	e := elton.New()
+	e.GET("/*file", tracing.Middleware(), staticServe.New(sf, config))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	for _, file := range []string{
+		"/index.html",
+		"/notfound.html",
+	} {
+		req := httptest.NewRequest("GET", file, nil).WithContext(ctx)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Equal(3, len(spans))
+	assert.True(spanStarted)
+	span := spans[0]
+	assert.Equal(SpanName, span.Name())
+	assert.Equal(parent.SpanContext().SpanID(), span.Parent().SpanID())
+	attrs := getAttributes(span)
+	assert.Equal("/index.html", attrs["static.file"].AsString())
+	assert.Equal(int64(18), attrs["static.size"].AsInt64())
+	assert.False(attrs["static.cache_hit"].AsBool())
+	assert.Equal("*otel.mockStaticFile", attrs["static.backend"].AsString())
+	assert.Equal(int64(200), attrs["http.status_code"].AsInt64())
+
+	span = spans[1]
+	assert.Equal(codes.Error, span.Status().Code)
+	assert.Equal(int64(404), getAttributes(span)["http.status_code"].AsInt64())
+	assert.Equal(1, len(span.Events()))
+
+	// 未使用middleware则不记录
+	recorder = tracetest.NewSpanRecorder()
+	provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent = provider.Tracer("test").Start(context.Background(), "request")
+	e = elton.New()
+	e.GET("/*file", staticServe.New(sf, config))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/index.html", nil).WithContext(ctx))
+	parent.End()
+	assert.Equal(1, len(recorder.Ended()))
+	assert.Empty(getAttributes(recorder.Ended()[0]))
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"io"
+	"os"
+	"sort"
+)
+
+type (
+	// Overlay static file of multi layers, the file is looked up in each layer
+	// by order until found, such as local theme directory overrides the embedded defaults
+	Overlay struct {
+		layers []StaticFile
+	}
+)
+
+// NewOverlay create an overlay of static files, the former layer has higher priority
+func NewOverlay(layers ...StaticFile) *Overlay {
+	return &Overlay{
+		layers: layers,
+	}
+}
+
+// find find the first layer which the file exists
+func (o *Overlay) find(file string) StaticFile {
+	for _, layer := range o.layers {
+		if layer.Exists(file) {
+			return layer
+		}
+	}
+	return nil
+}
+
+// Exists check the file exists in any layer
+func (o *Overlay) Exists(file string) bool {
+	return o.find(file) != nil
+}
+
+// Stat get stat of file from the first layer which the file exists
+func (o *Overlay) Stat(file string) os.FileInfo {
+	layer := o.find(file)
+	if layer == nil {
+		return nil
+	}
+	return layer.Stat(file)
+}
+
+// Get get the file's content from the first layer which the file exists
+func (o *Overlay) Get(file string) ([]byte, error) {
+	layer := o.find(file)
+	if layer == nil {
+		return nil, os.ErrNotExist
+	}
+	return layer.Get(file)
+}
+
+// NewReader new a reader for file from the first layer which the file exists
+func (o *Overlay) NewReader(file string) (io.Reader, error) {
+	layer := o.find(file)
+	if layer == nil {
+		return nil, os.ErrNotExist
+	}
+	return layer.NewReader(file)
+}
+
+// NewReadSeeker new a read seeker for file from the first layer which has the file,
+// it returns ErrNotSeekable if the layer doesn't support seeking
+func (o *Overlay) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	layer := o.find(file)
+	if layer == nil {
+		return nil, os.ErrNotExist
+	}
+	rsf, ok := layer.(ReadSeekerFile)
+	if !ok {
+		return nil, ErrNotSeekable
+	}
+	return rsf.NewReadSeeker(file)
+}
+
+// ReadDir read the file infos of directory from all layers,
+// the file of former layer overrides the same name of latter layers
+func (o *Overlay) ReadDir(dir string) ([]os.FileInfo, error) {
+	var infos []os.FileInfo
+	exists := make(map[string]bool)
+	var lastErr error
+	found := false
+	for _, layer := range o.layers {
+		dirLister, ok := getDirLister(layer)
+		if !ok {
+			continue
+		}
+		items, err := dirLister.ReadDir(dir)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		found = true
+		for _, info := range items {
+			if exists[info.Name()] {
+				continue
+			}
+			exists[info.Name()] = true
+			infos = append(infos, info)
+		}
+	}
+	if !found {
+		if lastErr == nil {
+			lastErr = os.ErrNotExist
+		}
+		return nil, lastErr
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	return infos, nil
+}
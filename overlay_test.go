@@ -0,0 +1,84 @@
+package staticserve
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestOverlay(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(root, "theme.css"), []byte("custom"), 0644)
+	assert.Nil(err)
+
+	defaults := NewIOFS(fstest.MapFS{
+		"theme.css": &fstest.MapFile{
+			Data: []byte("default"),
+		},
+		"index.html": &fstest.MapFile{
+			Data: []byte("index"),
+		},
+	})
+	o := NewOverlay(&FS{
+		Root: root,
+	}, defaults, &MockStaticFile{})
+
+	assert.True(o.Exists("/theme.css"))
+	assert.True(o.Exists("/index.html"))
+	assert.False(o.Exists("/notfound.html"))
+	assert.Equal(int64(6), o.Stat("/theme.css").Size())
+	assert.Nil(o.Stat("/notfound.html"))
+
+	buf, err := o.Get("/theme.css")
+	assert.Nil(err)
+	assert.Equal("custom", string(buf))
+	buf, err = o.Get("/index.html")
+	assert.Nil(err)
+	assert.Equal("index", string(buf))
+	_, err = o.Get("/notfound.html")
+	assert.Equal(os.ErrNotExist, err)
+
+	r, err := o.NewReader("/index.html")
+	assert.Nil(err)
+	buf, _ = ioutil.ReadAll(r)
+	assert.Equal("index", string(buf))
+	_, err = o.NewReader("/notfound.html")
+	assert.Equal(os.ErrNotExist, err)
+
+	rs, err := o.NewReadSeeker("/index.html")
+	assert.Nil(err)
+	buf, _ = ioutil.ReadAll(rs)
+	assert.Equal("index", string(buf))
+	_, err = o.NewReadSeeker("/notfound.html")
+	assert.Equal(os.ErrNotExist, err)
+
+	infos, err := o.ReadDir("/")
+	assert.Nil(err)
+	assert.Equal(2, len(infos))
+	assert.Equal("index.html", infos[0].Name())
+	assert.Equal("theme.css", infos[1].Name())
+	assert.Equal(int64(6), infos[1].Size())
+
+	_, err = o.ReadDir("/notfound")
+	assert.NotNil(err)
+	_, err = NewOverlay(&MockStaticFile{}).ReadDir("/")
+	assert.Equal(os.ErrNotExist, err)
+
+	e := elton.New()
+	e.GET("/*file", New(NewOverlay(&FS{
+		Root: root,
+	}, defaults), Config{}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/theme.css", nil))
+	assert.Equal("custom", resp.Body.String())
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal("index", resp.Body.String())
+}
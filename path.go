@@ -0,0 +1,57 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// resolvePath resolve the request file to the os file path of root, the file is cleaned as
+// relative url path before joined, and it returns false if the file is out of root(such as
+// /../etc/passwd or the sibling directory /tmp-secrets of /tmp) or the file contains NUL
+func resolvePath(root, file string) (string, bool) {
+	if isPathEscaped(file) {
+		return "", false
+	}
+	file = path.Clean(strings.TrimLeft(filepath.ToSlash(file), "/"))
+	// 未指定root时使用绝对路径
+	if root == "" {
+		return filepath.Join(string(filepath.Separator), filepath.FromSlash(file)), true
+	}
+	result := filepath.Join(root, filepath.FromSlash(file))
+	rel, err := filepath.Rel(root, result)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return result, true
+}
+
+// relativePath get the slash-separated path of file relative to root, such as /js/app.js
+func relativePath(root, file string) string {
+	if root == "" {
+		return filepath.ToSlash(file)
+	}
+	// 文件在root下时直接截取，避免filepath.Rel的内存分配
+	if len(file) > len(root)+1 && strings.HasPrefix(file, root) && file[len(root)] == filepath.Separator {
+		return filepath.ToSlash(file[len(root):])
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == "." {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePath(t *testing.T) {
+	assert := assert.New(t)
+	root := filepath.FromSlash("/tmp")
+	tests := []struct {
+		file   string
+		result string
+		ok     bool
+	}{
+		{
+			file:   "/index.html",
+			result: "/tmp/index.html",
+			ok:     true,
+		},
+		{
+			file:   "js/../index.html",
+			result: "/tmp/index.html",
+			ok:     true,
+		},
+		{
+			file:   "/",
+			result: "/tmp",
+			ok:     true,
+		},
+		{
+			file: "/../tmp-secrets/token",
+		},
+		{
+			file: "../../etc/passwd",
+		},
+		{
+			file: "/js/../../etc/passwd",
+		},
+		{
+			file: "/index.html\x00.js",
+		},
+	}
+	for _, tt := range tests {
+		result, ok := resolvePath(root, tt.file)
+		assert.Equal(tt.ok, ok, tt.file)
+		assert.Equal(filepath.FromSlash(tt.result), result, tt.file)
+	}
+
+	result, ok := resolvePath("", "/index.html")
+	assert.True(ok)
+	assert.Equal(filepath.FromSlash("/index.html"), result)
+}
+
+func TestRelativePath(t *testing.T) {
+	assert := assert.New(t)
+	root := filepath.FromSlash("/tmp")
+	assert.Equal("/js/app.js", relativePath(root, filepath.FromSlash("/tmp/js/app.js")))
+	assert.Equal("/", relativePath(root, root))
+	assert.Equal("/", relativePath(root, filepath.FromSlash("/tmp/")))
+	assert.Equal("/../tmpx/a.js", relativePath(root, filepath.FromSlash("/tmpx/a.js")))
+	assert.Equal("/index.html", relativePath("", filepath.FromSlash("/index.html")))
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrPrecompressedMissing the precompressed file of the precompressed-only file is missing
+var ErrPrecompressedMissing = getStaticServeError("static precompressed file is missing", http.StatusInternalServerError)
+
+// precompressedExts the file extension of the precompressed encoding
+var precompressedExts = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// isPrecompressedFile check the file(such as app.js.br) is the precompressed file of the
+// encodings, and its original file exists
+func isPrecompressedFile(staticFile StaticFile, file string, encodings []string) bool {
+	for _, encoding := range encodings {
+		ext := precompressedExts[encoding]
+		if ext != "" && strings.HasSuffix(file, ext) && staticFile.Exists(strings.TrimSuffix(file, ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getExistsEncodings get the encodings whose precompressed file exists
+func getExistsEncodings(staticFile StaticFile, file string, encodings []string) []string {
+	existsEncodings := make([]string, 0, len(encodings))
+	for _, encoding := range encodings {
+		ext := precompressedExts[encoding]
+		if ext == "" || !staticFile.Exists(file+ext) {
+			continue
+		}
+		existsEncodings = append(existsEncodings, encoding)
+	}
+	return existsEncodings
+}
+
+// getPrecompressedEncoding get the precompressed encoding which is exists and accepted,
+// the encoding of higher quality is preferred, and then the order of encodings.
+// The second result is true if any precompressed file exists.
+func getPrecompressedEncoding(staticFile StaticFile, file, acceptEncoding string, encodings []string) (string, bool) {
+	existsEncodings := getExistsEncodings(staticFile, file, encodings)
+	if len(existsEncodings) == 0 {
+		return "", false
+	}
+	return NegotiateEncoding(acceptEncoding, existsEncodings), true
+}
+
+// getStrictPrecompressedEncoding get the precompressed encoding for the precompressed-only file,
+// the first exists encoding is used if none is accepted(as gzip_static always of nginx).
+// It returns false if no precompressed file exists.
+func getStrictPrecompressedEncoding(staticFile StaticFile, file, acceptEncoding string, encodings []string) (string, bool) {
+	existsEncodings := getExistsEncodings(staticFile, file, encodings)
+	if len(existsEncodings) == 0 {
+		return "", false
+	}
+	if encoding := NegotiateEncoding(acceptEncoding, existsEncodings); encoding != "" {
+		return encoding, true
+	}
+	return existsEncodings[0], true
+}
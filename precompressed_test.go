@@ -0,0 +1,141 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestGetPrecompressedEncoding(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"app.js":    &fstest.MapFile{},
+		"app.js.br": &fstest.MapFile{},
+		"app.js.gz": &fstest.MapFile{},
+		"a.css":     &fstest.MapFile{},
+		"a.css.gz":  &fstest.MapFile{},
+	})
+	encodings := []string{"br", "gzip", "deflate"}
+	encoding, ok := getPrecompressedEncoding(sf, "/app.js", "gzip, deflate, br", encodings)
+	assert.True(ok)
+	assert.Equal("br", encoding)
+
+	encoding, _ = getPrecompressedEncoding(sf, "/app.js", "gzip, br;q=0.5", encodings)
+	assert.Equal("gzip", encoding)
+
+	encoding, ok = getPrecompressedEncoding(sf, "/a.css", "br", encodings)
+	assert.True(ok)
+	assert.Empty(encoding)
+
+	encoding, ok = getPrecompressedEncoding(sf, "/index.html", "gzip, br", encodings)
+	assert.False(ok)
+	assert.Empty(encoding)
+}
+
+func TestServePrecompressed(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data: []byte("console.log('app')"),
+		},
+		"app.js.gz": &fstest.MapFile{
+			Data: []byte("gzip data"),
+		},
+		"app.js.br": &fstest.MapFile{
+			Data: []byte("br data"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Precompressed: []string{"br", "gzip"},
+	}))
+
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip, deflate, br")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("br data", resp.Body.String())
+	assert.Equal("br", resp.Header().Get(elton.HeaderContentEncoding))
+	assert.Equal("text/javascript; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("Accept-Encoding", resp.Header().Get(HeaderVary))
+	brETag := resp.Header().Get(elton.HeaderETag)
+	assert.NotEmpty(brETag)
+
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("gzip data", resp.Body.String())
+	assert.Equal("gzip", resp.Header().Get(elton.HeaderContentEncoding))
+	assert.NotEqual(brETag, resp.Header().Get(elton.HeaderETag))
+
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal("console.log('app')", resp.Body.String())
+	assert.Empty(resp.Header().Get(elton.HeaderContentEncoding))
+	assert.Equal("Accept-Encoding", resp.Header().Get(HeaderVary))
+}
+
+func TestGetStrictPrecompressedEncoding(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"app.js":    &fstest.MapFile{},
+		"app.js.gz": &fstest.MapFile{},
+		"app.js.br": &fstest.MapFile{},
+	})
+	encodings := []string{"br", "gzip"}
+	encoding, ok := getStrictPrecompressedEncoding(sf, "/app.js", "gzip", encodings)
+	assert.True(ok)
+	assert.Equal("gzip", encoding)
+
+	// 客户端不支持时使用首个存在的编码
+	encoding, ok = getStrictPrecompressedEncoding(sf, "/app.js", "", encodings)
+	assert.True(ok)
+	assert.Equal("br", encoding)
+
+	_, ok = getStrictPrecompressedEncoding(sf, "/index.html", "gzip", encodings)
+	assert.False(ok)
+}
+
+func TestServePrecompressedOnly(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data: []byte("console.log('app')"),
+		},
+		"app.js.gz": &fstest.MapFile{
+			Data: []byte("gzip data"),
+		},
+		"app.css": &fstest.MapFile{
+			Data: []byte("body{}"),
+		},
+		"index.html": &fstest.MapFile{
+			Data: []byte("<html></html>"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Precompressed:     []string{"br", "gzip"},
+		PrecompressedOnly: []string{"js", ".CSS"},
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("gzip data", resp.Body.String())
+	assert.Equal("gzip", resp.Header().Get(elton.HeaderContentEncoding))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.css", nil))
+	assert.Equal(500, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("<html></html>", resp.Body.String())
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// HeaderLink link
+const HeaderLink = "Link"
+
+// preloadTypes the value of "as" for the extension of preload asset
+var preloadTypes = map[string]string{
+	".css":   "style",
+	".js":    "script",
+	".mjs":   "script",
+	".woff":  "font",
+	".woff2": "font",
+	".ttf":   "font",
+	".otf":   "font",
+	".png":   "image",
+	".jpg":   "image",
+	".jpeg":  "image",
+	".gif":   "image",
+	".svg":   "image",
+	".webp":  "image",
+	".avif":  "image",
+	".json":  "fetch",
+}
+
+// getPreloadLink get the link value of the preload asset, the "as" is set by the extension
+// and the font and fetch are loaded with crossorigin(it is required by browser).
+// The asset is used as the link value if it starts with <
+func getPreloadLink(asset string) string {
+	if strings.HasPrefix(asset, "<") {
+		return asset
+	}
+	link := "<" + asset + ">; rel=preload"
+	ext := path.Ext(asset)
+	if index := strings.IndexAny(ext, "?#"); index != -1 {
+		ext = ext[:index]
+	}
+	as := preloadTypes[strings.ToLower(ext)]
+	if as == "" {
+		return link
+	}
+	link += "; as=" + as
+	if as == "font" || as == "fetch" {
+		link += "; crossorigin"
+	}
+	return link
+}
+
+// getPreloadLinks get the link header of the preload assets
+func getPreloadLinks(assets []string) string {
+	links := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		if asset == "" {
+			continue
+		}
+		links = append(links, getPreloadLink(asset))
+	}
+	return strings.Join(links, ", ")
+}
+
+// writeEarlyHints write the 103 early hints response with the link header,
+// it does nothing if the informational response is not supported
+func writeEarlyHints(w http.ResponseWriter) {
+	if !earlyHintsSupported || w == nil {
+		return
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
@@ -0,0 +1,37 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPreloadLink(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("</app.css>; rel=preload; as=style", getPreloadLink("/app.css"))
+	assert.Equal("</app.js?v=1>; rel=preload; as=script", getPreloadLink("/app.js?v=1"))
+	assert.Equal("</font.woff2>; rel=preload; as=font; crossorigin", getPreloadLink("/font.woff2"))
+	assert.Equal("</data.bin>; rel=preload", getPreloadLink("/data.bin"))
+	assert.Equal("</app.js>; rel=modulepreload", getPreloadLink("</app.js>; rel=modulepreload"))
+
+	assert.Equal("</app.css>; rel=preload; as=style, </app.js>; rel=preload; as=script", getPreloadLinks([]string{
+		"/app.css",
+		"",
+		"/app.js",
+	}))
+	assert.Empty(getPreloadLinks(nil))
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prometheus provides the prometheus metrics of static serve,
+// including the count of requests and cache lookups, the response bytes and the duration
+package prometheus
+
+import (
+	"strconv"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+// Metrics the prometheus metrics of static serve
+type Metrics struct {
+	requests *prom.CounterVec
+	bytes    prom.Counter
+	cache    *prom.CounterVec
+	duration prom.Histogram
+}
+
+// New create the metrics, the namespace is used as the prefix of metric name
+func New(namespace string) *Metrics {
+	return &Metrics{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "static",
+			Name:      "requests_total",
+			Help:      "The count of static file requests by status.",
+		}, []string{"status"}),
+		bytes: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "static",
+			Name:      "response_bytes_total",
+			Help:      "The bytes of static file responses(the unknown size of stream is not included).",
+		}),
+		cache: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "static",
+			Name:      "cache_total",
+			Help:      "The count of memory cache lookups of successful responses by result(hit or miss).",
+		}, []string{"result"}),
+		duration: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "static",
+			Name:      "serve_duration_seconds",
+			Help:      "The duration of serving static file.",
+			Buckets:   prom.DefBuckets,
+		}),
+	}
+}
+
+// Register register the metrics to the registerer
+func (m *Metrics) Register(r prom.Registerer) error {
+	for _, c := range []prom.Collector{
+		m.requests,
+		m.bytes,
+		m.cache,
+		m.duration,
+	} {
+		err := r.Register(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnServe record the metrics of successful response, it can be used as Config.OnServe
+func (m *Metrics) OnServe(c *elton.Context, info staticServe.ServeInfo) {
+	m.observe(info)
+	if info.Size > 0 {
+		m.bytes.Add(float64(info.Size))
+	}
+	// 未查询缓存的不计入
+	if !info.CacheUsed {
+		return
+	}
+	result := "miss"
+	if info.CacheHit {
+		result = "hit"
+	}
+	m.cache.WithLabelValues(result).Inc()
+}
+
+// OnError record the metrics of error response, it can be used as Config.OnError
+func (m *Metrics) OnError(c *elton.Context, info staticServe.ServeInfo, err error) {
+	m.observe(info)
+}
+
+func (m *Metrics) observe(info staticServe.ServeInfo) {
+	m.requests.WithLabelValues(strconv.Itoa(info.Status)).Inc()
+	m.duration.Observe(info.Elapsed.Seconds())
+}
+
+// Apply set the hooks of config, the original hooks are still called
+func (m *Metrics) Apply(config *staticServe.Config) {
+	onServe := config.OnServe
+	config.OnServe = func(c *elton.Context, info staticServe.ServeInfo) {
+		m.OnServe(c, info)
+		if onServe != nil {
+			onServe(c, info)
+		}
+	}
+	onError := config.OnError
+	config.OnError = func(c *elton.Context, info staticServe.ServeInfo, err error) {
+		m.OnError(c, info, err)
+		if onError != nil {
+			onError(c, info, err)
+		}
+	}
+}
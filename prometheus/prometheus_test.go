@@ -0,0 +1,123 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+type mockStaticFile struct{}
+type mockFileInfo struct {
+	os.FileInfo
+}
+
+func (m *mockStaticFile) Exists(file string) bool {
+	return file == "/index.html"
+}
+
+func (m *mockStaticFile) Get(file string) ([]byte, error) {
+	return []byte("<html>index</html>"), nil
+}
+
+func (m *mockStaticFile) Stat(file string) os.FileInfo {
+	if !m.Exists(file) {
+		return nil
+	}
+	return &mockFileInfo{}
+}
+
+func (m *mockStaticFile) NewReader(file string) (io.Reader, error) {
+	return bytes.NewReader([]byte("<html>index</html>")), nil
+}
+
+func (fi *mockFileInfo) Name() string {
+	return "index.html"
+}
+
+func (fi *mockFileInfo) Size() int64 {
+	return 18
+}
+
+func (fi *mockFileInfo) IsDir() bool {
+	return false
+}
+
+func (fi *mockFileInfo) ModTime() time.Time {
+	return time.Unix(1600000000, 0)
+}
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	m := New("test")
+	registry := prom.NewRegistry()
+	assert.Nil(m.Register(registry))
+	assert.NotNil(m.Register(registry))
+
+	served := 0
+	config := staticServe.Config{
+		ContentCacheSize: 1024,
+		OnServe: func(c *elton.Context, info staticServe.ServeInfo) {
+			served++
+		},
+	}
+	m.Apply(&config)
+	e := elton.New()
+	e.GET("/*file", staticServe.New(&mockStaticFile{}, config))
+	for _, file := range []string{
+		"/index.html",
+		"/index.html",
+		"/notfound.html",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+	}
+	assert.Equal(2, served)
+	assert.Equal(float64(2), testutil.ToFloat64(m.requests.WithLabelValues("200")))
+	assert.Equal(float64(1), testutil.ToFloat64(m.requests.WithLabelValues("404")))
+	assert.Equal(float64(36), testutil.ToFloat64(m.bytes))
+	assert.Equal(float64(1), testutil.ToFloat64(m.cache.WithLabelValues("hit")))
+	assert.Equal(float64(1), testutil.ToFloat64(m.cache.WithLabelValues("miss")))
+	assert.Equal(1, testutil.CollectAndCount(m.duration))
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP test_static_requests_total The count of static file requests by status.
+# TYPE test_static_requests_total counter
+test_static_requests_total{status="200"} 2
+test_static_requests_total{status="404"} 1
+`), "test_static_requests_total")
+	assert.Nil(err)
+
+	// 未启用缓存时不记录命中与未命中
+	m = New("nocache")
+	config = staticServe.Config{}
+	m.Apply(&config)
+	e = elton.New()
+	e.GET("/*file", staticServe.New(&mockStaticFile{}, config))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(float64(1), testutil.ToFloat64(m.requests.WithLabelValues("200")))
+	assert.Equal(0, testutil.CollectAndCount(m.cache))
+}
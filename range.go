@@ -0,0 +1,370 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vicanso/elton"
+)
+
+const (
+	// HeaderRange range
+	HeaderRange = "Range"
+	// HeaderContentRange content range
+	HeaderContentRange = "Content-Range"
+)
+
+var (
+	// ErrRangeNotSatisfiable range not satisfiable
+	ErrRangeNotSatisfiable = getStaticServeError("requested range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+
+	errInvalidRange = errors.New("invalid range")
+	errNoOverlap    = errors.New("range is out of content")
+)
+
+type (
+	// httpRange the range of content, [start, start+length)
+	httpRange struct {
+		start  int64
+		length int64
+	}
+	// rangeContent the content which supports range,
+	// it's the buffer of file or a seekable reader
+	rangeContent struct {
+		size int64
+		buf  []byte
+		rs   io.ReadSeeker
+	}
+	// readCloser reader with closer of the original reader
+	readCloser struct {
+		io.Reader
+		closer io.Closer
+	}
+	// sectionReader the reader of range, the section is got on the first read
+	// so that the ranges of multipart seek the reader in order
+	sectionReader struct {
+		rc *rangeContent
+		ra httpRange
+		r  io.Reader
+	}
+)
+
+// Read read the data of range
+func (sr *sectionReader) Read(p []byte) (int, error) {
+	if sr.r == nil {
+		r, err := sr.rc.section(sr.ra)
+		if err != nil {
+			return 0, err
+		}
+		sr.r = r
+	}
+	return sr.r.Read(p)
+}
+
+// Close close the original reader
+func (rc *readCloser) Close() error {
+	if rc.closer == nil {
+		return nil
+	}
+	return rc.closer.Close()
+}
+
+// WriteTo write the data of original reader to w, the original reader is passed to
+// the io.ReaderFrom of w(such as http.ResponseWriter), so that sendfile can be used
+// for *os.File(or the *io.LimitedReader of it)
+func (rc *readCloser) WriteTo(w io.Writer) (int64, error) {
+	if rf, ok := w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(rc.Reader)
+	}
+	return io.Copy(w, rc.Reader)
+}
+
+// contentRange get the value of content range
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// parseRange parse the range header, it returns nil if the header is empty
+func parseRange(header string, size int64) ([]httpRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, errInvalidRange
+	}
+	ranges := make([]httpRange, 0, 1)
+	noOverlap := false
+	for _, item := range strings.Split(header[len(prefix):], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		index := strings.Index(item, "-")
+		if index < 0 {
+			return nil, errInvalidRange
+		}
+		start := strings.TrimSpace(item[:index])
+		end := strings.TrimSpace(item[index+1:])
+		var r httpRange
+		if start == "" {
+			// 后缀形式：-500 表示最后500字节
+			if end == "" {
+				return nil, errInvalidRange
+			}
+			n, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n == 0 {
+				noOverlap = true
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r.start = size - n
+			r.length = size - r.start
+		} else {
+			n, err := strconv.ParseInt(start, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n >= size {
+				noOverlap = true
+				continue
+			}
+			r.start = n
+			if end == "" {
+				r.length = size - r.start
+			} else {
+				n, err := strconv.ParseInt(end, 10, 64)
+				if err != nil || r.start > n {
+					return nil, errInvalidRange
+				}
+				if n >= size {
+					n = size - 1
+				}
+				r.length = n - r.start + 1
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if noOverlap && len(ranges) == 0 {
+		return nil, errNoOverlap
+	}
+	return ranges, nil
+}
+
+// newRangeContent create a range content, it returns nil if neither buffer nor
+// seekable reader is available
+func newRangeContent(buf []byte, r io.Reader) *rangeContent {
+	if buf != nil {
+		return &rangeContent{
+			size: int64(len(buf)),
+			buf:  buf,
+		}
+	}
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		return nil
+	}
+	size, ok := getReaderSize(rs)
+	if !ok {
+		return nil
+	}
+	return &rangeContent{
+		size: size,
+		rs:   rs,
+	}
+}
+
+// getReaderSize get the size of reader, the size of *os.File is got from its stat
+// without seeking, and the seeker is used for the others
+func getReaderSize(r io.Reader) (int64, bool) {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			return info.Size(), true
+		}
+	}
+	rs, ok := r.(io.Seeker)
+	if !ok {
+		return 0, false
+	}
+	return getSeekerSize(rs)
+}
+
+// getSeekerSize get the size of seeker, it seeks to the start after getting size
+func getSeekerSize(rs io.Seeker) (int64, bool) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, false
+	}
+	_, err = rs.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+// close close the reader of content
+func (rc *rangeContent) close() {
+	closeReader(rc.rs)
+}
+
+// section get the reader of the range
+func (rc *rangeContent) section(r httpRange) (io.Reader, error) {
+	if rc.buf != nil {
+		return bytes.NewReader(rc.buf[r.start : r.start+r.length]), nil
+	}
+	_, err := rc.rs.Seek(r.start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return io.LimitReader(rc.rs, r.length), nil
+}
+
+// serve serve the range request, it returns false if the full content should be served
+func (rc *rangeContent) serve(c *elton.Context, header string) (bool, error) {
+	ranges, err := parseRange(header, rc.size)
+	if err != nil {
+		// 无效的range则忽略，超出范围则返回416
+		if err == errInvalidRange {
+			return false, nil
+		}
+		rc.close()
+		c.SetHeader(HeaderContentRange, fmt.Sprintf("bytes */%d", rc.size))
+		return true, ErrRangeNotSatisfiable
+	}
+	if len(ranges) == 0 {
+		return false, nil
+	}
+	// 多个range的总长度超过文件大小，则直接返回完整数据
+	var total int64
+	for _, ra := range ranges {
+		total += ra.length
+	}
+	if total > rc.size {
+		return false, nil
+	}
+	c.StatusCode = http.StatusPartialContent
+	if len(ranges) > 1 {
+		err = rc.serveMultipart(c, ranges)
+		if err != nil {
+			return true, getStaticServeError(err.Error(), http.StatusInternalServerError)
+		}
+		return true, nil
+	}
+	ra := ranges[0]
+	c.SetHeader(HeaderContentRange, ra.contentRange(rc.size))
+	if rc.buf != nil {
+		// 数据可能为缓存中的数据，限制容量避免写入BodyBuffer时修改缓存
+		end := ra.start + ra.length
+		c.BodyBuffer = bytes.NewBuffer(rc.buf[ra.start:end:end])
+		return true, nil
+	}
+	r, err := rc.section(ra)
+	if err != nil {
+		rc.close()
+		return true, getStaticServeError(err.Error(), http.StatusInternalServerError)
+	}
+	closer, _ := rc.rs.(io.Closer)
+	c.SetHeader(elton.HeaderContentLength, strconv.FormatInt(ra.length, 10))
+	c.Body = &readCloser{
+		Reader: r,
+		closer: closer,
+	}
+	return true, nil
+}
+
+// newMultipartReader create the reader of multipart/byteranges and get its size, the data
+// of ranges is read on the goroutine of caller
+func (rc *rangeContent) newMultipartReader(boundary, contentType string, ranges []httpRange) (io.Reader, int64, error) {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	err := mw.SetBoundary(boundary)
+	if err != nil {
+		return nil, 0, err
+	}
+	var size int64
+	readers := make([]io.Reader, 0, 2*len(ranges)+1)
+	// 复制分隔及头部数据（buffer会重用）
+	appendBoundary := func() {
+		data := append([]byte(nil), buf.Bytes()...)
+		buf.Reset()
+		size += int64(len(data))
+		readers = append(readers, bytes.NewReader(data))
+	}
+	for _, ra := range ranges {
+		header := make(textproto.MIMEHeader)
+		if contentType != "" {
+			header.Set(elton.HeaderContentType, contentType)
+		}
+		header.Set(HeaderContentRange, ra.contentRange(rc.size))
+		_, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, 0, err
+		}
+		appendBoundary()
+		size += ra.length
+		readers = append(readers, &sectionReader{
+			rc: rc,
+			ra: ra,
+		})
+	}
+	err = mw.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+	appendBoundary()
+	return io.MultiReader(readers...), size, nil
+}
+
+// serveMultipart serve the multi ranges as multipart/byteranges
+func (rc *rangeContent) serveMultipart(c *elton.Context, ranges []httpRange) error {
+	contentType := c.GetHeader(elton.HeaderContentType)
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+	r, size, err := rc.newMultipartReader(boundary, contentType, ranges)
+	if err != nil {
+		rc.close()
+		return err
+	}
+	c.SetHeader(elton.HeaderContentType, "multipart/byteranges; boundary="+boundary)
+	c.SetHeader(elton.HeaderContentLength, strconv.FormatInt(size, 10))
+	if rc.buf != nil {
+		buf := bytes.NewBuffer(make([]byte, 0, size))
+		// 数据均在内存中，不会出错
+		_, _ = buf.ReadFrom(r)
+		c.BodyBuffer = buf
+		return nil
+	}
+	closer, _ := rc.rs.(io.Closer)
+	c.Body = &readCloser{
+		Reader: r,
+		closer: closer,
+	}
+	return nil
+}
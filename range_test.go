@@ -0,0 +1,403 @@
+package staticserve
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestParseRange(t *testing.T) {
+	assert := assert.New(t)
+	tests := []struct {
+		header string
+		ranges []httpRange
+		err    error
+	}{
+		{"", nil, nil},
+		{"bytes=0-4", []httpRange{{0, 5}}, nil},
+		{"bytes=5-", []httpRange{{5, 11}}, nil},
+		{"bytes=-6", []httpRange{{10, 6}}, nil},
+		{"bytes=-100", []httpRange{{0, 16}}, nil},
+		{"bytes=10-100", []httpRange{{10, 6}}, nil},
+		{"bytes=0-1, 4-5", []httpRange{{0, 2}, {4, 2}}, nil},
+		{"bytes=16-", nil, errNoOverlap},
+		{"bytes=-0", nil, errNoOverlap},
+		{"bytes=0-1,20-30", []httpRange{{0, 2}}, nil},
+		{"items=0-1", nil, errInvalidRange},
+		{"bytes=1", nil, errInvalidRange},
+		{"bytes=-", nil, errInvalidRange},
+		{"bytes=a-1", nil, errInvalidRange},
+		{"bytes=5-1", nil, errInvalidRange},
+		{"bytes=--1", nil, errInvalidRange},
+	}
+	for _, tt := range tests {
+		ranges, err := parseRange(tt.header, 16)
+		assert.Equal(tt.err, err, tt.header)
+		assert.Equal(tt.ranges, ranges, tt.header)
+	}
+}
+
+type notSeekReader struct {
+	io.Reader
+}
+
+func TestNewRangeContent(t *testing.T) {
+	assert := assert.New(t)
+	rc := newRangeContent([]byte("abcd"), nil)
+	assert.Equal(int64(4), rc.size)
+
+	rc = newRangeContent(nil, strings.NewReader("abcdef"))
+	assert.Equal(int64(6), rc.size)
+	r, err := rc.section(httpRange{2, 3})
+	assert.Nil(err)
+	buf, _ := ioutil.ReadAll(r)
+	assert.Equal("cde", string(buf))
+
+	size, ok := getSeekerSize(strings.NewReader("abc"))
+	assert.True(ok)
+	assert.Equal(int64(3), size)
+
+	assert.Nil(newRangeContent(nil, &notSeekReader{
+		Reader: strings.NewReader("abcd"),
+	}))
+}
+
+// readerFromWriter record the reader passed to ReadFrom
+type readerFromWriter struct {
+	strings.Builder
+	src io.Reader
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.src = r
+	return io.Copy(&w.Builder, r)
+}
+
+func TestReadCloserWriteTo(t *testing.T) {
+	assert := assert.New(t)
+	r := strings.NewReader("abcd")
+	rc := &readCloser{
+		Reader: r,
+	}
+	w := &readerFromWriter{}
+	n, err := io.Copy(w, rc)
+	assert.Nil(err)
+	assert.Equal(int64(4), n)
+	assert.Equal("abcd", w.String())
+	// 原始的reader传递给ReadFrom
+	assert.Equal(r, w.src)
+
+	b := &strings.Builder{}
+	_, err = io.Copy(b, &readCloser{
+		Reader: strings.NewReader("efgh"),
+	})
+	assert.Nil(err)
+	assert.Equal("efgh", b.String())
+}
+
+func TestGetReaderSize(t *testing.T) {
+	assert := assert.New(t)
+	file := filepath.Join(t.TempDir(), "a.txt")
+	assert.Nil(os.WriteFile(file, []byte("abcdef"), 0644))
+	f, err := os.Open(file)
+	assert.Nil(err)
+	defer f.Close()
+	size, ok := getReaderSize(f)
+	assert.True(ok)
+	assert.Equal(int64(6), size)
+
+	size, ok = getReaderSize(strings.NewReader("abc"))
+	assert.True(ok)
+	assert.Equal(int64(3), size)
+
+	_, ok = getReaderSize(&notSeekReader{
+		Reader: strings.NewReader("abcd"),
+	})
+	assert.False(ok)
+}
+
+func TestServeFileSendfile(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(root, "large.bin"), []byte(strings.Repeat("a", 2048)), 0644))
+	fn := New(&FS{}, Config{
+		Path:            root,
+		EnableRange:     true,
+		MaxFileSize:     1024,
+		StreamLargeFile: true,
+	})
+
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/large.bin", nil))
+	c.Next = func() error {
+		return nil
+	}
+	assert.Nil(fn(c))
+	assert.Equal("2048", c.GetHeader(elton.HeaderContentLength))
+	f, ok := c.Body.(*os.File)
+	assert.True(ok)
+	_ = f.Close()
+
+	req := httptest.NewRequest("GET", "/large.bin", nil)
+	req.Header.Set(HeaderRange, "bytes=1024-")
+	c = elton.NewContext(httptest.NewRecorder(), req)
+	c.Next = func() error {
+		return nil
+	}
+	assert.Nil(fn(c))
+	assert.Equal("1024", c.GetHeader(elton.HeaderContentLength))
+	rc, ok := c.Body.(*readCloser)
+	assert.True(ok)
+	lr, ok := rc.Reader.(*io.LimitedReader)
+	assert.True(ok)
+	_, ok = lr.R.(*os.File)
+	assert.True(ok)
+	w := &readerFromWriter{}
+	_, err := io.Copy(w, rc)
+	assert.Nil(err)
+	assert.Equal(lr, w.src)
+	assert.Equal(1024, w.Len())
+	_ = rc.Close()
+}
+
+func TestServeMultipartClose(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(root, "large.bin"), []byte(strings.Repeat("a", 2048)), 0644))
+	fn := New(&FS{}, Config{
+		Path:            root,
+		EnableRange:     true,
+		MaxFileSize:     1024,
+		StreamLargeFile: true,
+	})
+
+	req := httptest.NewRequest("GET", "/large.bin", nil)
+	req.Header.Set(HeaderRange, "bytes=0-9, 1024-1033")
+	c := elton.NewContext(httptest.NewRecorder(), req)
+	c.Next = func() error {
+		return nil
+	}
+	assert.Nil(fn(c))
+	rc, ok := c.Body.(*readCloser)
+	assert.True(ok)
+	// 未读取数据时关闭，文件也被关闭（不依赖后台写入的goroutine）
+	f, ok := rc.closer.(*os.File)
+	assert.True(ok)
+	assert.Nil(rc.Close())
+	_, err := f.Stat()
+	assert.NotNil(err)
+}
+
+func TestServeRange(t *testing.T) {
+	staticFile := &MockStaticFile{}
+
+	t.Run("range of reader", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:        staticPath,
+			EnableRange: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "bytes=6-8")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(206, resp.Code)
+		assert.Equal("bytes", resp.Header().Get(HeaderAcceptRanges))
+		assert.Equal("bytes 6-8/16", resp.Header().Get(HeaderContentRange))
+		assert.Equal("3", resp.Header().Get(elton.HeaderContentLength))
+		assert.Equal("xxx", resp.Body.String())
+	})
+
+	t.Run("range of buffer", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableRange:      true,
+			EnableStrongETag: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "bytes=-7")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(206, resp.Code)
+		assert.Equal("bytes 9-15/16", resp.Header().Get(HeaderContentRange))
+		assert.Equal(generateETag([]byte("<html>xxx</html>")), resp.Header().Get(elton.HeaderETag))
+		assert.Equal("</html>", resp.Body.String())
+	})
+
+	t.Run("range of cache", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableRange:      true,
+			ContentCacheSize: 2048,
+		})
+		e := elton.New()
+		// 外层中间件在响应数据后追加内容，不应修改缓存的数据
+		e.Use(func(c *elton.Context) error {
+			err := c.Next()
+			if err != nil {
+				return err
+			}
+			if c.BodyBuffer != nil {
+				c.BodyBuffer.WriteString("!!")
+			}
+			return nil
+		})
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "bytes=0-5")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(206, resp.Code)
+		assert.Equal("<html>!!", resp.Body.String())
+
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>!!", resp.Body.String())
+	})
+
+	t.Run("multiple ranges", func(t *testing.T) {
+		for _, strongETag := range []bool{
+			true,
+			false,
+		} {
+			assert := assert.New(t)
+			fn := New(staticFile, Config{
+				Path:             staticPath,
+				EnableRange:      true,
+				EnableStrongETag: strongETag,
+			})
+			e := elton.New()
+			e.GET("/*file", fn)
+			req := httptest.NewRequest("GET", "/index.html", nil)
+			req.Header.Set(HeaderRange, "bytes=0-5, 6-8")
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, req)
+			assert.Equal(206, resp.Code)
+			assert.Equal(strconv.Itoa(resp.Body.Len()), resp.Header().Get(elton.HeaderContentLength))
+			mediaType, params, err := mime.ParseMediaType(resp.Header().Get(elton.HeaderContentType))
+			assert.Nil(err)
+			assert.Equal("multipart/byteranges", mediaType)
+
+			mr := multipart.NewReader(resp.Body, params["boundary"])
+			results := []string{
+				"bytes 0-5/16", "<html>",
+				"bytes 6-8/16", "xxx",
+			}
+			for i := 0; i < len(results); i += 2 {
+				part, err := mr.NextPart()
+				assert.Nil(err)
+				assert.Equal("text/html; charset=utf-8", part.Header.Get(elton.HeaderContentType))
+				assert.Equal(results[i], part.Header.Get(HeaderContentRange))
+				buf, _ := ioutil.ReadAll(part)
+				assert.Equal(results[i+1], string(buf))
+			}
+			_, err = mr.NextPart()
+			assert.Equal(io.EOF, err)
+		}
+	})
+
+	t.Run("ranges larger than size", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:        staticPath,
+			EnableRange: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "bytes=0-10, 5-15")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+	})
+
+	t.Run("not satisfiable", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:        staticPath,
+			EnableRange: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "bytes=100-")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(416, resp.Code)
+		assert.Equal("bytes */16", resp.Header().Get(HeaderContentRange))
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:        staticPath,
+			EnableRange: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "items=1-2")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+	})
+
+	t.Run("if range", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableRange:      true,
+			EnableStrongETag: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		eTag := generateETag([]byte("<html>xxx</html>"))
+		for ifRange, code := range map[string]int{
+			eTag:                            206,
+			`"abc"`:                         200,
+			"Sat, 08 Jun 2019 02:17:54 GMT": 206,
+			"Sat, 08 Jun 2019 02:17:55 GMT": 200,
+		} {
+			req := httptest.NewRequest("GET", "/index.html", nil)
+			req.Header.Set(HeaderRange, "bytes=0-1")
+			req.Header.Set(HeaderIfRange, ifRange)
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, req)
+			assert.Equal(code, resp.Code, ifRange)
+		}
+	})
+
+	t.Run("range disabled", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path: staticPath,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderRange, "bytes=0-1")
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("none", resp.Header().Get(HeaderAcceptRanges))
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"io/fs"
+	"os"
+)
+
+// fileInfoDirEntry the fs.DirEntry of file info
+type fileInfoDirEntry struct {
+	info os.FileInfo
+}
+
+// Name returns the name of file
+func (e *fileInfoDirEntry) Name() string {
+	return e.info.Name()
+}
+
+// IsDir reports whether the entry describes a directory
+func (e *fileInfoDirEntry) IsDir() bool {
+	return e.info.IsDir()
+}
+
+// Type returns the type bits of file mode
+func (e *fileInfoDirEntry) Type() fs.FileMode {
+	return e.info.Mode().Type()
+}
+
+// Info returns the file info
+func (e *fileInfoDirEntry) Info() (fs.FileInfo, error) {
+	return e.info, nil
+}
+
+// dirEntriesToInfos convert the entries to file infos, the entry which can't get info is ignored
+func dirEntriesToInfos(entries []fs.DirEntry) []os.FileInfo {
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		// 有可能在读取目录之后文件被删除，忽略
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// readDirerLister the DirLister of ReadDirer
+type readDirerLister struct {
+	readDirer ReadDirer
+}
+
+// ReadDir read the file infos of directory, the entry which can't get info is ignored
+func (l *readDirerLister) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := l.readDirer.ReadDirEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	return dirEntriesToInfos(entries), nil
+}
+
+// getDirLister get the DirLister of static file, the ReadDirer is converted to DirLister
+func getDirLister(staticFile StaticFile) (DirLister, bool) {
+	switch f := staticFile.(type) {
+	case DirLister:
+		return f, true
+	case ReadDirer:
+		return &readDirerLister{
+			readDirer: f,
+		}, true
+	}
+	return nil, false
+}
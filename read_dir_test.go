@@ -0,0 +1,138 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+// readDirerFile the static file implements ReadDirer
+type readDirerFile struct {
+	*IOFS
+	fsys fs.FS
+}
+
+func (f *readDirerFile) ReadDirEntries(dir string) ([]fs.DirEntry, error) {
+	if strings.HasSuffix(dir, "error") {
+		return nil, errors.New("read dir fail")
+	}
+	return fs.ReadDir(f.fsys, toFSPath(dir))
+}
+
+func newReadDirerFile() StaticFile {
+	fsys := fstest.MapFS{
+		"index.html": &fstest.MapFile{
+			Data: []byte("index"),
+		},
+		"js/app.js": &fstest.MapFile{
+			Data: []byte("app"),
+		},
+	}
+	return &readDirerFile{
+		IOFS: NewIOFS(fsys),
+		fsys: fsys,
+	}
+}
+
+func TestGetDirLister(t *testing.T) {
+	assert := assert.New(t)
+	_, ok := getDirLister(&MockStaticFile{})
+	assert.False(ok)
+
+	dirLister, ok := getDirLister(&FS{})
+	assert.True(ok)
+	assert.Equal(&FS{}, dirLister)
+
+	dirLister, ok = getDirLister(newReadDirerFile())
+	assert.True(ok)
+	infos, err := dirLister.ReadDir("/")
+	assert.Nil(err)
+	names := make([]string, 0)
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	assert.Equal([]string{"index.html", "js"}, names)
+
+	_, err = dirLister.ReadDir("/error")
+	assert.NotNil(err)
+}
+
+func TestReadDirerListing(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(newReadDirerFile(), Config{
+		EnableDirectoryListing: true,
+		DirectoryListingJSON:   true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/", nil))
+	assert.Equal(200, resp.Code)
+	assert.True(strings.Contains(resp.Body.String(), "app.js"))
+}
+
+func TestBuiltinReadDirer(t *testing.T) {
+	assert := assert.New(t)
+	tg, err := NewTarGz(bytes.NewReader(newTestTarGz(t)))
+	assert.Nil(err)
+	fsys := newTestMapFS()
+	staticFiles := []StaticFile{
+		NewIOFS(fsys),
+		tg,
+	}
+	for _, staticFile := range staticFiles {
+		readDirer, ok := staticFile.(ReadDirer)
+		assert.True(ok)
+		entries, err := readDirer.ReadDirEntries("/")
+		assert.Nil(err)
+		names := make([]string, 0)
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+			if entry.Name() == "assets" {
+				assert.True(entry.IsDir())
+				assert.True(entry.Type().IsDir())
+			}
+			info, err := entry.Info()
+			assert.Nil(err)
+			assert.Equal(entry.Name(), info.Name())
+		}
+		assert.Equal([]string{"assets", "index.html"}, names)
+
+		_, err = readDirer.ReadDirEntries("/notfound")
+		assert.NotNil(err)
+	}
+
+	dir := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644))
+	var staticFile StaticFile = &FS{
+		Root: dir,
+	}
+	readDirer, ok := staticFile.(ReadDirer)
+	assert.True(ok)
+	entries, err := readDirer.ReadDirEntries("/")
+	assert.Nil(err)
+	assert.Equal(1, len(entries))
+	assert.Equal("index.html", entries[0].Name())
+}
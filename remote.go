@@ -0,0 +1,357 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultRemoteTimeout    = 10 * time.Second
+	defaultRemoteRevalidate = time.Minute
+	// HEAD的结果缓存时长，同一请求中的Exists与Stat仅请求一次源站
+	remoteHeadTTL = time.Second
+	// HEAD与验证记录的最大缓存数量
+	remoteCacheSize = 10000
+)
+
+type (
+	// RemoteConfig remote config
+	RemoteConfig struct {
+		// 源站地址，如 https://cdn-origin.example.com/assets
+		Origin string
+		// 请求源站的超时，默认为10秒
+		Timeout time.Duration
+		// 本地缓存目录，文件从源站拉取后保存至此目录，后续直接读取本地文件。为空则不缓存
+		CacheDir string
+		// 本地缓存的文件在此时长后向源站重新验证（使用ETag与Last-Modified的条件请求，
+		// 304则继续使用，404则删除），默认为1分钟
+		Revalidate time.Duration
+		// 自定义http client，如果设置则Timeout无效
+		Client *http.Client
+	}
+	// Remote static file of remote http origin, it works as a pull-through cache if cache dir is set
+	Remote struct {
+		origin     string
+		client     *http.Client
+		cache      *FS
+		revalidate time.Duration
+		// 源站HEAD的结果（未使用本地缓存时）
+		heads *lru
+		// 本地缓存文件的验证记录
+		validations *lru
+		fetchGroup  singleflight.Group
+	}
+	// remoteFileInfo file info of remote file
+	remoteFileInfo struct {
+		name string
+		// 源站未返回Content-Length时为-1
+		size    int64
+		modTime time.Time
+	}
+	remoteHead struct {
+		// 文件不存在时为nil
+		info      *remoteFileInfo
+		expiredAt time.Time
+	}
+	remoteValidation struct {
+		eTag      string
+		checkedAt time.Time
+	}
+)
+
+func (info *remoteFileInfo) Name() string       { return info.name }
+func (info *remoteFileInfo) Size() int64        { return info.size }
+func (info *remoteFileInfo) Mode() os.FileMode  { return 0444 }
+func (info *remoteFileInfo) ModTime() time.Time { return info.modTime }
+func (info *remoteFileInfo) IsDir() bool        { return false }
+func (info *remoteFileInfo) Sys() interface{}   { return nil }
+
+// NewRemote create a static file of remote http origin,
+// it should be used with empty Config.Path because the file is requested as url path
+func NewRemote(config RemoteConfig) *Remote {
+	client := config.Client
+	if client == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = defaultRemoteTimeout
+		}
+		client = &http.Client{
+			Timeout: timeout,
+		}
+	}
+	revalidate := config.Revalidate
+	if revalidate <= 0 {
+		revalidate = defaultRemoteRevalidate
+	}
+	r := &Remote{
+		origin:      strings.TrimSuffix(config.Origin, "/"),
+		client:      client,
+		revalidate:  revalidate,
+		heads:       newLRU(remoteCacheSize),
+		validations: newLRU(remoteCacheSize),
+	}
+	if config.CacheDir != "" {
+		r.cache = &FS{
+			Root: config.CacheDir,
+		}
+	}
+	return r
+}
+
+// getURLPath get the clean url path of file, it returns empty for directory
+func getURLPath(file string) string {
+	file = filepath.ToSlash(file)
+	if file == "" || strings.HasSuffix(file, "/") {
+		return ""
+	}
+	p := path.Clean("/" + file)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
+
+// do do the request to origin, the response body should be closed by caller if no error,
+// the response of 304 is returned only if the conditional header is set
+func (r *Remote) do(ctx context.Context, method, urlPath string, header http.Header) (*http.Response, error) {
+	if urlPath == "" {
+		return nil, os.ErrNotExist
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.origin+urlPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK ||
+		(resp.StatusCode == http.StatusNotModified && len(header) != 0) {
+		return resp, nil
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, os.ErrNotExist
+	}
+	return nil, getStaticServeError(fmt.Sprintf("remote origin response unexpected status: %d", resp.StatusCode), http.StatusBadGateway)
+}
+
+// head get the file info from origin by HEAD, the result is cached for a short time,
+// it returns nil if the file is not found
+func (r *Remote) head(file string) *remoteFileInfo {
+	urlPath := getURLPath(file)
+	if v, ok := r.heads.get(urlPath, func(value interface{}) bool {
+		return !time.Now().After(value.(*remoteHead).expiredAt)
+	}); ok {
+		return v.(*remoteHead).info
+	}
+	resp, err := r.do(context.Background(), http.MethodHead, urlPath, nil)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		// 其它出错不缓存
+		return nil
+	}
+	var info *remoteFileInfo
+	if err == nil {
+		resp.Body.Close()
+		modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+		info = &remoteFileInfo{
+			name:    path.Base(urlPath),
+			size:    resp.ContentLength,
+			modTime: modTime,
+		}
+	}
+	r.heads.add(urlPath, &remoteHead{
+		info:      info,
+		expiredAt: time.Now().Add(remoteHeadTTL),
+	}, 1)
+	return info
+}
+
+// fetch fetch the file from origin and save it to cache dir, the conditional request
+// is used if the file is cached
+func (r *Remote) fetch(file string) error {
+	urlPath := getURLPath(file)
+	_, err, _ := r.fetchGroup.Do(urlPath, func() (interface{}, error) {
+		return nil, r.doFetch(urlPath)
+	})
+	return err
+}
+
+func (r *Remote) doFetch(urlPath string) error {
+	target := r.cache.path(filepath.FromSlash(urlPath))
+	header := make(http.Header)
+	cachedInfo, _ := os.Stat(target)
+	if cachedInfo != nil {
+		if v, ok := r.validations.get(urlPath, nil); ok && v.(*remoteValidation).eTag != "" {
+			header.Set("If-None-Match", v.(*remoteValidation).eTag)
+		}
+		header.Set("If-Modified-Since", cachedInfo.ModTime().UTC().Format(http.TimeFormat))
+	}
+	resp, err := r.do(context.Background(), http.MethodGet, urlPath, header)
+	if err != nil {
+		if cachedInfo == nil {
+			return err
+		}
+		// 源站已删除则删除本地缓存，其它出错则继续使用本地缓存
+		if errors.Is(err, os.ErrNotExist) {
+			os.Remove(target)
+			r.validations.removeKeys(func(key string) bool {
+				return key == urlPath
+			})
+			return err
+		}
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		eTag := resp.Header.Get("ETag")
+		if v, ok := r.validations.get(urlPath, nil); ok && eTag == "" {
+			eTag = v.(*remoteValidation).eTag
+		}
+		r.validated(urlPath, eTag)
+		return nil
+	}
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	// 先写入临时文件再重命名，避免并发时读取到不完整的文件
+	f, err := ioutil.TempFile(filepath.Dir(target), ".remote-")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	err = os.Rename(f.Name(), target)
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		_ = os.Chtimes(target, modTime, modTime)
+	}
+	r.validated(urlPath, resp.Header.Get("ETag"))
+	return nil
+}
+
+// validated record the validation of cached file
+func (r *Remote) validated(urlPath, eTag string) {
+	r.validations.add(urlPath, &remoteValidation{
+		eTag:      eTag,
+		checkedAt: time.Now(),
+	}, 1)
+}
+
+// sync make sure the file is in cache dir, it fetches from origin if not cached
+// or the cached file should be revalidated
+func (r *Remote) sync(file string) error {
+	if r.cache.Exists(file) {
+		v, ok := r.validations.get(getURLPath(file), nil)
+		if ok && time.Since(v.(*remoteValidation).checkedAt) < r.revalidate {
+			return nil
+		}
+	}
+	return r.fetch(file)
+}
+
+// Exists check the file exists
+func (r *Remote) Exists(file string) bool {
+	if r.cache != nil {
+		return r.sync(file) == nil
+	}
+	return r.head(file) != nil
+}
+
+// Stat get stat of file
+func (r *Remote) Stat(file string) os.FileInfo {
+	if r.cache != nil {
+		if r.sync(file) != nil {
+			return nil
+		}
+		return r.cache.Stat(file)
+	}
+	info := r.head(file)
+	if info == nil {
+		return nil
+	}
+	return info
+}
+
+// Get get the file's content
+func (r *Remote) Get(file string) ([]byte, error) {
+	reader, err := r.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(reader)
+	return ioutil.ReadAll(reader)
+}
+
+// NewReader new a reader for file
+func (r *Remote) NewReader(file string) (io.Reader, error) {
+	return r.NewReaderContext(context.Background(), file)
+}
+
+// NewReaderContext new a reader for file with context, the request to origin is canceled
+// when the context is done(the file is still pulled completely if cache dir is specified)
+func (r *Remote) NewReaderContext(ctx context.Context, file string) (io.Reader, error) {
+	if r.cache != nil {
+		err := r.sync(file)
+		if err != nil {
+			return nil, err
+		}
+		return r.cache.NewReader(file)
+	}
+	resp, err := r.do(ctx, http.MethodGet, getURLPath(file), nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// NewReadSeeker new a read seeker for file from the cache directory,
+// it returns ErrNotSeekable if the cache directory is not specified
+func (r *Remote) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	if r.cache == nil {
+		return nil, ErrNotSeekable
+	}
+	err := r.sync(file)
+	if err != nil {
+		return nil, err
+	}
+	return r.cache.NewReadSeeker(file)
+}
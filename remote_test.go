@@ -0,0 +1,211 @@
+package staticserve
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func newTestOrigin(count *int32) *httptest.Server {
+	modTime := time.Unix(1600000000, 0)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(count, 1)
+		switch req.URL.Path {
+		case "/assets/js/app.js":
+			w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+			w.Header().Set("Content-Length", "18")
+			_, _ = w.Write([]byte("console.log('app')"))
+		case "/assets/error.js":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetURLPath(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("", getURLPath(""))
+	assert.Equal("", getURLPath("/"))
+	assert.Equal("", getURLPath("/js/"))
+	assert.Equal("/js/app.js", getURLPath("js/app.js"))
+	assert.Equal("/app.js", getURLPath("/../app.js"))
+}
+
+func TestRemote(t *testing.T) {
+	assert := assert.New(t)
+	var count int32
+	origin := newTestOrigin(&count)
+	defer origin.Close()
+
+	r := NewRemote(RemoteConfig{
+		Origin: origin.URL + "/assets/",
+	})
+	assert.Equal(defaultRemoteTimeout, r.client.Timeout)
+	assert.True(r.Exists("/js/app.js"))
+	assert.False(r.Exists("/notfound.js"))
+	assert.False(r.Exists("/"))
+	info := r.Stat("/js/app.js")
+	assert.Equal("app.js", info.Name())
+	assert.Equal(int64(18), info.Size())
+	assert.Equal(int64(1600000000), info.ModTime().Unix())
+
+	buf, err := r.Get("/js/app.js")
+	assert.Nil(err)
+	assert.Equal("console.log('app')", string(buf))
+	_, err = r.Get("/notfound.js")
+	assert.Equal(os.ErrNotExist, err)
+	_, err = r.Get("/error.js")
+	assert.Equal("category=elton-static-serve, message=remote origin response unexpected status: 500", err.Error())
+
+	reader, err := r.NewReaderContext(context.Background(), "/js/app.js")
+	assert.Nil(err)
+	buf, _ = ioutil.ReadAll(reader)
+	assert.Equal("console.log('app')", string(buf))
+	// 请求取消后不再请求源站
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.NewReaderContext(ctx, "/js/app.js")
+	assert.True(errors.Is(err, context.Canceled))
+}
+
+func TestRemoteCache(t *testing.T) {
+	assert := assert.New(t)
+	var count int32
+	origin := newTestOrigin(&count)
+	defer origin.Close()
+
+	cacheDir := t.TempDir()
+	r := NewRemote(RemoteConfig{
+		Origin:   origin.URL + "/assets",
+		Timeout:  time.Second,
+		CacheDir: cacheDir,
+	})
+	assert.Equal(time.Second, r.client.Timeout)
+
+	e := elton.New()
+	e.GET("/*file", New(r, Config{}))
+	for i := 0; i < 2; i++ {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/app.js", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("console.log('app')", resp.Body.String())
+	}
+	// 仅首次从源站拉取
+	assert.Equal(int32(1), atomic.LoadInt32(&count))
+
+	buf, err := ioutil.ReadFile(filepath.Join(cacheDir, "js", "app.js"))
+	assert.Nil(err)
+	assert.Equal("console.log('app')", string(buf))
+	assert.Equal(int64(1600000000), r.Stat("/js/app.js").ModTime().Unix())
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.js", nil))
+	assert.Equal(404, resp.Code)
+	assert.Nil(r.Stat("/notfound.js"))
+	_, err = r.NewReader("/error.js")
+	assert.NotNil(err)
+
+	rs, err := r.NewReadSeeker("/js/app.js")
+	assert.Nil(err)
+	size, _ := getSeekerSize(rs)
+	assert.Equal(int64(18), size)
+	_, err = r.NewReadSeeker("/error.js")
+	assert.NotNil(err)
+	_, err = NewRemote(RemoteConfig{
+		Origin: origin.URL,
+	}).NewReadSeeker("/js/app.js")
+	assert.Equal(ErrNotSeekable, err)
+}
+
+func TestRemoteHead(t *testing.T) {
+	assert := assert.New(t)
+	var count int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&count, 1)
+		// 不返回Content-Length
+		if req.URL.Path == "/stream.js" {
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer origin.Close()
+
+	r := NewRemote(RemoteConfig{
+		Origin: origin.URL,
+	})
+	// 同一文件的Exists与Stat只请求一次源站
+	assert.True(r.Exists("/stream.js"))
+	info := r.Stat("/stream.js")
+	assert.Equal(int64(-1), info.Size())
+	assert.Equal(int32(1), atomic.LoadInt32(&count))
+	assert.False(r.Exists("/notfound.js"))
+	assert.Nil(r.Stat("/notfound.js"))
+	assert.Equal(int32(2), atomic.LoadInt32(&count))
+}
+
+func TestRemoteRevalidate(t *testing.T) {
+	assert := assert.New(t)
+	var count, notModified int32
+	var content atomic.Value
+	content.Store("v1")
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&count, 1)
+		data := content.Load().(string)
+		if data == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		eTag := `"` + data + `"`
+		if req.Header.Get("If-None-Match") == eTag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", eTag)
+		_, _ = w.Write([]byte(data))
+	}))
+	defer origin.Close()
+
+	r := NewRemote(RemoteConfig{
+		Origin:     origin.URL,
+		CacheDir:   t.TempDir(),
+		Revalidate: 20 * time.Millisecond,
+	})
+	assert.Equal(defaultRemoteRevalidate, NewRemote(RemoteConfig{}).revalidate)
+
+	buf, err := r.Get("/app.js")
+	assert.Nil(err)
+	assert.Equal("v1", string(buf))
+	_, _ = r.Get("/app.js")
+	assert.Equal(int32(1), atomic.LoadInt32(&count))
+
+	// 过期后使用etag验证，未修改则继续使用本地缓存
+	time.Sleep(30 * time.Millisecond)
+	buf, _ = r.Get("/app.js")
+	assert.Equal("v1", string(buf))
+	assert.Equal(int32(2), atomic.LoadInt32(&count))
+	assert.Equal(int32(1), atomic.LoadInt32(&notModified))
+
+	// 已修改则重新拉取
+	content.Store("v2")
+	time.Sleep(30 * time.Millisecond)
+	buf, _ = r.Get("/app.js")
+	assert.Equal("v2", string(buf))
+
+	// 源站已删除则删除本地缓存
+	content.Store("")
+	time.Sleep(30 * time.Millisecond)
+	assert.False(r.Exists("/app.js"))
+	assert.False(r.cache.Exists("/app.js"))
+}
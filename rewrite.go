@@ -0,0 +1,43 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"regexp"
+)
+
+type (
+	// RewriteRule the rewrite rule of request path
+	RewriteRule struct {
+		// 匹配请求路径的正则
+		Regexp *regexp.Regexp
+		// 替换的路径，支持 $1 等分组引用，如 /current/$1
+		Replacement string
+	}
+)
+
+// rewritePath rewrite the path by the first matched rule, then the rewrite function
+func rewritePath(file string, rules []RewriteRule, fn func(string) string) string {
+	for _, rule := range rules {
+		if rule.Regexp != nil && rule.Regexp.MatchString(file) {
+			file = rule.Regexp.ReplaceAllString(file, rule.Replacement)
+			break
+		}
+	}
+	if fn != nil {
+		file = fn(file)
+	}
+	return file
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewritePath(t *testing.T) {
+	assert := assert.New(t)
+	rules := []RewriteRule{
+		{
+			Regexp:      regexp.MustCompile(`^/v2/(.*)$`),
+			Replacement: "/current/$1",
+		},
+		{
+			Regexp:      regexp.MustCompile(`^/(en|zh)/`),
+			Replacement: "/",
+		},
+	}
+	assert.Equal("/current/app.js", rewritePath("/v2/app.js", rules, nil))
+	assert.Equal("/index.html", rewritePath("/en/index.html", rules, nil))
+	assert.Equal("/app.js", rewritePath("/app.js", rules, nil))
+	// 仅使用首个匹配的规则
+	assert.Equal("/current/en/index.html", rewritePath("/v2/en/index.html", rules, nil))
+
+	// 重写函数在规则之后
+	assert.Equal("/v2/app.js", rewritePath("/V2/app.js", rules, strings.ToLower))
+	assert.Equal("/app.js", rewritePath("/app.js", nil, nil))
+}
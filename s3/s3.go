@@ -0,0 +1,199 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 provides a static file of s3 compatible object storage,
+// the client is an interface so that any sdk(aws-sdk-go, minio-go) can be adapted
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+type (
+	// ObjectInfo the metadata of object
+	ObjectInfo struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+		ETag         string
+		ContentType  string
+	}
+	// Client the client of object storage, it should return ErrNotFound if the object is not exists
+	Client interface {
+		HeadObject(ctx context.Context, bucket, key string) (*ObjectInfo, error)
+		GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error)
+	}
+	// Storage static file of object storage
+	Storage struct {
+		client Client
+		bucket string
+		prefix string
+		ctx    context.Context
+		// WithContext创建的副本缓存请求中对象的head结果，Exists与Stat只请求一次
+		mutex sync.Mutex
+		heads map[string]*headResult
+	}
+	headResult struct {
+		info *ObjectInfo
+		err  error
+	}
+	// objectFileInfo file info of object
+	objectFileInfo struct {
+		info *ObjectInfo
+	}
+)
+
+// ErrNotFound object is not found
+var ErrNotFound = errors.New("object is not found")
+
+var (
+	_ staticServe.StaticFile  = (*Storage)(nil)
+	_ staticServe.ContextFile = (*Storage)(nil)
+)
+
+func (fi *objectFileInfo) Name() string       { return path.Base(fi.info.Key) }
+func (fi *objectFileInfo) Size() int64        { return fi.info.Size }
+func (fi *objectFileInfo) Mode() os.FileMode  { return 0444 }
+func (fi *objectFileInfo) ModTime() time.Time { return fi.info.LastModified }
+func (fi *objectFileInfo) IsDir() bool        { return false }
+func (fi *objectFileInfo) Sys() interface{}   { return fi.info }
+
+// New create a static file of object storage, the prefix is prepended to the key of object
+func New(client Client, bucket, prefix string) *Storage {
+	return &Storage{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+		ctx:    context.Background(),
+	}
+}
+
+// WithContext create a copy of storage which uses the context(such as the request context),
+// the head of object is cached by the copy, so it should be created for each request
+func (s *Storage) WithContext(ctx context.Context) staticServe.StaticFile {
+	return &Storage{
+		client: s.client,
+		bucket: s.bucket,
+		prefix: s.prefix,
+		ctx:    ctx,
+		heads:  make(map[string]*headResult),
+	}
+}
+
+// getKey get the key of object
+func (s *Storage) getKey(file string) string {
+	key := strings.TrimPrefix(path.Clean("/"+file), "/")
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+func (s *Storage) head(file string) (*ObjectInfo, error) {
+	key := s.getKey(file)
+	// 对象存储无目录，根目录与以/结尾的均视为不存在
+	if key == "" || strings.HasSuffix(key, "/") {
+		return nil, ErrNotFound
+	}
+	if s.heads == nil {
+		return s.client.HeadObject(s.ctx, s.bucket, key)
+	}
+	s.mutex.Lock()
+	result, ok := s.heads[key]
+	s.mutex.Unlock()
+	if ok {
+		return result.info, result.err
+	}
+	info, err := s.client.HeadObject(s.ctx, s.bucket, key)
+	// 仅缓存成功与不存在的结果
+	if err == nil || errors.Is(err, ErrNotFound) {
+		s.mutex.Lock()
+		s.heads[key] = &headResult{
+			info: info,
+			err:  err,
+		}
+		s.mutex.Unlock()
+	}
+	return info, err
+}
+
+// Exists check the object exists
+func (s *Storage) Exists(file string) bool {
+	_, err := s.head(file)
+	return err == nil
+}
+
+// Stat get stat of object
+func (s *Storage) Stat(file string) os.FileInfo {
+	info, err := s.head(file)
+	if err != nil || info == nil {
+		return nil
+	}
+	return &objectFileInfo{
+		info: info,
+	}
+}
+
+// Get get the object's content
+func (s *Storage) Get(file string) ([]byte, error) {
+	r, err := s.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return ioutil.ReadAll(r)
+}
+
+// NewReader new a reader for object, the reader should be closed after used
+func (s *Storage) NewReader(file string) (io.Reader, error) {
+	return s.NewReaderContext(s.ctx, file)
+}
+
+// NewReaderContext new a reader for object with the context of request,
+// the getting of object is canceled when the client disconnects
+func (s *Storage) NewReaderContext(ctx context.Context, file string) (io.Reader, error) {
+	r, _, err := s.client.GetObject(ctx, s.bucket, s.getKey(file))
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ETag get the etag of object, it can be used as Config.ETagFunc
+func (s *Storage) ETag(file string, info os.FileInfo) string {
+	if info == nil {
+		return ""
+	}
+	objectInfo, ok := info.Sys().(*ObjectInfo)
+	if !ok || objectInfo.ETag == "" {
+		return ""
+	}
+	eTag := objectInfo.ETag
+	if !strings.HasPrefix(eTag, `"`) && !strings.HasPrefix(eTag, "W/") {
+		eTag = `"` + eTag + `"`
+	}
+	return eTag
+}
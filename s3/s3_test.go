@@ -0,0 +1,141 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+type mockClient struct {
+	bucket  string
+	objects map[string]string
+	heads   int32
+	// 最近一次head的context
+	ctx atomic.Value
+}
+
+type contextKey struct{}
+
+func (m *mockClient) HeadObject(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	atomic.AddInt32(&m.heads, 1)
+	m.ctx.Store(ctx)
+	content, ok := m.objects[key]
+	if bucket != m.bucket || !ok {
+		return nil, ErrNotFound
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         int64(len(content)),
+		LastModified: time.Unix(1600000000, 0),
+		ETag:         "abcd",
+	}, nil
+}
+
+func (m *mockClient) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	info, err := m.HeadObject(ctx, bucket, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ioutil.NopCloser(bytes.NewBufferString(m.objects[key])), info, nil
+}
+
+func TestStorage(t *testing.T) {
+	assert := assert.New(t)
+	client := &mockClient{
+		bucket: "assets",
+		objects: map[string]string{
+			"web/index.html": "<html>index</html>",
+			"web/js/app.js":  "console.log('app')",
+		},
+	}
+	s := New(client, "assets", "/web/")
+
+	assert.Equal("web/js/app.js", s.getKey("/js/app.js"))
+	assert.Equal("web/index.html", s.getKey("/../index.html"))
+	assert.True(s.Exists("/index.html"))
+	assert.False(s.Exists("/"))
+	assert.False(s.Exists("/notfound.html"))
+
+	info := s.Stat("/js/app.js")
+	assert.Equal("app.js", info.Name())
+	assert.Equal(int64(18), info.Size())
+	assert.False(info.IsDir())
+	assert.Equal(int64(1600000000), info.ModTime().Unix())
+	assert.Nil(s.Stat("/notfound.html"))
+
+	assert.Equal(`"abcd"`, s.ETag("/js/app.js", info))
+	assert.Empty(s.ETag("/notfound.html", nil))
+
+	buf, err := s.Get("/js/app.js")
+	assert.Nil(err)
+	assert.Equal("console.log('app')", string(buf))
+	_, err = s.Get("/notfound.html")
+	assert.Equal(ErrNotFound, err)
+
+	var crf staticServe.ContextReaderFile = s
+	r, err := crf.NewReaderContext(context.Background(), "/js/app.js")
+	assert.Nil(err)
+	buf, _ = ioutil.ReadAll(r)
+	assert.Equal("console.log('app')", string(buf))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = crf.NewReaderContext(ctx, "/js/app.js")
+	assert.Equal(context.Canceled, err)
+
+	e := elton.New()
+	e.GET("/*file", staticServe.New(s, staticServe.Config{
+		ETagFunc: s.ETag,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("<html>index</html>", resp.Body.String())
+	assert.Equal(`"abcd"`, resp.Header().Get(elton.HeaderETag))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.html", nil))
+	assert.Equal(404, resp.Code)
+}
+
+func TestStorageWithContext(t *testing.T) {
+	assert := assert.New(t)
+	client := &mockClient{
+		bucket: "assets",
+		objects: map[string]string{
+			"index.html": "<html>index</html>",
+		},
+	}
+	s := New(client, "assets", "")
+	ctx := context.WithValue(context.Background(), contextKey{}, "request")
+	sf := s.WithContext(ctx)
+
+	// 同一副本的Exists与Stat只head一次，不存在的结果也缓存
+	assert.True(sf.Exists("/index.html"))
+	assert.NotNil(sf.Stat("/index.html"))
+	assert.False(sf.Exists("/notfound.html"))
+	assert.Nil(sf.Stat("/notfound.html"))
+	assert.Equal(int32(2), atomic.LoadInt32(&client.heads))
+	assert.Equal("request", client.ctx.Load().(context.Context).Value(contextKey{}))
+
+	// 中间件中使用请求的context
+	e := elton.New()
+	e.GET("/*file", staticServe.New(s, staticServe.Config{}))
+	req := httptest.NewRequest("GET", "/index.html", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextKey{}, "handler"))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("handler", client.ctx.Load().(context.Context).Value(contextKey{}))
+}
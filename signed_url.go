@@ -0,0 +1,125 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vicanso/hes"
+)
+
+const (
+	defaultSignatureKey = "sig"
+	defaultExpiresKey   = "exp"
+)
+
+var (
+	// ErrSignatureInvalid the signature of url is invalid
+	ErrSignatureInvalid = getStaticServeError("signature of url is invalid", http.StatusForbidden)
+	// ErrSignatureExpired the signed url is expired
+	ErrSignatureExpired = getStaticServeError("signed url is expired", http.StatusForbidden)
+)
+
+type (
+	// SignedURLConfig the config of hmac signed url
+	SignedURLConfig struct {
+		// 签名的密钥
+		Secret []byte
+		// 签名的query参数名，默认为sig
+		SignatureKey string
+		// 过期时间（unix秒）的query参数名，默认为exp
+		ExpiresKey string
+		// 需要签名访问的路径（匹配规则与CacheControlRule的Pattern一致），为空则所有文件
+		Patterns []string
+	}
+)
+
+func (signed *SignedURLConfig) signatureKey() string {
+	if signed.SignatureKey == "" {
+		return defaultSignatureKey
+	}
+	return signed.SignatureKey
+}
+
+func (signed *SignedURLConfig) expiresKey() string {
+	if signed.ExpiresKey == "" {
+		return defaultExpiresKey
+	}
+	return signed.ExpiresKey
+}
+
+// match check the file(url path) requires signature
+func (signed *SignedURLConfig) match(file string) bool {
+	if len(signed.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range signed.Patterns {
+		if matchPathPattern(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign get the signature of url path and expires
+func (signed *SignedURLConfig) sign(urlPath, expires string) string {
+	mac := hmac.New(sha256.New, signed.Secret)
+	_, _ = mac.Write([]byte(urlPath + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Sign sign the url path(the path of request url, e.g. /static/report.pdf),
+// it returns the url with the signature and expires query string
+func (signed *SignedURLConfig) Sign(urlPath string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{}
+	query.Set(signed.expiresKey(), exp)
+	query.Set(signed.signatureKey(), signed.sign(urlPath, exp))
+	return urlPath + "?" + query.Encode()
+}
+
+// Verify verify the signature and expires of url
+func (signed *SignedURLConfig) Verify(u *url.URL) error {
+	if err := signed.verify(u); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (signed *SignedURLConfig) verify(u *url.URL) *hes.Error {
+	query := u.Query()
+	exp := query.Get(signed.expiresKey())
+	sig := query.Get(signed.signatureKey())
+	if exp == "" || sig == "" {
+		return ErrSignatureInvalid
+	}
+	if !hmac.Equal([]byte(sig), []byte(signed.sign(u.Path, exp))) {
+		return ErrSignatureInvalid
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	if time.Now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+	return nil
+}
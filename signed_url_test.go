@@ -0,0 +1,65 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedURL(t *testing.T) {
+	assert := assert.New(t)
+	signed := &SignedURLConfig{
+		Secret: []byte("secret"),
+	}
+	signedURL := signed.Sign("/report.pdf", time.Now().Add(time.Minute))
+	assert.True(strings.HasPrefix(signedURL, "/report.pdf?exp="))
+	u, _ := url.Parse(signedURL)
+	assert.Nil(signed.Verify(u))
+
+	// 路径不一致
+	u, _ = url.Parse(strings.Replace(signedURL, "report", "other", 1))
+	assert.Equal(ErrSignatureInvalid, signed.Verify(u))
+
+	// 无签名
+	u, _ = url.Parse("/report.pdf")
+	assert.Equal(ErrSignatureInvalid, signed.Verify(u))
+
+	// 其它密钥
+	u, _ = url.Parse((&SignedURLConfig{
+		Secret: []byte("abc"),
+	}).Sign("/report.pdf", time.Now().Add(time.Minute)))
+	assert.Equal(ErrSignatureInvalid, signed.Verify(u))
+
+	// 已过期
+	u, _ = url.Parse(signed.Sign("/report.pdf", time.Now().Add(-time.Minute)))
+	assert.Equal(ErrSignatureExpired, signed.Verify(u))
+
+	// 自定义参数名
+	signed.SignatureKey = "token"
+	signed.ExpiresKey = "expires"
+	u, _ = url.Parse(signed.Sign("/report.pdf", time.Now().Add(time.Minute)))
+	assert.NotEmpty(u.Query().Get("token"))
+	assert.NotEmpty(u.Query().Get("expires"))
+	assert.Nil(signed.Verify(u))
+
+	signed.Patterns = []string{"/private/"}
+	assert.True(signed.match("/private/a.pdf"))
+	assert.False(signed.match("/public/a.pdf"))
+}
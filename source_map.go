@@ -0,0 +1,86 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// SourceMapPolicy the exposure policy of source map(.map file)
+type SourceMapPolicy string
+
+const (
+	// SourceMapServe serve the source map as other files(default)
+	SourceMapServe SourceMapPolicy = "serve"
+	// SourceMapDeny the source map is not found and the SourceMap header is removed
+	SourceMapDeny SourceMapPolicy = "deny"
+	// SourceMapRestrictIPs the source map and the SourceMap header are only for the allowed ips
+	SourceMapRestrictIPs SourceMapPolicy = "restrict-ips"
+)
+
+const (
+	// HeaderSourceMap sourcemap
+	HeaderSourceMap = "SourceMap"
+	// HeaderXSourceMap x-sourcemap(deprecated)
+	HeaderXSourceMap = "X-SourceMap"
+)
+
+type sourceMapRule struct {
+	policy SourceMapPolicy
+	allow  []*net.IPNet
+}
+
+// newSourceMapRule new the rule of source map, it returns nil if the source map is served
+func newSourceMapRule(policy SourceMapPolicy, allowIPs []string) (*sourceMapRule, error) {
+	switch policy {
+	case "", SourceMapServe:
+		return nil, nil
+	case SourceMapDeny, SourceMapRestrictIPs:
+	default:
+		return nil, fmt.Errorf("invalid source map policy: %s", policy)
+	}
+	allow, err := parseIPNets(allowIPs)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceMapRule{
+		policy: policy,
+		allow:  allow,
+	}, nil
+}
+
+// isSourceMap check the file is source map
+func isSourceMap(file string) bool {
+	return strings.EqualFold(filepath.Ext(file), ".map")
+}
+
+// isAllowed check the source map is allowed for the ip
+func (rule *sourceMapRule) isAllowed(ip string) bool {
+	if rule.policy != SourceMapRestrictIPs {
+		return false
+	}
+	clientIP := net.ParseIP(getHostname(ip))
+	return clientIP != nil && containsIP(rule.allow, clientIP)
+}
+
+// removeSourceMapHeaders remove the SourceMap headers of response
+func removeSourceMapHeaders(header http.Header) {
+	header.Del(HeaderSourceMap)
+	header.Del(HeaderXSourceMap)
+}
@@ -0,0 +1,83 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestNewSourceMapRule(t *testing.T) {
+	assert := assert.New(t)
+
+	rule, err := newSourceMapRule("", nil)
+	assert.Nil(err)
+	assert.Nil(rule)
+	rule, err = newSourceMapRule(SourceMapServe, nil)
+	assert.Nil(err)
+	assert.Nil(rule)
+
+	_, err = newSourceMapRule("hide", nil)
+	assert.Equal("invalid source map policy: hide", err.Error())
+	_, err = newSourceMapRule(SourceMapRestrictIPs, []string{"a.b.c.d"})
+	assert.NotNil(err)
+
+	rule, err = newSourceMapRule(SourceMapDeny, []string{"10.0.0.1"})
+	assert.Nil(err)
+	assert.False(rule.isAllowed("10.0.0.1"))
+
+	rule, err = newSourceMapRule(SourceMapRestrictIPs, []string{"10.0.0.0/8"})
+	assert.Nil(err)
+	assert.True(rule.isAllowed("10.0.0.1"))
+	assert.True(rule.isAllowed("10.0.0.1:8080"))
+	assert.False(rule.isAllowed("192.168.1.1"))
+	assert.False(rule.isAllowed(""))
+}
+
+func TestIsSourceMap(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isSourceMap("/app.js.map"))
+	assert.True(isSourceMap("/app.css.MAP"))
+	assert.False(isSourceMap("/app.js"))
+	assert.False(isSourceMap("/map"))
+}
+
+func TestServeSourceMap(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:              staticPath,
+		SourceMapPolicy:   SourceMapRestrictIPs,
+		SourceMapAllowIPs: []string{"10.0.0.0/8"},
+		Header: map[string]string{
+			HeaderSourceMap: "/app.js.map",
+		},
+	}))
+
+	req := httptest.NewRequest("GET", "/app.js.map", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(404, resp.Code)
+
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Empty(resp.Header().Get(HeaderSourceMap))
+
+	req = httptest.NewRequest("GET", "/app.js.map", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("/app.js.map", resp.Header().Get(HeaderSourceMap))
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultStatCacheSize = 10000
+
+type (
+	statCacheItem struct {
+		expiredAt time.Time
+		// 是否已判断存在
+		exists bool
+		info   os.FileInfo
+	}
+	// statCacheFile static file with the cache of Exists and Stat, the result
+	// is cached only when the file exists, and it will be expired after ttl
+	statCacheFile struct {
+		StaticFile
+		ttl time.Duration
+		// 缓存由各副本共享
+		cache *lru
+	}
+)
+
+// newStatCacheFile create a static file with stat cache, the count of
+// cache items will not exceed the max size
+func newStatCacheFile(staticFile StaticFile, ttl time.Duration, maxSize int) *statCacheFile {
+	if maxSize <= 0 {
+		maxSize = defaultStatCacheSize
+	}
+	return &statCacheFile{
+		StaticFile: staticFile,
+		ttl:        ttl,
+		cache:      newLRU(maxSize),
+	}
+}
+
+// withSource create a copy of stat cache file which uses the static file as source,
+// the cache is shared with the original
+func (sc *statCacheFile) withSource(staticFile StaticFile) *statCacheFile {
+	return &statCacheFile{
+		StaticFile: staticFile,
+		ttl:        sc.ttl,
+		cache:      sc.cache,
+	}
+}
+
+// get get the copy of valid cache item
+func (sc *statCacheFile) get(file string) (statCacheItem, bool) {
+	// 已过期，删除缓存
+	v, ok := sc.cache.get(file, func(value interface{}) bool {
+		return !time.Now().After(value.(statCacheItem).expiredAt)
+	})
+	if !ok {
+		return statCacheItem{}, false
+	}
+	return v.(statCacheItem), true
+}
+
+// set update the cache item of file
+func (sc *statCacheFile) set(file string, fn func(item *statCacheItem)) {
+	sc.cache.update(file, func(value interface{}) (interface{}, int) {
+		now := time.Now()
+		item, ok := value.(statCacheItem)
+		if !ok || now.After(item.expiredAt) {
+			item = statCacheItem{
+				expiredAt: now.Add(sc.ttl),
+			}
+		}
+		fn(&item)
+		return item, 1
+	})
+}
+
+// Exists check the file exists, it's cached if exists
+func (sc *statCacheFile) Exists(file string) bool {
+	if item, ok := sc.get(file); ok && (item.exists || item.info != nil) {
+		return true
+	}
+	exists := sc.StaticFile.Exists(file)
+	if exists {
+		sc.set(file, func(item *statCacheItem) {
+			item.exists = true
+		})
+	}
+	return exists
+}
+
+// Stat get stat of file, it's cached if not nil
+func (sc *statCacheFile) Stat(file string) os.FileInfo {
+	if item, ok := sc.get(file); ok && item.info != nil {
+		return item.info
+	}
+	info := sc.StaticFile.Stat(file)
+	if info != nil {
+		sc.set(file, func(item *statCacheItem) {
+			item.info = info
+		})
+	}
+	return info
+}
+
+// remove remove the stat cache of file, its parent directory and the files in the directory
+func (sc *statCacheFile) remove(file string) {
+	dir := filepath.Dir(file)
+	sc.cache.removeKeys(func(key string) bool {
+		return key == dir || isCacheKeyOf(key, file)
+	})
+}
@@ -0,0 +1,105 @@
+package staticserve
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+type countStaticFile struct {
+	MockStaticFile
+	existsCount int
+	statCount   int
+}
+
+func (cf *countStaticFile) Exists(file string) bool {
+	cf.existsCount++
+	return cf.MockStaticFile.Exists(file)
+}
+
+func (cf *countStaticFile) Stat(file string) os.FileInfo {
+	cf.statCount++
+	return cf.MockStaticFile.Stat(file)
+}
+
+func TestStatCacheFile(t *testing.T) {
+	assert := assert.New(t)
+	cf := &countStaticFile{}
+	sc := newStatCacheFile(cf, 50*time.Millisecond, 0)
+
+	for i := 0; i < 3; i++ {
+		assert.True(sc.Exists("/index.html"))
+		assert.NotNil(sc.Stat("/index.html"))
+		// 不存在的文件不缓存
+		assert.False(sc.Exists("/notfound.html"))
+	}
+	assert.Equal(4, cf.existsCount)
+	assert.Equal(1, cf.statCount)
+
+	// 已获取stat的，无需再判断是否存在
+	assert.NotNil(sc.Stat("/banner.jpg"))
+	assert.True(sc.Exists("/banner.jpg"))
+	assert.Equal(4, cf.existsCount)
+
+	// 过期后重新获取
+	time.Sleep(60 * time.Millisecond)
+	assert.True(sc.Exists("/index.html"))
+	assert.NotNil(sc.Stat("/index.html"))
+	assert.Equal(5, cf.existsCount)
+	assert.Equal(3, cf.statCount)
+}
+
+func TestStatCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+	cf := &countStaticFile{}
+	e := elton.New()
+	e.GET("/*file", New(cf, Config{
+		Path:         staticPath,
+		StatCacheTTL: time.Minute,
+	}))
+	for i := 0; i < 3; i++ {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+		assert.Equal(200, resp.Code)
+	}
+	// 判断是否目录时已获取stat，因此无需再调用Exists
+	assert.Equal(0, cf.existsCount)
+	assert.Equal(1, cf.statCount)
+}
+
+func TestStatCacheRemove(t *testing.T) {
+	assert := assert.New(t)
+	cf := &countStaticFile{}
+	sc := newStatCacheFile(cf, time.Minute, 0)
+	for _, file := range []string{"/local", "/local/js", "/local/js/app.js", "/local/index.html"} {
+		assert.NotNil(sc.Stat(file))
+	}
+	assert.Equal(4, cf.statCount)
+
+	// 删除文件、其所在目录与目录下文件的缓存
+	sc.remove("/local/js")
+	for _, file := range []string{"/local", "/local/js", "/local/js/app.js", "/local/index.html"} {
+		assert.NotNil(sc.Stat(file))
+	}
+	assert.Equal(7, cf.statCount)
+}
+
+func TestStatCacheSize(t *testing.T) {
+	assert := assert.New(t)
+	cf := &countStaticFile{}
+	sc := newStatCacheFile(cf, time.Minute, 2)
+	assert.Equal(defaultStatCacheSize, newStatCacheFile(cf, time.Minute, 0).cache.maxSize)
+
+	for _, file := range []string{"/local/index.html", "/local/banner.jpg", "/local/js/app.js"} {
+		assert.NotNil(sc.Stat(file))
+	}
+	count, _ := sc.cache.stats()
+	assert.Equal(2, count)
+	// 超过数量限制，淘汰最少使用的
+	assert.NotNil(sc.Stat("/local/index.html"))
+	assert.Equal(4, cf.statCount)
+}
@@ -16,22 +16,38 @@ package staticserve
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"html/template"
 	"io"
+	"io/fs"
 	"io/ioutil"
+	"math"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/vicanso/elton"
 	"github.com/vicanso/hes"
+	"golang.org/x/sync/singleflight"
 )
 
 type (
+	// CacheControlMode the mode of cache control
+	CacheControlMode string
 	// StaticFile static file
 	StaticFile interface {
 		Exists(string) bool
@@ -39,40 +55,367 @@ type (
 		Stat(string) os.FileInfo
 		NewReader(string) (io.Reader, error)
 	}
+	// DirLister list the files of directory, the static file which supports
+	// enumeration can implement it, such as directory listing
+	DirLister interface {
+		ReadDir(string) ([]os.FileInfo, error)
+	}
+	// ReadDirer the static file which lists the directory as fs.DirEntry can implement it instead of
+	// DirLister, it's used the same as DirLister(such as directory listing and overlay). The built-in
+	// static files(FS, IOFS, TarGz and OpenerFile) implement both DirLister and ReadDirer.
+	ReadDirer interface {
+		ReadDirEntries(string) ([]fs.DirEntry, error)
+	}
+	// ReadSeekerFile the static file which supports seeking can implement it, it's used instead of
+	// NewReader, so that the range requests and the accurate content length are supported.
+	// It should return ErrNotSeekable if the file is not seekable, then NewReader is used.
+	ReadSeekerFile interface {
+		NewReadSeeker(string) (io.ReadSeeker, error)
+	}
+	// ContextReaderFile the static file which reads from network(such as s3 or http origin)
+	// can implement it, the reading is canceled when the request context is done
+	ContextReaderFile interface {
+		NewReaderContext(context.Context, string) (io.Reader, error)
+	}
+	// ContextFile the static file which looks up from network(such as s3) can implement it,
+	// the copy bound to the request context is used for Exists and Stat of each request
+	// (it can also cache the lookup of the request)
+	ContextFile interface {
+		WithContext(context.Context) StaticFile
+	}
+	// contextReader the reader stops reading when the context is done
+	contextReader struct {
+		ctx context.Context
+		r   io.Reader
+	}
 	// Config static serve config
 	Config struct {
 		// 静态文件目录
 		Path string
+		// 根据请求的Host使用不同的静态文件目录（如 a.example.com 、 *.example.com ），
+		// 未匹配时使用Path（未设置则返回404），每个目录的缓存、并发限制等独立
+		Hosts map[string]string
+		// 允许的请求方法（如GET与HEAD），其它方法返回405，为空则不限制。
+		// OPTIONS请求（未限制或允许时）直接返回204及Allow（启用CORS时包括其响应头），不查找文件
+		Methods []string
+		// 需要从请求路径中删除的前缀（如挂载于/assets下时），使用路由参数（如 /assets/*file ）时无需设置，
+		// 路由参数仅为文件路径
+		StripPrefix string
+		// 请求路径的重写规则（在StripPrefix之后，使用首个匹配的规则），如 /v2/(.*) 重写为 /current/$1
+		RewriteRules []RewriteRule
+		// 请求路径的重写函数（在RewriteRules之后），重写后的路径仍会检查是否越过Path等
+		Rewrite func(string) string
 		// http cache control max age
 		MaxAge int
 		// http cache control s-maxage
 		SMaxAge int
+		// 使用private替换public，用于需要认证的静态文件（与CacheControlMode为private一致）
+		Private bool
+		// Cache-Control的模式，默认为public。no-cache与no-store时忽略MaxAge等其它缓存配置
+		CacheControlMode CacheControlMode
+		// 在Cache-Control中添加immutable，用于文件内容不会变化（如文件名带hash）的静态文件
+		Immutable bool
+		// Cache-Control的stale-while-revalidate（秒），缓存过期后在此时间内可使用旧数据并后台更新
+		StaleWhileRevalidate int
+		// Cache-Control的stale-if-error（秒），源站出错时在此时间内可使用旧数据
+		StaleIfError int
+		// 根据MaxAge（文件名包含hash时为一年）设置Expires，用于仅支持Expires的旧代理或客户端
+		EnableExpires bool
+		// CDN使用的Surrogate-Control与surrogate key（用于按分组清除缓存）
+		Surrogate *SurrogateConfig
+		// 自定义cache control，设置后直接使用此值，忽略MaxAge与SMaxAge
+		CacheControl string
+		// 所有文件均设置Content-Disposition: attachment（以附件的形式下载）
+		Attachment bool
+		// 以附件形式下载的路径（匹配规则与CacheControlRule的Pattern一致），如 *.pdf 、 /downloads/
+		AttachmentPatterns []string
+		// 请求的query string中包含此参数时以附件形式下载（如 download ，参数值不为空时作为文件名），
+		// 如果启用了DenyQueryString需要添加至AllowQueryKeys
+		AttachmentQueryKey string
+		// 按路径配置的Cache-Control（按顺序匹配首个规则），优先于其它的缓存配置
+		CacheControlRules []CacheControlRule
+		// 文件名包含hash（如 app.3f2a9c1d.js ）时使用一年的immutable缓存，忽略MaxAge等缓存配置
+		ImmutableFingerprint bool
+		// 判断文件名是否包含hash的正则，默认为 \.[0-9a-f]{8,}\.
+		FingerprintPattern *regexp.Regexp
+		// 设置安全相关的响应头（X-Content-Type-Options、X-Frame-Options与Referrer-Policy）
+		EnableSecurityHeaders bool
+		// 自定义安全相关响应头的值，为空则使用默认值，"-"表示不设置
+		SecurityHeaders SecurityHeaders
+		// html设置跨源隔离的响应头（Cross-Origin-Opener-Policy: same-origin与
+		// Cross-Origin-Embedder-Policy: require-corp），用于需要SharedArrayBuffer的多线程wasm应用，
+		// .wasm默认为application/wasm
+		CrossOriginIsolation bool
 		// http response header
 		Header map[string]string
+		// 按路径配置的响应头（按顺序匹配所有规则，后面规则的值覆盖前面的），在Header之后设置
+		HeaderRules []HeaderRule
+		// 在设置所有响应头（Cache-Control、ETag等）之后调用，用于根据文件动态设置响应头，
+		// path为相对于Path的文件路径，info可能为nil（StaticFile不支持Stat）
+		SetHeaders func(c *elton.Context, path string, info os.FileInfo)
+		// 访问文件前的鉴权，参数为相对于Path的文件路径（已处理index、fallback等），如 /private/report.pdf ，
+		// 目录列表则为目录的路径（如 /private ），
+		// 返回出错时则直接返回该出错（如401、403）
+		Authorize func(c *elton.Context, file string) error
+		// 按路径限制访问的ip，匹配的规则均需要允许才可访问，否则返回403
+		IPRules []IPRule
+		// 获取客户端ip的函数，默认为连接的ip（c.RemoteAddr），在代理之后时可使用c.RealIP等
+		ClientIP func(c *elton.Context) string
+		// 签名访问（校验query中的签名与过期时间），签名与过期时间的参数可不添加至AllowQueryKeys
+		SignedURL *SignedURLConfig
+		// 根据Referer防盗链
+		Hotlink *HotlinkConfig
+		// 页面（url路径，如 /index.html ）需要预加载的资源，如 /app.css ，以Link响应头返回，
+		// 资源以<开头时则作为完整的Link值
+		Preload map[string][]string
+		// 页面有预加载资源时先返回103 Early Hints（需要Go1.19及以上的http server）
+		EarlyHints bool
+		// 跨域配置，预检请求（OPTIONS）需要路由同时支持OPTIONS方法
+		CORS *CORSConfig
+		// 响应头Vary的值，与其它功能（如压缩等）设置的Vary合并去重
+		Vary []string
 		// 禁止query string（因为有时静态文件为CDN回源，避免生成各种重复的缓存）
 		DenyQueryString bool
+		// 禁止query string时允许的参数（如用于版本号的v），仅包含这些参数的请求不会被拒绝
+		AllowQueryKeys []string
+		// 删除不允许（AllowQueryKeys之外）的query参数而非返回400（如第三方脚本添加的cache-buster参数），
+		// 请求的url也会修改，后续的中间件不会获取到删除的参数
+		StripQueryString bool
+		// 删除了query参数的请求的Cache-Control（如 public, max-age=60 ），为空则与正常请求一致
+		StripQueryCacheControl string
 		// 是否禁止文件路径以.开头（因为这些文件有可能包括重要信息）
 		DenyDot bool
+		// 允许访问的以.开头的路径（路径前缀或glob匹配），如 /.well-known/acme-challenge/ ，
+		// 在DenyDot启用时仍可访问
+		DotAllow []string
+		// 允许访问的以.开头的路径段（如 .well-known ），在DenyDot启用时仍可访问（其它以.开头的路径段仍禁止），
+		// 为nil时默认为 .well-known （用于Let's Encrypt等的验证），设置为空则不允许
+		DotSegmentAllow []string
+		// 自定义的请求路径校验（在DenyDot之后），如限制路径深度等，可使用NewDotValidator、
+		// NewExtensionValidator、TraversalValidator与NewMaxDepthValidator，非*hes.Error的出错返回400
+		PathValidators []func(string) error
+		// 允许访问的文件扩展名（如 .js 、 .css ），为空则不限制，设置时无扩展名的文件也禁止访问
+		AllowExtensions []string
+		// 禁止访问的文件扩展名（如 .go 、 .env 、 .bak ），优先于AllowExtensions
+		DenyExtensions []string
+		// 符号链接的处理方式（仅对FS有效），默认为follow，
+		// deny则禁止路径中包括符号链接，restrict-root则仅允许指向Path目录内的符号链接
+		SymlinkPolicy SymlinkPolicy
+		// source map（.map文件）的访问策略，默认为serve，deny则返回404且删除响应头SourceMap与X-SourceMap，
+		// restrict-ips则仅允许SourceMapAllowIPs访问（其它ip同deny）
+		SourceMapPolicy SourceMapPolicy
+		// 策略为restrict-ips时允许访问source map的ip或CIDR
+		SourceMapAllowIPs []string
 		// 是否使用strong etag
 		EnableStrongETag bool
 		// 禁止生成ETag
 		DisableETag bool
 		// 禁止生成 last-modifed
 		DisableLastModified bool
+		// 文件的修改时间为零值时（如embed.FS）使用的修改时间（如构建时间），用于生成weak etag与last-modified
+		ModifiedTime time.Time
+		// 自定义生成ETag的函数（如静态文件不支持Stat，可通过此函数返回构建的hash等标识），
+		// 设置后则不再使用weak etag与strong etag的生成方式，返回空字符串则不设置ETag
+		ETagFunc func(file string, info os.FileInfo) string
+		// strong etag的哈希函数（如SHA256ETagHasher、CRC32CETagHasher），默认为sha1，
+		// 生成的etag为"长度-哈希值"
+		ETagHasher func([]byte) string
+		// 构建时生成的文件信息（etag、大小与修改时间），设置后优先使用其etag与修改时间，无需运行时计算
+		Manifest Manifest
+		// 仅返回Manifest中的文件（已处理index、fallback等），其它文件均返回404，避免误部署的文件被访问
+		ManifestOnly bool
+		// strong etag、压缩与转换需要将文件读取至内存，文件大小（根据Stat获取）超过此限制则返回出错，0表示不限制
+		MaxFileSize int64
+		// 文件大小超过限制时返回的出错，默认为ErrFileTooLarge
+		FileTooLargeError error
+		// 文件大小超过MaxFileSize时以流的形式返回（使用weak etag且不压缩），需要转换的数据仍返回出错
+		StreamLargeFile bool
+		// 图片的其它格式（可选 avif、webp，按优先级），如请求 photo.jpg 时，客户端Accept支持且 photo.jpg.avif
+		// 存在，则响应此文件（Content-Type为image/avif）
+		ImageVariants []string
+		// 支持的语言（如 en 、 zh-CN ），如请求 /docs/index.html 时，根据请求头Accept-Language
+		// 响应存在的 /docs/index.en.html 等文件，并设置Content-Language与Vary: Accept-Language
+		Languages []string
+		// 默认的语言，无匹配的语言时使用（对应的文件不存在则使用原文件），原文件的Content-Language也为此语言
+		DefaultLanguage string
+		// 预压缩文件的编码（可选 br、gzip、zstd，对应的文件为 .br、.gz、.zst ），如 foo.js.br 存在
+		// 且客户端支持时，响应预压缩文件并设置Content-Encoding（优先客户端q值更高的，相同则按配置顺序）
+		Precompressed []string
+		// 仅响应预压缩文件的扩展名（如 .js 、 .css ），预压缩文件不存在时返回500，客户端不支持时也使用
+		// 首个存在的预压缩文件（同nginx的gzip_static always），保证未压缩的文件不会被响应，需要转换的数据除外
+		PrecompressedOnly []string
+		// 运行时压缩（如 NewGzipCompressor(0) ），按客户端支持的编码选择q值更高的（相同则按配置顺序），
+		// 压缩后的etag添加编码后缀，与未压缩的区分
+		Compressors []Compressor
+		// 运行时压缩的最小长度（字节），默认为1024
+		CompressMinLength int
+		// 运行时压缩数据的缓存总大小（字节，LRU淘汰，文件修改时间或大小变化时失效），
+		// 每个文件每种编码仅压缩一次，0表示不缓存
+		CompressCacheSize int
+		// 运行时压缩的content type（支持 text/* 的形式），默认为文本、js、json与svg等
+		CompressContentTypes []string
+		// 自定义扩展名对应的content type（如 .wasm: application/wasm），优先于默认的mime判断
+		MIMETypes map[string]string
+		// 未知扩展名时使用的content type，如 application/octet-stream
+		DefaultContentType string
+		// 文本类型（text/*、application/javascript与application/json）未指定charset时添加的charset，如 utf-8
+		Charset string
+		// 响应数据的转换函数（如在index.html中注入运行时配置，可使用NewPlaceholderTransform），
+		// 转换后的数据用于生成etag（未配置ETagFunc时），保证条件请求的正确性
+		Transform func(c *elton.Context, file string, content []byte) ([]byte, error)
+		// 将markdown文件渲染为html返回（在Transform之前），用于文档服务等
+		Markdown *MarkdownConfig
+		// 需要转换的content type，默认为text/html
+		TransformContentTypes []string
+		// 目录的index文件，优先于Index列表查找，如果两者均未配置则默认为index.html
+		IndexFile string
+		// 目录的index文件列表，按顺序查找首个存在的文件
+		Index []string
+		// 将拒绝访问的出错（越过目录、以.开头、query string）以404的形式返回，
+		// 原始出错可通过hes.Error的Err获取（用于日志等）
+		HideRejectionReason bool
+		// 内存中缓存文件内容的总大小（字节，LRU淘汰），0表示不缓存
+		ContentCacheSize int
+		// 单个文件可缓存的最大大小（字节），默认为ContentCacheSize
+		ContentCacheFileSize int
+		// Exists与Stat结果的缓存时长（仅缓存存在的文件），用于减少每次请求的文件系统调用，
+		// 0表示不缓存（开发环境文件频繁修改时不建议设置）
+		StatCacheTTL time.Duration
+		// Exists与Stat结果的最大缓存数量（LRU淘汰），默认为10000
+		StatCacheSize int
+		// 不存在的文件的缓存时长，在此时间内再次请求直接返回404（不再查询文件系统），0表示不缓存
+		NotFoundCacheTTL time.Duration
+		// 不存在的文件的最大缓存数量（LRU淘汰），默认为10000
+		NotFoundCacheSize int
+		// strong etag的缓存数量（LRU淘汰，文件修改时间或大小变化时失效），
+		// 缓存命中时无需读取文件内容计算etag，0表示不缓存
+		ETagCacheSize int
+		// 自定义的内容缓存（可用于多个中间件共享或获取命中统计），优先于ContentCacheSize
+		ContentCache *ContentCache
+		// 启动时在后台预热文件（读取文件计算strong etag、压缩数据等），需要启用对应的缓存
+		// （ContentCacheSize、ETagCacheSize或CompressCacheSize）
+		WarmUp *WarmUpConfig
+		// 响应是否来自内容（或压缩数据）缓存的响应头（如 X-Cache ），值为HIT或MISS，为空则不设置
+		CacheStatusHeader string
+		// 响应来自内容缓存时，设置响应头Age为添加至缓存的时长（秒）
+		EnableCacheAge bool
+		// 目录中的配置文件名（如 .staticserve.json ），启动时加载所有目录的配置（需要StaticFile支持读取目录），
+		// 覆盖该目录及子目录的响应头、Cache-Control、index文件与目录列表的配置，配置文件不可访问。
+		// 配置了Watcher时，配置文件变化则重新加载
+		DirConfigFile string
+		// 启用文件的元数据文件（如 report.pdf.meta.json ），可设置该文件的响应头、Content-Type、
+		// Content-Disposition与Cache-Control，元数据文件不可访问。按需加载，修改时间变化则重新加载
+		EnableFileMeta bool
+		// 监听静态文件目录的文件变化（如fsnotify子包），文件变化时删除其内容、etag、stat与404的缓存
+		Watcher Watcher
+		// 自定义出错的处理（如返回自定义的html页面），返回nil表示已处理，OnError的回调仍为原始出错
+		ErrorHandler func(c *elton.Context, err error) error
+		// 成功处理（包括304、404 NotFoundFile等）后的回调，可用于访问日志等
+		OnServe func(c *elton.Context, info ServeInfo)
+		// 处理出错（包括拒绝访问、404等）时的回调，可用于告警等
+		OnError func(c *elton.Context, info ServeInfo, err error)
+		// 读取文件（Exists、Stat、Get与NewReader）的超时，超时返回504，为0则不限制。
+		// 超时的调用仍在后台执行，完成后丢弃其结果
+		Timeout time.Duration
+		// 同时读取（返回）文件的最大数量，超出时返回503，为0则不限制
+		MaxConcurrency int
+		// 超出最大并发数时的等待时长，为0则直接返回503
+		ConcurrencyWait time.Duration
+		// 超出最大并发数时响应头Retry-After的值，默认为1秒
+		RetryAfter time.Duration
+		// 每个响应的限速（字节/秒），为0则不限速，限速时数据以流的形式返回
+		RateLimit int64
+		// 限速时可连续发送的字节数，默认与RateLimit一致
+		RateLimitBurst int64
+		// 忽略客户端的no-cache（默认客户端请求头为no-cache时，不判断请求的If-None-Match与If-Modified-Since，
+		// 保证返回完整的响应数据，与浏览器强制刷新的处理一致）
+		IgnoreClientNoCache bool
+		// 创建中间件时检查静态文件目录是否可读取的目录，否则panic（避免因部署出错导致所有请求404）
+		StrictRoot bool
+		// 是否支持range请求（仅支持seek的数据），返回206
+		EnableRange bool
+		// 禁止条件请求的处理（默认If-None-Match或If-Modified-Since匹配时返回304，If-Match或If-Unmodified-Since不满足时返回412，
+		// 如果使用elton-fresh等中间件处理可禁用）
+		DisableConditional bool
+		// 目录无index文件时生成目录列表（需要StaticFile实现DirLister）
+		EnableDirectoryListing bool
+		// 目录列表以json的形式返回（默认根据请求头Accept判断）
+		DirectoryListingJSON bool
+		// 自定义目录列表的模板，模板数据为*DirListing
+		DirectoryListingTemplate *template.Template
+		// 目录请求不以/结尾时，301重定向至以/结尾的地址
+		RedirectDirSlash bool
+		// 请求index文件时（如 /docs/index.html ），301重定向至目录地址（如 /docs/ ）
+		RedirectIndex bool
+		// 文件不存在时忽略大小写查找（需要StaticFile实现DirLister，目录列表缓存1分钟），
+		// 用于从Windows等迁移的网站
+		CaseInsensitive bool
+		// 无扩展名的请求，文件不存在时尝试对应的.html文件（目录则使用index文件）
+		CleanURLs bool
+		// 文件不存在时依次尝试添加的扩展名（如 .html 、 .htm ），在CleanURLs之后且在Fallback之前
+		TryExtensions []string
+		// 文件不存在时返回的文件（如单页应用的 /index.html ），状态码为200
+		Fallback string
+		// 文件不存在时返回的404页面（如 /404.html ），响应状态码为404且不缓存
+		NotFoundFile string
 		// 如果404，是否调用next执行后续的中间件（默认为不执行，返回404错误）
 		NotFoundNext bool
-		Skipper      elton.Skipper
+		// 文件不存在时的处理函数（优先于NotFoundFile与NotFoundNext），
+		// 可通过GetNotFoundFile获取请求的文件路径
+		NotFoundHandler elton.Handler
+		Skipper         elton.Skipper
 	}
 	// FS file system
 	FS struct {
+		// 根目录，如果设置则文件路径基于此目录（如用于Overlay中的不同目录）
+		Root string
+	}
+	// fileInfoWithModTime file info with the specified mod time
+	fileInfoWithModTime struct {
+		os.FileInfo
+		modTime time.Time
 	}
 )
 
+const (
+	// CacheControlPublic public cache
+	CacheControlPublic CacheControlMode = "public"
+	// CacheControlPrivate private cache, only cached by the browser
+	CacheControlPrivate CacheControlMode = "private"
+	// CacheControlNoCache the cache should be revalidated before used
+	CacheControlNoCache CacheControlMode = "no-cache"
+	// CacheControlNoStore the response should not be cached
+	CacheControlNoStore CacheControlMode = "no-store"
+)
+
 const (
 	// ErrCategory static serve error category
 	ErrCategory = "elton-static-serve"
+	// NotFoundFileKey the context key of the requested file which is not found
+	NotFoundFileKey = "staticserve-not-found-file"
+
+	defaultIndexFile = "index.html"
+	// OPTIONS请求默认的Allow
+	defaultAllowMethods = "GET, HEAD, OPTIONS"
+	// 默认允许访问的以.开头的路径段
+	defaultDotSegment = ".well-known"
+	// 文件名包含hash时的缓存
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	immutableMaxAge       = 31536000 * time.Second
 )
 
+// defaultMIMETypes the mime types which may not be included in the mime table of old go version or system
+var defaultMIMETypes = map[string]string{
+	".avif":        "image/avif",
+	".mjs":         "text/javascript; charset=utf-8",
+	".wasm":        "application/wasm",
+	".webmanifest": "application/manifest+json",
+	".webp":        "image/webp",
+}
+
+// defaultFingerprintPattern the hash in file name generated by webpack, such as app.3f2a9c1d.js
+var defaultFingerprintPattern = regexp.MustCompile(`\.[0-9a-f]{8,}\.`)
+
 var (
 	// ErrNotAllowQueryString not all query string
 	ErrNotAllowQueryString = getStaticServeError("static serve not allow query string", http.StatusBadRequest)
@@ -82,11 +425,33 @@ var (
 	ErrOutOfPath = getStaticServeError("out of path", http.StatusBadRequest)
 	// ErrNotAllowAccessDot file include dot
 	ErrNotAllowAccessDot = getStaticServeError("static server not allow with dot", http.StatusBadRequest)
+	// ErrExtensionNotAllowed the extension of file is not allowed
+	ErrExtensionNotAllowed = getStaticServeError("static file extension is not allowed", http.StatusForbidden)
+	// ErrFileTooLarge file is too large to load into memory
+	ErrFileTooLarge = getStaticServeError("static file is too large", http.StatusRequestEntityTooLarge)
+	// ErrMethodNotAllowed method not allowed
+	ErrMethodNotAllowed = getStaticServeError("method not allowed", http.StatusMethodNotAllowed)
+	// ErrPreconditionFailed the If-Match or If-Unmodified-Since of request doesn't hold
+	ErrPreconditionFailed = getStaticServeError("precondition failed", http.StatusPreconditionFailed)
+	// ErrHotlink the referer is not allowed
+	ErrHotlink = getStaticServeError("static file hotlink is not allowed", http.StatusForbidden)
+	// ErrNotSeekable the file is not seekable
+	ErrNotSeekable = errors.New("static file is not seekable")
+	// ErrRootInvalid root path is not a readable directory
+	ErrRootInvalid = getStaticServeError("static root path is not a readable directory", http.StatusInternalServerError)
 )
 
+// path get the path of file, it's joined with the root if the root is specified
+func (fs *FS) path(file string) string {
+	if fs.Root == "" {
+		return file
+	}
+	return filepath.Join(fs.Root, file)
+}
+
 // Exists check the file exists
 func (fs *FS) Exists(file string) bool {
-	if _, err := os.Stat(file); os.IsNotExist(err) {
+	if _, err := os.Stat(fs.path(file)); os.IsNotExist(err) {
 		return false
 	}
 	return true
@@ -94,19 +459,49 @@ func (fs *FS) Exists(file string) bool {
 
 // Stat get stat of file
 func (fs *FS) Stat(file string) os.FileInfo {
-	info, _ := os.Stat(file)
+	info, _ := os.Stat(fs.path(file))
 	return info
 }
 
 // Get get the file's content
 func (fs *FS) Get(file string) (buf []byte, err error) {
-	buf, err = ioutil.ReadFile(file)
+	buf, err = ioutil.ReadFile(fs.path(file))
 	return
 }
 
 // NewReader new a reader for file
 func (fs *FS) NewReader(file string) (io.Reader, error) {
-	return os.Open(file)
+	return os.Open(fs.path(file))
+}
+
+// NewReadSeeker new a read seeker for file, *os.File is returned so that
+// sendfile can be used when it's copied to response
+func (fs *FS) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	return os.Open(fs.path(file))
+}
+
+// ReadDirEntries read the entries of directory
+func (fs *FS) ReadDirEntries(dir string) ([]fs.DirEntry, error) {
+	return os.ReadDir(fs.path(dir))
+}
+
+// ReadDir read the file infos of directory
+func (fs *FS) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDirEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	return dirEntriesToInfos(entries), nil
+}
+
+// ModTime get the specified mod time
+func (info *fileInfoWithModTime) ModTime() time.Time {
+	return info.modTime
+}
+
+// isZeroTime check the time is zero or unix epoch
+func isZeroTime(t time.Time) bool {
+	return t.IsZero() || t.Unix() == 0
 }
 
 // getStaticServeError 获取static serve的出错
@@ -118,19 +513,322 @@ func getStaticServeError(message string, statusCode int) *hes.Error {
 	}
 }
 
+// stripPathPrefix strip the prefix of path, the prefix should match the whole path segment
+func stripPathPrefix(file, prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(file, prefix) {
+		return file
+	}
+	rest := file[len(prefix):]
+	if rest != "" && rest[0] != '/' {
+		return file
+	}
+	return rest
+}
+
+// newReader new a reader of file, the read seeker is used if the static file supports,
+// otherwise the reader with context
+func newReader(ctx context.Context, staticFile StaticFile, file string) (io.Reader, error) {
+	if rsf, ok := staticFile.(ReadSeekerFile); ok {
+		rs, err := rsf.NewReadSeeker(file)
+		if err != ErrNotSeekable {
+			return rs, err
+		}
+	}
+	return newReaderContext(ctx, staticFile, file)
+}
+
+// newReaderContext new a reader of file with context, the reader which is not
+// seekable stops reading when the context is done
+func newReaderContext(ctx context.Context, staticFile StaticFile, file string) (io.Reader, error) {
+	if crf, ok := staticFile.(ContextReaderFile); ok {
+		return crf.NewReaderContext(ctx, file)
+	}
+	r, err := staticFile.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	// 支持seek的数据（如本地文件）不处理，避免range等功能不可用
+	if _, ok := r.(io.Seeker); ok || ctx.Done() == nil {
+		return r, nil
+	}
+	closer, _ := r.(io.Closer)
+	return &readCloser{
+		Reader: &contextReader{
+			ctx: ctx,
+			r:   r,
+		},
+		closer: closer,
+	}, nil
+}
+
+// Read read data from the reader, it returns the error of context if the context is done
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// rejectError get the error of rejection, it will be converted to not found error if hide is true
+func rejectError(err *hes.Error, hide bool) error {
+	if !hide {
+		return err
+	}
+	return &hes.Error{
+		StatusCode: ErrNotFound.StatusCode,
+		Message:    ErrNotFound.Message,
+		Category:   ErrCategory,
+		Err:        err,
+	}
+}
+
+// isQueryAllowed check all keys of query are allowed
+func isQueryAllowed(rawQuery string, keys map[string]bool) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return false
+	}
+	for key := range query {
+		if !keys[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// stripQuery remove the keys which are not allowed from query, the invalid query is removed
+func stripQuery(rawQuery string, keys map[string]bool) (string, bool) {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", true
+	}
+	stripped := false
+	for key := range query {
+		if !keys[key] {
+			query.Del(key)
+			stripped = true
+		}
+	}
+	if !stripped {
+		return rawQuery, false
+	}
+	return query.Encode(), true
+}
+
+// isDotAllowed check the file is in the dot allow list
+func isDotAllowed(file string, allows []string) bool {
+	// 先clean，避免通过 .. 等方式绕过检测
+	file = path.Clean("/" + file)
+	for _, item := range allows {
+		if strings.ContainsAny(item, "*?[") {
+			matched, _ := path.Match(item, file)
+			if matched {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(file, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// getIndexes get the index files of directory, the IndexFile is preferred
+// and it's index.html if none is configured
+func getIndexes(config *Config) []string {
+	indexes := make([]string, 0, len(config.Index)+1)
+	if config.IndexFile != "" {
+		indexes = append(indexes, config.IndexFile)
+	}
+	indexes = append(indexes, config.Index...)
+	if len(indexes) == 0 {
+		indexes = append(indexes, defaultIndexFile)
+	}
+	return indexes
+}
+
+// isIndexFile check the base name of file is in the index list
+func isIndexFile(file string, indexes []string) bool {
+	name := filepath.Base(file)
+	for _, item := range indexes {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findIndexFile find the first exists index file of directory
+func findIndexFile(staticFile StaticFile, dir string, indexes []string) (string, bool) {
+	for _, name := range indexes {
+		file := filepath.Join(dir, name)
+		if staticFile.Exists(file) {
+			return file, true
+		}
+	}
+	return dir, false
+}
+
+// isContentTypeMatched check the media type of content type is in the list
+func isContentTypeMatched(contentType string, contentTypes []string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	if mediaType == "" {
+		return false
+	}
+	for _, item := range contentTypes {
+		if strings.EqualFold(item, mediaType) {
+			return true
+		}
+		// 支持 text/* 的形式
+		if strings.HasSuffix(item, "/*") && len(mediaType) > len(item)-1 && strings.EqualFold(item[:len(item)-1], mediaType[:len(item)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// charsetContentTypes the content types which should specify charset
+var charsetContentTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/json",
+}
+
+// appendCharset append the charset to the content type of text,
+// it will be ignored if the content type has charset
+func appendCharset(contentType, charset string) string {
+	if !isContentTypeMatched(contentType, charsetContentTypes) || strings.Contains(strings.ToLower(contentType), "charset=") {
+		return contentType
+	}
+	return contentType + "; charset=" + charset
+}
+
+// checkRoot check the root path is a readable directory
+func checkRoot(staticFile StaticFile, root string) error {
+	info := staticFile.Stat(root)
+	if info == nil || !info.IsDir() {
+		return ErrRootInvalid
+	}
+	if dirLister, ok := getDirLister(staticFile); ok {
+		_, err := dirLister.ReadDir(root)
+		if err != nil {
+			return ErrRootInvalid
+		}
+	}
+	return nil
+}
+
+// closeReader close the reader if it's a closer
+func closeReader(r io.Reader) {
+	if closer, ok := r.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// getFileContent get the content of file, the error will be converted to hes.Error
+func getFileContent(staticFile StaticFile, file string) ([]byte, error) {
+	buf, err := staticFile.Get(file)
+	if err != nil {
+		he, ok := err.(*hes.Error)
+		if !ok {
+			he = hes.NewWithErrorStatusCode(err, http.StatusInternalServerError)
+			he.Category = ErrCategory
+		}
+		return nil, he
+	}
+	return buf, nil
+}
+
+// SHA1ETagHasher hash the content by sha1, it's the default etag hasher
+func SHA1ETagHasher(buf []byte) string {
+	sum := sha1.Sum(buf)
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
+// SHA256ETagHasher hash the content by sha256
+func SHA256ETagHasher(buf []byte) string {
+	sum := sha256.Sum256(buf)
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
+// crc32cTable crc32 table of castagnoli
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// CRC32CETagHasher hash the content by crc32(castagnoli), it's faster for large file
+func CRC32CETagHasher(buf []byte) string {
+	return fmt.Sprintf("%08x", crc32.Checksum(buf, crc32cTable))
+}
+
 // generateETag generate eTag
 func generateETag(buf []byte) string {
+	return generateETagWithHasher(buf, nil)
+}
+
+// generateETagWithHasher generate eTag by the hasher, sha1 is used if the hasher is nil
+func generateETagWithHasher(buf []byte, hasher func([]byte) string) string {
 	size := len(buf)
-	if size == 0 {
-		return `"0-2jmj7l5rSw0yVb_vlWAYkK_YBwk="`
+	if hasher == nil {
+		if size == 0 {
+			return `"0-2jmj7l5rSw0yVb_vlWAYkK_YBwk="`
+		}
+		hasher = SHA1ETagHasher
 	}
-	h := sha1.New()
-	_, err := h.Write(buf)
-	if err != nil {
+	return fmt.Sprintf(`"%x-%s"`, size, hasher(buf))
+}
+
+// getWeakETag get the weak etag of size and modified time(unix), such as W/"400-5cfb1ad2",
+// it's formatted in the buffer of stack instead of fmt.Sprintf
+func getWeakETag(size, modTime int64) string {
+	var buf [48]byte
+	b := append(buf[:0], `W/"`...)
+	b = strconv.AppendInt(b, size, 16)
+	b = append(b, '-')
+	b = strconv.AppendInt(b, modTime, 16)
+	b = append(b, '"')
+	return string(b)
+}
+
+// getCacheControl get the cache control of config
+func getCacheControl(config *Config) string {
+	if config.CacheControl != "" {
+		return config.CacheControl
+	}
+	switch config.CacheControlMode {
+	case CacheControlNoCache, CacheControlNoStore:
+		return string(config.CacheControlMode)
+	}
+	private := config.Private || config.CacheControlMode == CacheControlPrivate
+	cacheArr := []string{
+		string(CacheControlPublic),
+	}
+	if private {
+		cacheArr[0] = string(CacheControlPrivate)
+	}
+	if config.MaxAge > 0 {
+		cacheArr = append(cacheArr, "max-age="+strconv.Itoa(config.MaxAge))
+	}
+	if config.SMaxAge > 0 {
+		cacheArr = append(cacheArr, "s-maxage="+strconv.Itoa(config.SMaxAge))
+	}
+	if config.StaleWhileRevalidate > 0 {
+		cacheArr = append(cacheArr, "stale-while-revalidate="+strconv.Itoa(config.StaleWhileRevalidate))
+	}
+	if config.StaleIfError > 0 {
+		cacheArr = append(cacheArr, "stale-if-error="+strconv.Itoa(config.StaleIfError))
+	}
+	if config.Immutable {
+		cacheArr = append(cacheArr, "immutable")
+	}
+	// 仅public时无需设置
+	if len(cacheArr) == 1 && !private {
 		return ""
 	}
-	hash := base64.URLEncoding.EncodeToString(h.Sum(nil))
-	return fmt.Sprintf(`"%x-%s"`, size, hash)
+	return strings.Join(cacheArr, ", ")
 }
 
 // NewDefault create a static server milldeware use FS
@@ -138,20 +836,216 @@ func NewDefault(config Config) elton.Handler {
 	return New(&FS{}, config)
 }
 
-// New create a static serve middleware
+// New create a static serve middleware, it panics if the config is invalid
 func New(staticFile StaticFile, config Config) elton.Handler {
-	cacheArr := []string{
-		"public",
+	handler, err := newE(staticFile, config)
+	if err != nil {
+		panic(err)
 	}
-	if config.MaxAge > 0 {
-		cacheArr = append(cacheArr, "max-age="+strconv.Itoa(config.MaxAge))
+	return handler
+}
+
+// newE create a static serve middleware, the error of config(such as watcher
+// and dir configs) is returned
+func newE(staticFile StaticFile, config Config) (elton.Handler, error) {
+	if len(config.Hosts) != 0 {
+		return newHostHandler(staticFile, config)
 	}
-	if config.SMaxAge > 0 {
-		cacheArr = append(cacheArr, "s-maxage="+strconv.Itoa(config.SMaxAge))
+	cacheControl := getCacheControl(&config)
+	var securityHeaders map[string]string
+	if config.EnableSecurityHeaders {
+		securityHeaders = getSecurityHeaders(config.SecurityHeaders)
+	}
+	// 仅使用MaxAge生成的Cache-Control才设置Expires
+	var expiresMaxAge time.Duration
+	if config.EnableExpires && config.CacheControl == "" && config.MaxAge > 0 &&
+		config.CacheControlMode != CacheControlNoCache && config.CacheControlMode != CacheControlNoStore {
+		expiresMaxAge = time.Duration(config.MaxAge) * time.Second
+	}
+	var fingerprintPattern *regexp.Regexp
+	if config.ImmutableFingerprint {
+		fingerprintPattern = config.FingerprintPattern
+		if fingerprintPattern == nil {
+			fingerprintPattern = defaultFingerprintPattern
+		}
+	}
+	fileTooLargeError := config.FileTooLargeError
+	if fileTooLargeError == nil {
+		fileTooLargeError = ErrFileTooLarge
+	}
+	mimeTypes := make(map[string]string)
+	for ext, contentType := range config.MIMETypes {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		mimeTypes[ext] = contentType
+	}
+	// 旧版本的mime中可能未包含的类型
+	for ext, contentType := range defaultMIMETypes {
+		if mimeTypes[ext] == "" {
+			mimeTypes[ext] = contentType
+		}
+	}
+	indexes := getIndexes(&config)
+	// 禁止访问.开头的文件时，目录列表中也不展示
+	listingFilter := func(info os.FileInfo) bool {
+		return true
+	}
+	dotSegments := config.DotSegmentAllow
+	if dotSegments == nil {
+		dotSegments = []string{defaultDotSegment}
+	}
+	var dotValidator func(string) error
+	if config.DenyDot {
+		dotValidator = NewDotValidator(config.DotAllow, dotSegments)
+		segmentValidator := NewDotValidator(nil, dotSegments)
+		listingFilter = func(info os.FileInfo) bool {
+			return segmentValidator(info.Name()) == nil
+		}
+	}
+	methods := make(map[string]bool)
+	for _, method := range config.Methods {
+		methods[strings.ToUpper(method)] = true
+	}
+	allow := strings.ToUpper(strings.Join(config.Methods, ", "))
+	optionsAllow := allow
+	if optionsAllow == "" {
+		optionsAllow = defaultAllowMethods
+	}
+	allowQueryKeys := make(map[string]bool)
+	for _, key := range config.AllowQueryKeys {
+		allowQueryKeys[key] = true
+	}
+	if config.SignedURL != nil {
+		allowQueryKeys[config.SignedURL.signatureKey()] = true
+		allowQueryKeys[config.SignedURL.expiresKey()] = true
+	}
+	compressMinLength := config.CompressMinLength
+	if compressMinLength <= 0 {
+		compressMinLength = defaultCompressMinLength
+	}
+	compressContentTypes := config.CompressContentTypes
+	if len(compressContentTypes) == 0 {
+		compressContentTypes = defaultCompressContentTypes
+	}
+	var compressCache *ContentCache
+	if config.CompressCacheSize > 0 {
+		compressCache = NewContentCache(config.CompressCacheSize)
+	}
+	transformContentTypes := config.TransformContentTypes
+	if len(transformContentTypes) == 0 {
+		transformContentTypes = []string{
+			"text/html",
+		}
+	}
+	cache := config.ContentCache
+	if cache == nil && config.ContentCacheSize > 0 {
+		cache = NewContentCache(config.ContentCacheSize)
+	}
+	cacheFileSize := config.ContentCacheFileSize
+	if cache != nil && (cacheFileSize <= 0 || cacheFileSize > cache.maxSize) {
+		cacheFileSize = cache.maxSize
+	}
+	// 超过MaxFileSize的文件不读取至内存，因此也不缓存（以流的形式返回或返回出错）
+	if config.MaxFileSize > 0 && int64(cacheFileSize) > config.MaxFileSize {
+		cacheFileSize = int(config.MaxFileSize)
+	}
+	getContentType := func(ext string) string {
+		contentType := mimeTypes[strings.ToLower(ext)]
+		if contentType == "" {
+			contentType = mime.TypeByExtension(ext)
+		}
+		// 未知类型时使用默认的content type
+		if contentType == "" {
+			contentType = config.DefaultContentType
+		}
+		if contentType != "" && config.Charset != "" {
+			contentType = appendCharset(contentType, config.Charset)
+		}
+		return contentType
 	}
-	cacheControl := ""
-	if len(cacheArr) > 1 {
-		cacheControl = strings.Join(cacheArr, ", ")
+	// 扩展名对应的Content-Type，避免每次请求重新获取。响应头使用c.SetHeader设置，
+	// 不共享header的slice，避免其它中间件修改时影响所有的请求
+	contentTypes := sync.Map{}
+	setContentType := func(c *elton.Context, file string) {
+		ext := filepath.Ext(file)
+		if value, ok := contentTypes.Load(ext); ok {
+			c.SetHeader(elton.HeaderContentType, value.(string))
+			return
+		}
+		contentType := getContentType(ext)
+		if contentType == "" {
+			if config.Charset != "" {
+				if contentType := c.GetHeader(elton.HeaderContentType); contentType != "" {
+					c.SetHeader(elton.HeaderContentType, appendCharset(contentType, config.Charset))
+				}
+			}
+			return
+		}
+		contentTypes.Store(ext, contentType)
+		c.SetHeader(elton.HeaderContentType, contentType)
+	}
+	// 用于判断文件是否存在与获取文件信息
+	// 读取文件的超时（Exists、Stat、Get与NewReader）
+	sourceFile := staticFile
+	var timeoutSourceFile *timeoutFile
+	if config.Timeout > 0 {
+		timeoutSourceFile = newTimeoutFile(staticFile, config.Timeout)
+		sourceFile = timeoutSourceFile
+	}
+	// FileOpener的文件每个请求只打开一次
+	openerFile, _ := staticFile.(*OpenerFile)
+	metaFile := sourceFile
+	var statCacheMetaFile *statCacheFile
+	if config.StatCacheTTL > 0 {
+		statCacheMetaFile = newStatCacheFile(sourceFile, config.StatCacheTTL, config.StatCacheSize)
+		metaFile = statCacheMetaFile
+	}
+	// 修改时间为零值时（如embed.FS）使用配置的修改时间
+	stat := func(metaFile StaticFile, file string) os.FileInfo {
+		info := metaFile.Stat(file)
+		if info != nil && !config.ModifiedTime.IsZero() && isZeroTime(info.ModTime()) {
+			return &fileInfoWithModTime{
+				FileInfo: info,
+				modTime:  config.ModifiedTime,
+			}
+		}
+		return info
+	}
+	var eTags *eTagCache
+	if config.ETagCacheSize > 0 && config.EnableStrongETag {
+		eTags = newETagCache(config.ETagCacheSize)
+	}
+	// 合并同一文件的并发读取（及计算strong etag）
+	var readGroup singleflight.Group
+	readFile := func(file string) ([]byte, string, error) {
+		type result struct {
+			buf  []byte
+			eTag string
+		}
+		v, err, _ := readGroup.Do(file, func() (interface{}, error) {
+			buf, err := getFileContent(sourceFile, file)
+			if err != nil {
+				return nil, err
+			}
+			r := &result{
+				buf: buf,
+			}
+			if config.EnableStrongETag {
+				r.eTag = generateETagWithHasher(buf, config.ETagHasher)
+			}
+			return r, nil
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		r := v.(*result)
+		return r.buf, r.eTag, nil
+	}
+	var notFounds *notFoundCache
+	if config.NotFoundCacheTTL > 0 {
+		notFounds = newNotFoundCache(config.NotFoundCacheTTL, config.NotFoundCacheSize)
 	}
 	skipper := config.Skipper
 	if skipper == nil {
@@ -159,10 +1053,325 @@ func New(staticFile StaticFile, config Config) elton.Handler {
 	}
 	// convert to the different os file path
 	basePath := filepath.Join(config.Path, "")
+	notFoundFile := ""
+	if config.NotFoundFile != "" {
+		notFoundFile = filepath.Join(basePath, config.NotFoundFile)
+	}
+	// 文件不存在时的处理
+	serveNotFound := func(c *elton.Context, file string) error {
+		if config.NotFoundHandler != nil {
+			c.Set(NotFoundFileKey, file)
+			return config.NotFoundHandler(c)
+		}
+		// 返回自定义的404页面
+		if notFoundFile != "" && metaFile.Exists(notFoundFile) {
+			buf, err := getFileContent(sourceFile, notFoundFile)
+			if err != nil {
+				return err
+			}
+			setContentType(c, notFoundFile)
+			c.NoCache()
+			c.StatusCode = http.StatusNotFound
+			c.BodyBuffer = bytes.NewBuffer(buf)
+			return c.Next()
+		}
+		if config.NotFoundNext {
+			return c.Next()
+		}
+		return ErrNotFound
+	}
+	if err := config.SymlinkPolicy.validate(); err != nil {
+		return nil, err
+	}
+	// 仅FS的文件为系统文件，可检查符号链接
+	var symlinkFS *FS
+	if config.SymlinkPolicy != "" && config.SymlinkPolicy != SymlinkFollow {
+		symlinkFS, _ = staticFile.(*FS)
+	}
+	var caseFiles *caseIndex
+	if config.CaseInsensitive {
+		if dirLister, ok := getDirLister(staticFile); ok {
+			caseFiles = newCaseIndex(dirLister, 0)
+		}
+	}
+	sourceMap, err := newSourceMapRule(config.SourceMapPolicy, config.SourceMapAllowIPs)
+	if err != nil {
+		return nil, err
+	}
+	precompressedOnly := newExtensionSet(config.PrecompressedOnly)
+	var extensionValidator func(string) error
+	if len(config.AllowExtensions) != 0 || len(config.DenyExtensions) != 0 {
+		extensionValidator = NewExtensionValidator(config.AllowExtensions, config.DenyExtensions)
+	}
+	var limiter *concurrencyLimiter
+	retryAfter := "1"
+	if config.MaxConcurrency > 0 {
+		limiter = newConcurrencyLimiter(config.MaxConcurrency, config.ConcurrencyWait)
+		if config.RetryAfter > 0 {
+			retryAfter = strconv.Itoa(int(math.Ceil(config.RetryAfter.Seconds())))
+		}
+	}
+	ipRules, err := newIPRules(config.IPRules)
+	if err != nil {
+		return nil, err
+	}
+	clientIP := config.ClientIP
+	if clientIP == nil {
+		clientIP = func(c *elton.Context) string {
+			return c.RemoteAddr()
+		}
+	}
+	if config.StrictRoot {
+		if err := checkRoot(staticFile, basePath); err != nil {
+			return nil, err
+		}
+	}
+	var fileMetaList *fileMetas
+	if config.EnableFileMeta {
+		fileMetaList = newFileMetas()
+	}
+	var dirConfs *dirConfigs
+	if config.DirConfigFile != "" {
+		dirConfs, err = newDirConfigs(staticFile, basePath, config.DirConfigFile)
+		if err != nil {
+			return nil, configError("%s", err.Error())
+		}
+	}
+	if config.Watcher != nil {
+		err := config.Watcher.Watch(basePath, func(file string) {
+			if dirConfs != nil {
+				dirConfs.refresh(file)
+			}
+			if cache != nil {
+				cache.Remove(file)
+			}
+			if compressCache != nil {
+				compressCache.Remove(file)
+			}
+			if eTags != nil {
+				eTags.remove(file)
+			}
+			if sc, ok := metaFile.(*statCacheFile); ok {
+				sc.remove(file)
+			}
+			if notFounds != nil {
+				notFounds.purge()
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 配置均有效后才开始预热
+	if config.WarmUp != nil {
+		warm := func(file string) error {
+			file = filepath.Join(basePath, file)
+			fileInfo := stat(metaFile, file)
+			if fileInfo == nil || fileInfo.IsDir() {
+				return ErrNotFound
+			}
+			if config.MaxFileSize > 0 && fileInfo.Size() > config.MaxFileSize {
+				return fileTooLargeError
+			}
+			cacheable := cache != nil && fileInfo.Size() <= int64(cacheFileSize)
+			contentType := mimeTypes[strings.ToLower(filepath.Ext(file))]
+			if contentType == "" {
+				contentType = mime.TypeByExtension(filepath.Ext(file))
+			}
+			compressible := compressCache != nil && len(config.Compressors) != 0 &&
+				fileInfo.Size() >= int64(compressMinLength) &&
+				isContentTypeMatched(contentType, compressContentTypes)
+			if !cacheable && !compressible && (eTags == nil || !config.EnableStrongETag) {
+				return nil
+			}
+			buf, eTag, err := readFile(file)
+			if err != nil {
+				return err
+			}
+			if cacheable {
+				cache.Add(file, fileInfo.ModTime(), fileInfo.Size(), buf, eTag)
+			}
+			if eTags != nil && eTag != "" {
+				eTags.Add(file, fileInfo.ModTime(), fileInfo.Size(), eTag)
+			}
+			if compressible {
+				for _, compressor := range config.Compressors {
+					compressedBuf, err := compressor.Compress(buf)
+					if err != nil {
+						return err
+					}
+					compressCache.Add(file+":"+compressor.Encoding(), fileInfo.ModTime(), fileInfo.Size(), compressedBuf, eTag)
+				}
+			}
+			return nil
+		}
+		go func() {
+			files, err := getWarmUpFiles(staticFile, &config, basePath)
+			if err != nil {
+				if config.WarmUp.OnProgress != nil {
+					config.WarmUp.OnProgress(WarmUpProgress{
+						Err: err,
+					})
+				}
+				return
+			}
+			warmUp(config.WarmUp, files, warm)
+		}()
+	}
+	// 不可访问的文件（目录配置与元数据文件）
+	isHiddenFile := func(file string) bool {
+		return (dirConfs != nil && dirConfs.isConfigFile(file)) ||
+			(fileMetaList != nil && isFileMeta(file))
+	}
+	// 校验文件路径的访问规则（IP与签名）
+	// 校验url path的IP规则与签名
+	checkRulePath := func(c *elton.Context, urlPath string) error {
+		if len(ipRules) != 0 && !isIPAllowed(ipRules, urlPath, clientIP(c)) {
+			return rejectError(ErrIPNotAllowed, config.HideRejectionReason)
+		}
+		// 校验签名
+		if config.SignedURL != nil && config.SignedURL.match(urlPath) {
+			if e := config.SignedURL.verify(c.Request.URL); e != nil {
+				return rejectError(e, config.HideRejectionReason)
+			}
+		}
+		return nil
+	}
+	checkPathRules := func(c *elton.Context, file string) error {
+		return checkRulePath(c, relativePath(basePath, file))
+	}
+	// 文件（os path）是否符合符号链接的规则
+	isSymlinkAllowedFile := func(file string) bool {
+		return symlinkFS == nil || isSymlinkAllowed(config.SymlinkPolicy, symlinkFS.path(basePath), symlinkFS.path(file))
+	}
+	// 校验最终访问的文件或目录（os path）的访问控制，第一个返回值为true表示以404返回
+	checkFile := func(c *elton.Context, file string, dir bool) (bool, error) {
+		urlPath := relativePath(basePath, file)
+		if config.ManifestOnly {
+			if dir && !config.Manifest.hasDir(urlPath) {
+				return true, nil
+			}
+			if !dir && !config.Manifest.has(urlPath) {
+				return true, nil
+			}
+		}
+		if !isSymlinkAllowedFile(file) {
+			return false, rejectError(ErrSymlinkNotAllowed, config.HideRejectionReason)
+		}
+		// 检查最终访问的文件（已处理index、fallback等）的扩展名
+		if !dir && extensionValidator != nil {
+			if he := validatePath(file, []func(string) error{extensionValidator}); he != nil {
+				return false, rejectError(he, config.HideRejectionReason)
+			}
+		}
+		if config.Authorize != nil {
+			if err := config.Authorize(c, urlPath); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+	// 目录列表中的文件是否可展示，与访问文件的规则一致（不可访问的文件不展示）
+	isListable := func(c *elton.Context, metaFile StaticFile, dir string, info os.FileInfo) bool {
+		if !listingFilter(info) {
+			return false
+		}
+		file := filepath.Join(dir, info.Name())
+		urlPath := relativePath(basePath, file)
+		if isHiddenFile(file) {
+			return false
+		}
+		// 目录使用以/结尾的路径匹配（如 /internal/ ）
+		rulePath := urlPath
+		if info.IsDir() {
+			rulePath += "/"
+		}
+		if len(ipRules) != 0 && !isIPAllowed(ipRules, rulePath, clientIP(c)) {
+			return false
+		}
+		// 需要签名访问的文件（列表中的地址未签名）不展示
+		if config.SignedURL != nil && config.SignedURL.match(rulePath) {
+			return false
+		}
+		if info.IsDir() {
+			return !config.ManifestOnly || config.Manifest.hasDir(urlPath)
+		}
+		if config.ManifestOnly && !config.Manifest.has(urlPath) {
+			return false
+		}
+		if extensionValidator != nil && extensionValidator(info.Name()) != nil {
+			return false
+		}
+		if sourceMap != nil && !sourceMap.isAllowed(clientIP(c)) && isSourceMap(file) {
+			return false
+		}
+		// 预压缩文件（存在原文件）不展示
+		if len(config.Precompressed) != 0 && isPrecompressedFile(metaFile, file, config.Precompressed) {
+			return false
+		}
+		return true
+	}
 	return func(c *elton.Context) (err error) {
 		if skipper(c) {
 			return c.Next()
 		}
+		// 仅处理中间件本身的出错，调用next之后的出错不处理
+		if config.ErrorHandler != nil {
+			originalNext := c.Next
+			nextCalled := false
+			c.Next = func() error {
+				nextCalled = true
+				return originalNext()
+			}
+			defer func() {
+				c.Next = originalNext
+				if err != nil && !nextCalled {
+					err = config.ErrorHandler(c, err)
+				}
+			}()
+		}
+		info := &ServeInfo{}
+		if config.OnServe != nil || config.OnError != nil {
+			startedAt := time.Now()
+			defer func() {
+				emitServe(c, &config, info, startedAt, err)
+			}()
+		}
+		// 每个请求使用独立的超时记录，Exists或Stat超时时返回ErrTimeout（而非404）
+		metaFile := metaFile
+		// 读取文件内容使用的static file
+		readerFile := sourceFile
+		var requestTimeout *timeoutFile
+		contextFile, withContext := staticFile.(ContextFile)
+		if timeoutSourceFile != nil || withContext || openerFile != nil {
+			var source StaticFile = staticFile
+			// 使用绑定请求context的副本判断文件是否存在与获取文件信息
+			if withContext {
+				source = contextFile.WithContext(c.Context())
+			}
+			// 每个文件只打开一次，文件信息与reader均从打开的文件获取
+			if openerFile != nil {
+				openerRequest := openerFile.withRequest()
+				defer openerRequest.close()
+				source = openerRequest
+			}
+			if timeoutSourceFile != nil {
+				requestTimeout = timeoutSourceFile.withTimeoutRecord()
+				requestTimeout.StaticFile = source
+				source = requestTimeout
+			}
+			metaFile = source
+			readerFile = source
+			if statCacheMetaFile != nil {
+				metaFile = statCacheMetaFile.withSource(source)
+			}
+		}
+		preflight := config.CORS != nil && isCORSPreflight(c.Request)
+		if len(methods) != 0 && !methods[c.Request.Method] && !preflight {
+			c.SetHeader(HeaderAllow, allow)
+			err = ErrMethodNotAllowed
+			return
+		}
 		file := ""
 		rawParams := c.RawParams
 		// 从第一个参数获取文件名
@@ -175,95 +1384,694 @@ func New(staticFile StaticFile, config Config) elton.Handler {
 		if file == "" {
 			file = url.Path
 		}
+		if config.StripPrefix != "" {
+			file = stripPathPrefix(file, config.StripPrefix)
+		}
+		if len(config.RewriteRules) != 0 || config.Rewrite != nil {
+			file = rewritePath(file, config.RewriteRules, config.Rewrite)
+		}
 
 		// 检查文件（路径）是否包括.
-		if config.DenyDot {
-			arr := strings.SplitN(file, string(filepath.Separator), -1)
-			for _, item := range arr {
-				if item != "" && item[0] == '.' {
-					err = ErrNotAllowAccessDot
-					return
-				}
+		if dotValidator != nil {
+			if he := validatePath(file, []func(string) error{dotValidator}); he != nil {
+				err = rejectError(he, config.HideRejectionReason)
+				return
+			}
+		}
+		if len(config.PathValidators) != 0 {
+			if he := validatePath(file, config.PathValidators); he != nil {
+				err = rejectError(he, config.HideRejectionReason)
+				return
 			}
 		}
 
-		file = filepath.Join(config.Path, file)
+		// 以/结尾表示访问的是目录
+		dirPath := strings.HasSuffix(file, "/")
 		// 避免文件名是有 .. 等导致最终文件路径越过配置的路径
-		if !strings.HasPrefix(file, basePath) {
-			err = ErrOutOfPath
+		file, ok := resolvePath(basePath, file)
+		if !ok {
+			err = rejectError(ErrOutOfPath, config.HideRejectionReason)
+			return
+		}
+		// 忽略大小写查找文件，需在访问规则（配置文件、IP、签名等）的判断之前，
+		// 避免通过不同大小写的路径绕过规则
+		if caseFiles != nil && !metaFile.Exists(file) {
+			file = caseFiles.resolve(basePath, file)
+		}
+		if isHiddenFile(file) {
+			return serveNotFound(c, relativePath(basePath, file))
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			if config.CORS != nil {
+				setCORSHeaders(c.Headers, config.CORS, c.Request, relativePath(basePath, file))
+			}
+			c.SetHeader(HeaderAllow, optionsAllow)
+			c.NoContent()
 			return
 		}
 
+		err = checkPathRules(c, file)
+		if err != nil {
+			return
+		}
+		// 删除不允许的query参数
+		queryStripped := false
+		if config.StripQueryString && url.RawQuery != "" {
+			url.RawQuery, queryStripped = stripQuery(url.RawQuery, allowQueryKeys)
+		}
 		// 禁止 querystring
-		if config.DenyQueryString && url.RawQuery != "" {
-			err = ErrNotAllowQueryString
+		if config.DenyQueryString && url.RawQuery != "" && !isQueryAllowed(url.RawQuery, allowQueryKeys) {
+			err = rejectError(ErrNotAllowQueryString, config.HideRejectionReason)
+			return
+		}
+		// 最近查找过且不存在的文件
+		requestFile := file
+		info.File = relativePath(basePath, file)
+		if notFounds != nil && notFounds.Has(requestFile) {
+			return serveNotFound(c, info.File)
+		}
+		// index文件重定向至目录地址（仅当该文件为目录对应的index文件时），避免重复的缓存
+		if config.RedirectIndex && !dirPath && isIndexFile(file, indexes) {
+			indexFile, ok := findIndexFile(metaFile, filepath.Dir(file), indexes)
+			if ok && indexFile == file {
+				// 使用clean后的路径（多个/合并为一个），避免 //evil.com/ 形式的重定向至其它域名
+				cleanPath := path.Clean("/" + url.Path)
+				target := strings.TrimSuffix(cleanPath, path.Base(cleanPath))
+				if url.RawQuery != "" {
+					target += "?" + url.RawQuery
+				}
+				return c.Redirect(http.StatusMovedPermanently, target)
+			}
+		}
+		exists := false
+		// 文件信息只获取一次，用于判断目录、缓存、etag与Last-Modified等，
+		// 避免多次Stat且文件替换时前后不一致，仅在文件变化时（index、fallback等）才重新获取
+		var fileInfo os.FileInfo
+		infoFile := ""
+		if !dirPath {
+			fileInfo = stat(metaFile, file)
+			infoFile = file
+		}
+		dir := dirPath || (fileInfo != nil && fileInfo.IsDir())
+		// 目录请求重定向至以/结尾的地址，保证index.html中的相对路径正确
+		if dir && !dirPath && config.RedirectDirSlash {
+			target := path.Base(url.Path) + "/"
+			if url.RawQuery != "" {
+				target += "?" + url.RawQuery
+			}
+			return c.Redirect(http.StatusMovedPermanently, target)
+		}
+		dirIndexes := indexes
+		dirListing := config.EnableDirectoryListing
+		if dir && dirConfs != nil {
+			if conf := dirConfs.get(relativePath(basePath, file)); conf != nil {
+				if len(conf.Index) != 0 {
+					dirIndexes = conf.Index
+				}
+				if conf.DirectoryListing != nil {
+					dirListing = *conf.DirectoryListing
+				}
+			}
+		}
+		// 如果是目录，则查找对应的index文件
+		if dir {
+			file, exists = findIndexFile(metaFile, file, dirIndexes)
+		} else {
+			// 已获取文件信息则无需再判断是否存在
+			exists = fileInfo != nil || metaFile.Exists(file)
+		}
+		// 目录无index文件时，如果支持则生成目录列表
+		if !exists && dir && dirListing {
+			if dirLister, ok := getDirLister(staticFile); ok {
+				// 目录列表与文件的访问控制一致
+				notFound, e := checkFile(c, file, true)
+				if notFound {
+					return serveNotFound(c, info.File)
+				}
+				if e != nil {
+					err = e
+					return
+				}
+				// 目录使用以/结尾的路径匹配IP与签名的规则（如 /internal/ ）
+				dirPath := relativePath(basePath, file)
+				if !strings.HasSuffix(dirPath, "/") {
+					dirPath += "/"
+				}
+				if e := checkRulePath(c, dirPath); e != nil {
+					err = e
+					return
+				}
+				dir := file
+				filter := func(info os.FileInfo) bool {
+					return isListable(c, metaFile, dir, info)
+				}
+				served, e := serveDirListing(c, dirLister, file, filter, config.DirectoryListingJSON, config.DirectoryListingTemplate)
+				if e != nil {
+					err = e
+					return
+				}
+				if served {
+					return c.Next()
+				}
+			}
+		}
+		// 无扩展名的请求尝试对应的html文件（如 /about 对应 /about.html ）
+		if !exists && !dir && config.CleanURLs && filepath.Ext(file) == "" {
+			if metaFile.Exists(file + ".html") {
+				file += ".html"
+				exists = true
+			}
+		}
+		// 文件不存在时依次尝试添加扩展名
+		if !exists && !dir {
+			for _, ext := range config.TryExtensions {
+				if metaFile.Exists(file + ext) {
+					file += ext
+					exists = true
+					break
+				}
+			}
+		}
+		// 文件不存在时使用fallback文件（如单页应用的history模式）
+		if !exists && config.Fallback != "" {
+			file = filepath.Join(basePath, config.Fallback)
+			exists = metaFile.Exists(file)
+		}
+		if requestTimeout.isTimeout() {
+			err = ErrTimeout
 			return
 		}
-		exists := staticFile.Exists(file)
 		if !exists {
-			if config.NotFoundNext {
-				return c.Next()
+			if notFounds != nil {
+				notFounds.Add(requestFile)
 			}
-			err = ErrNotFound
+			return serveNotFound(c, info.File)
+		}
+		// 最终访问的文件（index、CleanURLs、TryExtensions与fallback）与请求的不一致时，
+		// 访问规则（如 *.html 、 *.pdf ）需要重新匹配
+		if file != requestFile {
+			if isHiddenFile(file) {
+				return serveNotFound(c, info.File)
+			}
+			err = checkPathRules(c, file)
+			if err != nil {
+				return
+			}
+		}
+		notFound, err := checkFile(c, file, false)
+		if notFound {
+			return serveNotFound(c, info.File)
+		}
+		if err != nil {
 			return
 		}
+		checkedFile := file
+		sourceMapAllowed := sourceMap == nil || sourceMap.isAllowed(clientIP(c))
+		if !sourceMapAllowed && isSourceMap(file) {
+			return serveNotFound(c, info.File)
+		}
+		// 盗链时返回403或替代的文件
+		hotlinked := false
+		if config.Hotlink != nil && config.Hotlink.match(file) && !config.Hotlink.isAllowed(c.Request) {
+			if config.Hotlink.Placeholder == "" {
+				err = ErrHotlink
+				return
+			}
+			hotlinked = true
+			file = filepath.Join(basePath, config.Hotlink.Placeholder)
+		}
 
-		c.SetContentTypeByExt(file)
+		// 限制同时读取的文件数，数据为reader时在返回数据后才释放
+		if limiter != nil {
+			release, ok := limiter.acquire(c.Context())
+			if !ok {
+				c.SetHeader(HeaderRetryAfter, retryAfter)
+				err = ErrTooManyConcurrency
+				return
+			}
+			defer func() {
+				releaseAfterServe(c, release, err)
+			}()
+		}
+
+		// 原始的文件，用于匹配缓存规则等
+		originalFile := file
+		urlPath := relativePath(basePath, originalFile)
+		var dirConf *DirConfig
+		if dirConfs != nil {
+			dirConf = dirConfs.get(path.Dir(urlPath))
+		}
+		var fileMeta *FileMeta
+		if fileMetaList != nil {
+			fileMeta, err = fileMetaList.get(metaFile, originalFile)
+			if err != nil {
+				return
+			}
+		}
+		info.File = urlPath
+		if links := getPreloadLinks(config.Preload[urlPath]); links != "" {
+			c.AddHeader(HeaderLink, links)
+			// 在读取文件前先返回103，客户端可提前加载资源
+			if config.EarlyHints && c.Request.Method == http.MethodGet {
+				writeEarlyHints(c.Response)
+			}
+		}
+		// 根据Accept-Language使用对应语言的文件（如 index.en.html ）
+		if len(config.Languages) != 0 {
+			lang, ok := getLanguageVariant(metaFile, file, c.GetRequestHeader(HeaderAcceptLanguage), config.Languages, config.DefaultLanguage)
+			if ok {
+				addVary(c.Headers, HeaderAcceptLanguage)
+			}
+			if lang != "" {
+				file = getLanguageFile(file, lang)
+				c.SetHeader(HeaderContentLanguage, lang)
+			} else if config.DefaultLanguage != "" {
+				c.SetHeader(HeaderContentLanguage, config.DefaultLanguage)
+			}
+		}
+		// 客户端支持时使用图片的其它格式（如 photo.jpg.avif ）
+		if len(config.ImageVariants) != 0 && strings.HasPrefix(mime.TypeByExtension(filepath.Ext(file)), "image/") {
+			variant, ok := getImageVariant(metaFile, file, c.GetRequestHeader(HeaderAccept), config.ImageVariants)
+			if ok {
+				addVary(c.Headers, HeaderAccept)
+			}
+			if variant != "" {
+				file += imageVariantExts[variant]
+			}
+		}
+		setContentType(c, file)
+		if fileMeta != nil && fileMeta.ContentType != "" {
+			c.SetHeader(elton.HeaderContentType, fileMeta.ContentType)
+		}
+		markdown := config.Markdown != nil && config.Markdown.Renderer != nil && config.Markdown.match(file)
+		if markdown {
+			c.SetHeader(elton.HeaderContentType, "text/html; charset=utf-8")
+		}
+		htmlTransform := config.Transform != nil && isContentTypeMatched(c.GetHeader(elton.HeaderContentType), transformContentTypes)
+		transformable := markdown || htmlTransform
+		// 需要转换的数据不使用预压缩文件
+		if precompressedOnly != nil && !transformable && precompressedOnly[strings.ToLower(filepath.Ext(file))] {
+			encoding, ok := getStrictPrecompressedEncoding(metaFile, file, c.GetRequestHeader(elton.HeaderAcceptEncoding), config.Precompressed)
+			if !ok {
+				err = ErrPrecompressedMissing
+				return
+			}
+			addVary(c.Headers, elton.HeaderAcceptEncoding)
+			file += precompressedExts[encoding]
+			c.SetHeader(elton.HeaderContentEncoding, encoding)
+		} else if len(config.Precompressed) != 0 && !transformable {
+			encoding, ok := getPrecompressedEncoding(metaFile, file, c.GetRequestHeader(elton.HeaderAcceptEncoding), config.Precompressed)
+			if ok {
+				addVary(c.Headers, elton.HeaderAcceptEncoding)
+			}
+			if encoding != "" {
+				file += precompressedExts[encoding]
+				c.SetHeader(elton.HeaderContentEncoding, encoding)
+			}
+		}
+		if infoFile != file {
+			fileInfo = stat(metaFile, file)
+			infoFile = file
+		}
+		// 变体与预压缩文件的查找或Stat超时
+		if requestTimeout.isTimeout() {
+			err = ErrTimeout
+			return
+		}
+		// 实际读取的文件（语言、图片格式、预压缩文件或盗链的替代文件）也需要符合符号链接的规则
+		if file != checkedFile && !isSymlinkAllowedFile(file) {
+			err = rejectError(ErrSymlinkNotAllowed, config.HideRejectionReason)
+			return
+		}
+		// 未使用预压缩文件时，可压缩的数据在运行时压缩
+		var compressor Compressor
+		if len(config.Compressors) != 0 && c.GetHeader(elton.HeaderContentEncoding) == "" &&
+			isContentTypeMatched(c.GetHeader(elton.HeaderContentType), compressContentTypes) {
+			// 转换的数据与大小未知的文件长度未知，因此均可压缩
+			if transformable || fileInfo == nil || fileInfo.Size() < 0 || fileInfo.Size() >= int64(compressMinLength) {
+				addVary(c.Headers, elton.HeaderAcceptEncoding)
+				compressor = getCompressor(config.Compressors, c.GetRequestHeader(elton.HeaderAcceptEncoding))
+			}
+		}
+		// 客户端指定no-cache时，不判断If-None-Match与If-Modified-Since（不修改请求头）
+		noCache := !config.IgnoreClientNoCache && isNoCacheRequest(c.Request.Header)
+
+		// HEAD请求只设置响应头，不读取文件内容
+		head := c.Request.Method == http.MethodHead
 		var fileBuf []byte
-		// strong etag需要读取文件内容计算etag
-		if !config.DisableETag && config.EnableStrongETag {
-			buf, e := staticFile.Get(file)
-			if e != nil {
-				he, ok := e.(*hes.Error)
-				if !ok {
-					he = hes.NewWithErrorStatusCode(e, http.StatusInternalServerError)
-					he.Category = ErrCategory
+		// 读取文件时（或缓存中）预先计算的strong etag
+		contentETag := ""
+		// 命中的缓存的添加时间
+		var cachedAt time.Time
+		// 如果启用了内容缓存，优先从缓存中获取
+		if cache != nil {
+			if fileInfo != nil && !fileInfo.IsDir() && fileInfo.Size() <= int64(cacheFileSize) {
+				info.CacheUsed = true
+				var buf []byte
+				var eTag string
+				item, ok := cache.get(file, fileInfo.ModTime(), fileInfo.Size())
+				info.CacheHit = ok
+				if ok {
+					buf = item.buf
+					eTag = item.eTag
+					cachedAt = item.addedAt
+				}
+				if !ok && !head {
+					buf, eTag, err = readFile(file)
+					if err != nil {
+						return
+					}
+					cache.Add(file, fileInfo.ModTime(), fileInfo.Size(), buf, eTag)
+				}
+				fileBuf = buf
+				contentETag = eTag
+			}
+		}
+		transform := transformable && !head
+		// 构建时生成的文件信息，转换的数据不使用
+		var manifestEntry *ManifestEntry
+		if config.Manifest != nil && !transformable {
+			manifestEntry = config.Manifest.lookup(relativePath(basePath, file), fileInfo)
+		}
+		manifestETag := ""
+		if manifestEntry != nil {
+			manifestETag = manifestEntry.ETag
+		}
+		strongETag := !config.DisableETag && config.EnableStrongETag && config.ETagFunc == nil && manifestETag == ""
+		// 转换的数据不使用缓存的etag
+		useETagCache := eTags != nil && strongETag && !transformable
+		if useETagCache && contentETag == "" && fileInfo != nil {
+			contentETag, _ = eTags.Get(file, fileInfo.ModTime(), fileInfo.Size())
+		}
+		// strong etag需要读取文件内容计算etag（自定义etag函数或已缓存etag则无需读取），转换响应数据也需要读取
+		// 压缩数据的缓存（转换的数据不缓存）
+		var compressedBuf []byte
+		compressKey := ""
+		if compressor != nil && compressCache != nil && !transformable && !head {
+			compressKey = file + ":" + compressor.Encoding()
+			if fileInfo != nil {
+				info.CacheUsed = true
+				item, ok := compressCache.get(compressKey, fileInfo.ModTime(), fileInfo.Size())
+				if ok {
+					info.CacheHit = true
+					compressedBuf = item.buf
+					cachedAt = item.addedAt
+					if contentETag == "" {
+						contentETag = item.eTag
+					}
 				}
-				err = he
+			}
+		}
+		if info.CacheUsed && config.CacheStatusHeader != "" {
+			if info.CacheHit {
+				c.SetHeader(config.CacheStatusHeader, "HIT")
+			} else {
+				c.SetHeader(config.CacheStatusHeader, "MISS")
+			}
+		}
+		if info.CacheHit && config.EnableCacheAge {
+			c.SetHeader(HeaderAge, strconv.Itoa(int(time.Since(cachedAt).Seconds())))
+		}
+		// HEAD请求无缓存的strong etag时也需读取文件计算，与GET请求的etag一致（转换的数据HEAD请求不设置etag）
+		needBuffer := transform || (strongETag && contentETag == "" && (!head || !transformable)) ||
+			(!head && compressor != nil && compressedBuf == nil)
+		// 文件过大不读取至内存而以流的形式返回
+		streamed := false
+		if fileBuf == nil && needBuffer && config.MaxFileSize > 0 {
+			if fileInfo != nil && fileInfo.Size() > config.MaxFileSize {
+				if !config.StreamLargeFile || transform {
+					err = fileTooLargeError
+					return
+				}
+				streamed = true
+				compressor = nil
+			}
+		}
+		if fileBuf == nil && needBuffer && !streamed {
+			var buf []byte
+			buf, contentETag, err = readFile(file)
+			if err != nil {
 				return
 			}
-			fileBuf = buf
+			if useETagCache && fileInfo != nil {
+				eTags.Add(file, fileInfo.ModTime(), fileInfo.Size(), contentETag)
+			}
+			// HEAD请求仅用于计算etag，响应头仍根据文件信息设置
+			if !head {
+				fileBuf = buf
+			}
+		}
+		// 转换后的数据用于生成strong etag
+		if transform && markdown {
+			fileBuf, err = config.Markdown.render(urlPath, fileBuf)
+			if err != nil {
+				return
+			}
+		}
+		if transform && htmlTransform {
+			fileBuf, err = config.Transform(c, file, fileBuf)
+			if err != nil {
+				return
+			}
+		}
+		// 数据过小则不压缩
+		if compressor != nil && fileBuf != nil && len(fileBuf) < compressMinLength {
+			compressor = nil
+		}
+		if compressor != nil {
+			c.SetHeader(elton.HeaderContentEncoding, compressor.Encoding())
 		}
 
 		if !config.DisableETag {
-			if config.EnableStrongETag {
-				eTag := generateETag(fileBuf)
+			if config.ETagFunc != nil {
+				eTag := config.ETagFunc(file, fileInfo)
 				if eTag != "" {
 					c.SetHeader(elton.HeaderETag, eTag)
 				}
-			} else {
-				fileInfo := staticFile.Stat(file)
-				if fileInfo != nil {
-					eTag := fmt.Sprintf(`W/"%x-%x"`, fileInfo.Size(), fileInfo.ModTime().Unix())
+			} else if manifestETag != "" {
+				c.SetHeader(elton.HeaderETag, manifestETag)
+			} else if config.EnableStrongETag && !streamed {
+				eTag := ""
+				if contentETag != "" && !transformable {
+					eTag = contentETag
+				} else if fileBuf != nil {
+					eTag = generateETagWithHasher(fileBuf, config.ETagHasher)
+					if !transformable {
+						contentETag = eTag
+					}
+				}
+				if eTag != "" {
 					c.SetHeader(elton.HeaderETag, eTag)
 				}
+			} else if transformable {
+				// 转换的数据可能与文件无关（如注入的运行时配置），因此使用转换后数据的etag，HEAD请求不设置
+				if transform && fileBuf != nil {
+					c.SetHeader(elton.HeaderETag, generateETagWithHasher(fileBuf, config.ETagHasher))
+				}
+			} else if fileInfo != nil {
+				c.SetHeader(elton.HeaderETag, getWeakETag(fileInfo.Size(), fileInfo.ModTime().Unix()))
 			}
 		}
 
+		if eTag := c.GetHeader(elton.HeaderETag); eTag != "" && compressor != nil {
+			c.SetHeader(elton.HeaderETag, appendETagEncoding(eTag, compressor.Encoding()))
+		}
+
 		if !config.DisableLastModified {
-			fileInfo := staticFile.Stat(file)
-			if fileInfo != nil {
-				lmd := fileInfo.ModTime().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")
-				c.SetHeader(elton.HeaderLastModified, lmd)
+			// 修改时间为零值时（部分嵌入式文件系统）不设置
+			if manifestEntry != nil && !manifestEntry.ModTime.IsZero() {
+				c.SetHeader(elton.HeaderLastModified, manifestEntry.ModTime.UTC().Format(http.TimeFormat))
+			} else if modTime, ok := getModifiedTime(fileInfo); ok {
+				c.SetHeader(elton.HeaderLastModified, modTime.Format(http.TimeFormat))
 			}
 		}
 
-		for k, v := range config.Header {
+		for k, v := range securityHeaders {
 			c.SetHeader(k, v)
 		}
-		if cacheControl != "" {
+		if config.CrossOriginIsolation && isContentTypeMatched(c.GetHeader(elton.HeaderContentType), []string{"text/html"}) {
+			for k, v := range crossOriginIsolationHeaders {
+				c.SetHeader(k, v)
+			}
+		}
+		headers := append([]map[string]string{config.Header}, getRuleHeaders(config.HeaderRules, urlPath)...)
+		for _, header := range headers {
+			for k, v := range header {
+				// Vary与其它功能设置的值合并
+				if http.CanonicalHeaderKey(k) == HeaderVary {
+					addVary(c.Headers, v)
+					continue
+				}
+				c.SetHeader(k, v)
+			}
+		}
+		if dirConf != nil {
+			for k, v := range dirConf.Header {
+				if http.CanonicalHeaderKey(k) == HeaderVary {
+					addVary(c.Headers, v)
+					continue
+				}
+				c.SetHeader(k, v)
+			}
+		}
+		if fileMeta != nil {
+			for k, v := range fileMeta.Header {
+				if http.CanonicalHeaderKey(k) == HeaderVary {
+					addVary(c.Headers, v)
+					continue
+				}
+				c.SetHeader(k, v)
+			}
+		}
+		addVary(c.Headers, config.Vary...)
+		if config.CORS != nil {
+			setCORSHeaders(c.Headers, config.CORS, c.Request, urlPath)
+		}
+		if ruleCacheControl, ok := getRuleCacheControl(config.CacheControlRules, urlPath); ok {
+			if ruleCacheControl != "" {
+				c.SetHeader(elton.HeaderCacheControl, ruleCacheControl)
+			}
+		} else if dirConf != nil && dirConf.CacheControl != "" {
+			c.SetHeader(elton.HeaderCacheControl, dirConf.CacheControl)
+		} else if fingerprintPattern != nil && fingerprintPattern.MatchString(filepath.Base(originalFile)) {
+			c.SetHeader(elton.HeaderCacheControl, immutableCacheControl)
+			if config.EnableExpires {
+				c.SetHeader(HeaderExpires, time.Now().Add(immutableMaxAge).UTC().Format(http.TimeFormat))
+			}
+		} else if cacheControl != "" {
 			c.SetHeader(elton.HeaderCacheControl, cacheControl)
+			if expiresMaxAge > 0 {
+				c.SetHeader(HeaderExpires, time.Now().Add(expiresMaxAge).UTC().Format(http.TimeFormat))
+			}
+		}
+		if fileMeta != nil && fileMeta.CacheControl != "" {
+			c.SetHeader(elton.HeaderCacheControl, fileMeta.CacheControl)
+			c.Headers.Del(HeaderExpires)
+		}
+		if config.Surrogate != nil {
+			config.Surrogate.setHeaders(c.Headers, urlPath, config.SMaxAge)
+		}
+		if queryStripped && config.StripQueryCacheControl != "" {
+			c.SetHeader(elton.HeaderCacheControl, config.StripQueryCacheControl)
+			c.Headers.Del(HeaderExpires)
+		}
+		// 替代的文件不可缓存，避免正常的请求也获取到此文件
+		if hotlinked {
+			c.NoCache()
+			c.Headers.Del(HeaderExpires)
+		}
+
+		if filename, ok := getAttachmentFilename(&config, c.Request.URL, urlPath); ok {
+			c.SetHeader(HeaderContentDisposition, getAttachmentDisposition(filename))
+		}
+		if fileMeta != nil {
+			if disposition := fileMeta.getDisposition(); disposition != "" {
+				c.SetHeader(HeaderContentDisposition, disposition)
+			}
+		}
+
+		if config.SetHeaders != nil {
+			config.SetHeaders(c, urlPath, fileInfo)
+		}
+		if !sourceMapAllowed {
+			removeSourceMapHeaders(c.Headers)
+		}
+
+		// 前置条件（If-Match、If-Unmodified-Since）不满足时返回412，优先于304的判断
+		if !config.DisableConditional && IsPreconditionFailed(c.Request, c.Headers) {
+			err = ErrPreconditionFailed
+			return
+		}
+		// 条件请求匹配则直接返回304，无需读取文件
+		if !config.DisableConditional && !noCache && IsNotModified(c.Request, c.Headers) {
+			c.NotModified()
+			return c.Next()
+		}
+
+		if compressor != nil && !head {
+			if compressedBuf != nil {
+				fileBuf = compressedBuf
+			} else if fileBuf != nil {
+				fileBuf, err = compressor.Compress(fileBuf)
+				if err != nil {
+					return
+				}
+				if compressKey != "" && fileInfo != nil {
+					compressCache.Add(compressKey, fileInfo.ModTime(), fileInfo.Size(), fileBuf, contentETag)
+				}
+			}
+		}
+
+		var r io.Reader
+		// HEAD请求仅在需要判断是否支持range时才创建reader
+		if fileBuf == nil && (!head || config.EnableRange) {
+			r, err = newReader(c.Context(), readerFile, file)
+			if err != nil {
+				if _, ok := err.(*hes.Error); !ok {
+					err = getStaticServeError(err.Error(), http.StatusBadRequest)
+				}
+				return
+			}
 		}
-		if fileBuf != nil {
-			c.BodyBuffer = bytes.NewBuffer(fileBuf)
+		// 仅在启用range且数据支持seek时才支持range请求
+		var content *rangeContent
+		if config.EnableRange {
+			content = newRangeContent(fileBuf, r)
+		}
+		if content != nil {
+			c.SetHeader(HeaderAcceptRanges, "bytes")
 		} else {
-			r, e := staticFile.NewReader(file)
-			if e != nil {
-				err = getStaticServeError(e.Error(), http.StatusBadRequest)
+			// 不支持range，避免客户端发送range请求
+			c.SetHeader(HeaderAcceptRanges, "none")
+		}
+		if head {
+			closeReader(r)
+			size := int64(-1)
+			if content != nil {
+				size = content.size
+			} else if fileBuf != nil {
+				size = int64(len(fileBuf))
+			} else if fileInfo != nil {
+				size = fileInfo.Size()
+			}
+			// 需要转换或压缩的数据长度未知
+			if size >= 0 && !transformable && compressor == nil {
+				c.SetHeader(elton.HeaderContentLength, strconv.FormatInt(size, 10))
+			}
+			return c.Next()
+		}
+		served := false
+		rangeHeader := c.GetRequestHeader(HeaderRange)
+		// If-Range不匹配时（文件已修改）则返回完整数据
+		if content != nil && rangeHeader != "" && c.Request.Method == http.MethodGet && checkIfRange(c.Request.Header, c.Headers) {
+			served, err = content.serve(c, rangeHeader)
+			if err != nil {
 				return
 			}
-			c.Body = r
+		}
+		if !served {
+			if fileBuf != nil {
+				c.BodyBuffer = bytes.NewBuffer(fileBuf)
+			} else {
+				// 支持seek的数据（或*os.File）设置准确的Content-Length，net/http在长度已知时才可使用sendfile
+				if content != nil {
+					c.SetHeader(elton.HeaderContentLength, strconv.FormatInt(content.size, 10))
+				} else if size, ok := getReaderSize(r); ok {
+					c.SetHeader(elton.HeaderContentLength, strconv.FormatInt(size, 10))
+				}
+				c.Body = r
+			}
+		}
+		if config.RateLimit > 0 {
+			throttle(c, config.RateLimit, config.RateLimitBurst)
 		}
 		return c.Next()
-	}
+	}, nil
+}
+
+// GetNotFoundFile get the requested file(relative path of the static directory)
+// in NotFoundHandler, it returns empty string if not found
+func GetNotFoundFile(c *elton.Context) string {
+	return c.GetString(NotFoundFileKey)
 }
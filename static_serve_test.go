@@ -2,17 +2,28 @@ package staticserve
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vicanso/elton"
+	"github.com/vicanso/hes"
 )
 
 const (
@@ -41,6 +52,9 @@ func (m *MockStaticFile) Get(file string) ([]byte, error) {
 }
 
 func (m *MockStaticFile) Stat(file string) os.FileInfo {
+	if !m.Exists(file) {
+		return nil
+	}
 	return &MockFileStat{}
 }
 
@@ -78,10 +92,151 @@ func (mf *MockFileStat) Sys() interface{} {
 	return nil
 }
 
+func TestGetWeakETag(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`W/"400-5cfb1ad2"`, getWeakETag(1024, 1559960274))
+	assert.Equal(`W/"0-0"`, getWeakETag(0, 0))
+}
+
 func TestGenerateETag(t *testing.T) {
 	assert := assert.New(t)
 	assert.Equal(generateETag([]byte("")), `"0-2jmj7l5rSw0yVb_vlWAYkK_YBwk="`)
 	assert.Equal(generateETag([]byte("abc")), `"3-qZk-NkcGgWq6PiVxeFDCbJzQ2J0="`)
+	assert.Equal(generateETag([]byte("abc")), generateETagWithHasher([]byte("abc"), SHA1ETagHasher))
+	assert.Equal(`"3-ungWv48Bz-pBQUDeXa4iI7ADYaOWF3qctBD_YfIAFa0="`, generateETagWithHasher([]byte("abc"), SHA256ETagHasher))
+	assert.Equal(`"3-364b3fb7"`, generateETagWithHasher([]byte("abc"), CRC32CETagHasher))
+	assert.Equal(`"0-00000000"`, generateETagWithHasher([]byte(""), CRC32CETagHasher))
+
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:             staticPath,
+		EnableStrongETag: true,
+		ETagHasher:       CRC32CETagHasher,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(generateETagWithHasher([]byte("<html>xxx</html>"), CRC32CETagHasher), resp.Header().Get(elton.HeaderETag))
+}
+
+func TestIsDotAllowed(t *testing.T) {
+	assert := assert.New(t)
+	allows := []string{
+		"/.well-known/acme-challenge/",
+		"/.well-known/*.txt",
+	}
+	assert.True(isDotAllowed("/.well-known/acme-challenge/token", allows))
+	assert.True(isDotAllowed("/.well-known/security.txt", allows))
+	assert.False(isDotAllowed("/.well-known/config.json", allows))
+	assert.False(isDotAllowed("/.git/config", allows))
+	assert.False(isDotAllowed("/.well-known/acme-challenge/../../.git/config", allows))
+	assert.False(isDotAllowed("/.well-known/security.txt", nil))
+}
+
+func TestGetCacheControl(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(getCacheControl(&Config{}))
+	assert.Equal("public, max-age=60, s-maxage=10", getCacheControl(&Config{
+		MaxAge:  60,
+		SMaxAge: 10,
+	}))
+	assert.Equal("private", getCacheControl(&Config{
+		Private: true,
+	}))
+	assert.Equal("private, max-age=60", getCacheControl(&Config{
+		Private: true,
+		MaxAge:  60,
+	}))
+	assert.Equal("no-cache", getCacheControl(&Config{
+		MaxAge:       60,
+		CacheControl: "no-cache",
+	}))
+	assert.Equal("public, max-age=31536000, immutable", getCacheControl(&Config{
+		MaxAge:    31536000,
+		Immutable: true,
+	}))
+	assert.Equal("public, max-age=60, s-maxage=600, stale-while-revalidate=30, stale-if-error=86400", getCacheControl(&Config{
+		MaxAge:               60,
+		SMaxAge:              600,
+		StaleWhileRevalidate: 30,
+		StaleIfError:         86400,
+	}))
+	assert.Equal("private, max-age=60", getCacheControl(&Config{
+		CacheControlMode: CacheControlPrivate,
+		MaxAge:           60,
+	}))
+	assert.Equal("no-cache", getCacheControl(&Config{
+		CacheControlMode: CacheControlNoCache,
+		MaxAge:           60,
+	}))
+	assert.Equal("no-store", getCacheControl(&Config{
+		CacheControlMode: CacheControlNoStore,
+	}))
+	assert.Equal("public, max-age=60", getCacheControl(&Config{
+		CacheControlMode: CacheControlPublic,
+		MaxAge:           60,
+	}))
+}
+
+func TestAppendCharset(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("text/html; charset=utf-8", appendCharset("text/html", "utf-8"))
+	assert.Equal("text/html; charset=gbk", appendCharset("text/html; charset=gbk", "utf-8"))
+	assert.Equal("application/json; charset=utf-8", appendCharset("application/json", "utf-8"))
+	assert.Equal("image/png", appendCharset("image/png", "utf-8"))
+}
+
+func TestStripPathPrefix(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("/index.html", stripPathPrefix("/assets/index.html", "/assets"))
+	assert.Equal("/index.html", stripPathPrefix("/assets/index.html", "/assets/"))
+	assert.Equal("", stripPathPrefix("/assets", "/assets"))
+	assert.Equal("/assetsx/index.html", stripPathPrefix("/assetsx/index.html", "/assets"))
+	assert.Equal("/index.html", stripPathPrefix("/index.html", "/assets"))
+}
+
+func TestIsQueryAllowed(t *testing.T) {
+	assert := assert.New(t)
+	keys := map[string]bool{
+		"v": true,
+	}
+	assert.True(isQueryAllowed("v=1", keys))
+	assert.True(isQueryAllowed("v=1&v=2", keys))
+	assert.False(isQueryAllowed("v=1&a=2", keys))
+	assert.False(isQueryAllowed("%zz", keys))
+	assert.False(isQueryAllowed("v=1", nil))
+}
+
+func TestStripQuery(t *testing.T) {
+	assert := assert.New(t)
+	keys := map[string]bool{
+		"v": true,
+	}
+	query, stripped := stripQuery("v=1", keys)
+	assert.False(stripped)
+	assert.Equal("v=1", query)
+
+	query, stripped = stripQuery("_=1634567890&v=1&cb=abc", keys)
+	assert.True(stripped)
+	assert.Equal("v=1", query)
+
+	query, stripped = stripQuery("_=1634567890", nil)
+	assert.True(stripped)
+	assert.Empty(query)
+
+	query, stripped = stripQuery("%zz", keys)
+	assert.True(stripped)
+	assert.Empty(query)
+}
+
+func TestIsContentTypeMatched(t *testing.T) {
+	assert := assert.New(t)
+	contentTypes := []string{
+		"text/html",
+	}
+	assert.True(isContentTypeMatched("text/html; charset=utf-8", contentTypes))
+	assert.True(isContentTypeMatched("TEXT/HTML", contentTypes))
+	assert.False(isContentTypeMatched("image/png", contentTypes))
+	assert.False(isContentTypeMatched("", contentTypes))
 }
 
 func TestFS(t *testing.T) {
@@ -98,40 +253,1535 @@ func TestFS(t *testing.T) {
 		buf, err := fs.Get(file)
 		assert.Nil(err)
 		assert.NotEmpty(buf)
+
+		var dirLister DirLister = &fs
+		infos, err := dirLister.ReadDir(filepath.Dir(file))
+		assert.Nil(err)
+		found := false
+		for _, info := range infos {
+			if info.Name() == filepath.Base(file) {
+				found = true
+			}
+		}
+		assert.True(found)
+
+		_, err = fs.ReadDir("/not-exists-dir")
+		assert.NotNil(err)
+
+		var rsf ReadSeekerFile = &fs
+		rs, err := rsf.NewReadSeeker(file)
+		assert.Nil(err)
+		// 返回*os.File，可使用sendfile
+		f, ok := rs.(*os.File)
+		assert.True(ok)
+		assert.Nil(f.Close())
+	})
+
+	t.Run("out of path", func(t *testing.T) {
+		assert := assert.New(t)
+		tfs := FS{}
+
+		assert.Nil(tfs.Stat("/b"), "out of path should return nil stat")
+		assert.False(tfs.Exists("/b"), "file should be not exists")
+	})
+}
+func TestStrictRoot(t *testing.T) {
+	assert := assert.New(t)
+	assert.NotNil(NewDefault(Config{
+		Path:       os.TempDir(),
+		StrictRoot: true,
+	}))
+
+	for _, root := range []string{
+		"/not-exists-dir",
+		os.Args[0],
+	} {
+		func() {
+			defer func() {
+				assert.Equal(ErrRootInvalid, recover())
+			}()
+			NewDefault(Config{
+				Path:       root,
+				StrictRoot: true,
+			})
+		}()
+	}
+}
+
+func TestServeEscapedPath(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(root, "a b.txt"), []byte("space"), 0600))
+	assert.Nil(os.WriteFile(filepath.Join(root, "a%20b.txt"), []byte("escaped"), 0600))
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path: root,
+	}))
+
+	// 路由参数已解码，不再重复解码
+	for url, body := range map[string]string{
+		"/a%20b.txt":   "space",
+		"/a%2520b.txt": "escaped",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(body, resp.Body.String())
+	}
+}
+
+func TestServeFSIndex(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	err := os.Mkdir(filepath.Join(root, "docs"), 0755)
+	assert.Nil(err)
+	err = ioutil.WriteFile(filepath.Join(root, "docs", "index.html"), []byte("docs"), 0644)
+	assert.Nil(err)
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path: root,
+	}))
+	for _, url := range []string{
+		"/docs/",
+		"/docs",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("docs", resp.Body.String())
+	}
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(404, resp.Code)
+}
+
+func TestServeRedirectDirSlash(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	err := os.Mkdir(filepath.Join(root, "docs"), 0755)
+	assert.Nil(err)
+	err = ioutil.WriteFile(filepath.Join(root, "docs", "index.html"), []byte("docs"), 0644)
+	assert.Nil(err)
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:             root,
+		RedirectDirSlash: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs?a=1", nil))
+	assert.Equal(301, resp.Code)
+	assert.Equal("/docs/?a=1", resp.Header().Get(elton.HeaderLocation))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("docs", resp.Body.String())
+}
+
+func TestServeRedirectIndex(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	err := os.Mkdir(filepath.Join(root, "docs"), 0755)
+	assert.Nil(err)
+	for _, file := range []string{
+		"docs/index.html",
+		"docs/index.htm",
+	} {
+		err = ioutil.WriteFile(filepath.Join(root, file), []byte(file), 0644)
+		assert.Nil(err)
+	}
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:          root,
+		RedirectIndex: true,
+		Index: []string{
+			"index.html",
+			"index.htm",
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/index.html?a=1", nil))
+	assert.Equal(301, resp.Code)
+	assert.Equal("/docs/?a=1", resp.Header().Get(elton.HeaderLocation))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("docs/index.html", resp.Body.String())
+
+	// 非目录对应的index文件，不重定向
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/docs/index.htm", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("docs/index.htm", resp.Body.String())
+
+	// 不能重定向至其它域名
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "index.html"), []byte("index.html"), 0644))
+	for url, location := range map[string]string{
+		"//evil.com/..%2findex.html":                 "/",
+		"///evil.com/docs/..%2f..%2fdocs/index.html": "/docs/",
+	} {
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(301, resp.Code, url)
+		assert.Equal(location, resp.Header().Get(elton.HeaderLocation), url)
+	}
+}
+
+func TestServeCleanURLs(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	err := os.Mkdir(filepath.Join(root, "blog"), 0755)
+	assert.Nil(err)
+	for file, content := range map[string]string{
+		"about.html":      "about",
+		"blog/index.html": "blog",
+	} {
+		err = ioutil.WriteFile(filepath.Join(root, file), []byte(content), 0644)
+		assert.Nil(err)
+	}
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:      root,
+		CleanURLs: true,
+	}))
+	for url, content := range map[string]string{
+		"/about": "about",
+		"/blog":  "blog",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal(content, resp.Body.String())
+	}
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/contact", nil))
+	assert.Equal(404, resp.Code)
+
+	// 未启用
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path: root,
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/about", nil))
+	assert.Equal(404, resp.Code)
+}
+
+func TestServeCleanURLsRules(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	for file, content := range map[string]string{
+		"about.html":  "about",
+		"report.pdf":  "report",
+		"notes.txt":   "notes",
+		"a.meta.json": "{}",
+	} {
+		assert.Nil(ioutil.WriteFile(filepath.Join(root, file), []byte(content), 0644))
+	}
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:           root,
+		CleanURLs:      true,
+		TryExtensions:  []string{".pdf", ".txt", ".meta.json"},
+		EnableFileMeta: true,
+		IPRules: []IPRule{
+			{
+				Pattern: "*.pdf",
+				Allow:   []string{"10.0.0.0/8"},
+			},
+		},
+		SignedURL: &SignedURLConfig{
+			Secret:   []byte("secret"),
+			Patterns: []string{"*.html"},
+		},
+	}))
+	// 添加扩展名后的文件也需要满足访问规则
+	for url, code := range map[string]int{
+		"/about":  403,
+		"/report": 403,
+		"/notes":  200,
+		"/a":      404,
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(code, resp.Code, url)
+	}
+}
+
+func TestServeIndexFallbackRules(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.Mkdir(filepath.Join(root, "admin"), 0755))
+	for file, content := range map[string]string{
+		"app.html":         "app",
+		"admin/index.html": "admin",
+		"index.meta.json":  "{}",
+	} {
+		assert.Nil(ioutil.WriteFile(filepath.Join(root, file), []byte(content), 0644))
+	}
+
+	ipRules := []IPRule{
+		{
+			Pattern: "*.html",
+			Allow:   []string{"10.0.0.0/8"},
+		},
+	}
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:     root,
+		Fallback: "/app.html",
+		IPRules:  ipRules,
+	}))
+	// index与fallback文件也需要满足访问规则
+	for _, url := range []string{"/admin/", "/users/1"} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		assert.Equal(403, resp.Code, url)
+	}
+
+	// index文件为元数据文件时不可访问
+	e = elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:           root,
+		Index:          []string{"index.meta.json"},
+		EnableFileMeta: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(404, resp.Code)
+}
+
+type slowStaticFile struct {
+	MockStaticFile
+	getCount int32
+}
+
+func (sf *slowStaticFile) Get(file string) ([]byte, error) {
+	atomic.AddInt32(&sf.getCount, 1)
+	time.Sleep(50 * time.Millisecond)
+	return sf.MockStaticFile.Get(file)
+}
+
+func TestServeImmutableFingerprint(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:                 staticPath,
+		MaxAge:               60,
+		ImmutableFingerprint: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/app.3f2a9c1d.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("public, max-age=31536000, immutable", resp.Header().Get(elton.HeaderCacheControl))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/app.js", nil))
+	assert.Equal("public, max-age=60", resp.Header().Get(elton.HeaderCacheControl))
+
+	// 自定义的正则（如vite生成的文件名）
+	e = elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:                 staticPath,
+		ImmutableFingerprint: true,
+		FingerprintPattern:   regexp.MustCompile(`-[0-9a-zA-Z_]{8}\.`),
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/assets/index-BdX3k2aP.js", nil))
+	assert.Equal("public, max-age=31536000, immutable", resp.Header().Get(elton.HeaderCacheControl))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/app.3f2a9c1d.js", nil))
+	assert.Empty(resp.Header().Get(elton.HeaderCacheControl))
+}
+
+func TestServeExpires(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:                 staticPath,
+		MaxAge:               60,
+		EnableExpires:        true,
+		ImmutableFingerprint: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	expires, err := http.ParseTime(resp.Header().Get(HeaderExpires))
+	assert.Nil(err)
+	assert.True(expires.After(time.Now().Add(50 * time.Second)))
+	assert.True(expires.Before(time.Now().Add(70 * time.Second)))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.3f2a9c1d.js", nil))
+	expires, err = http.ParseTime(resp.Header().Get(HeaderExpires))
+	assert.Nil(err)
+	assert.True(expires.After(time.Now().Add(364 * 24 * time.Hour)))
+
+	// 自定义Cache-Control则不设置
+	e = elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:          staticPath,
+		MaxAge:        60,
+		CacheControl:  "no-cache",
+		EnableExpires: true,
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Empty(resp.Header().Get(HeaderExpires))
+}
+
+func TestServeVary(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data: []byte("console.log('app')"),
+		},
+		"app.js.gz": &fstest.MapFile{
+			Data: []byte("gzip data"),
+		},
+	})
+	e := elton.New()
+	// 其它中间件设置的Vary
+	e.Use(func(c *elton.Context) error {
+		c.SetHeader(HeaderVary, "Cookie")
+		return c.Next()
+	})
+	e.GET("/*file", New(sf, Config{
+		Precompressed: []string{"gzip"},
+		Header: map[string]string{
+			"vary": "Origin",
+		},
+		Vary: []string{"Accept"},
+	}))
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("Cookie, Accept-Encoding, Origin, Accept", resp.Header().Get(HeaderVary))
+}
+
+func TestServeHeaderRules(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		Header: map[string]string{
+			"X-Server": "static",
+			"X-Type":   "file",
+		},
+		HeaderRules: []HeaderRule{
+			{
+				Pattern: "*.html",
+				Header: map[string]string{
+					"X-Type": "html",
+					"Vary":   "Cookie",
+				},
+			},
+		},
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("static", resp.Header().Get("X-Server"))
+	assert.Equal("html", resp.Header().Get("X-Type"))
+	assert.Equal("Cookie", resp.Header().Get(HeaderVary))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("file", resp.Header().Get("X-Type"))
+	assert.Empty(resp.Header().Get(HeaderVary))
+}
+
+func TestServeRewrite(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		RewriteRules: []RewriteRule{
+			{
+				Regexp:      regexp.MustCompile(`^/v2/(.*)$`),
+				Replacement: "/$1",
+			},
+		},
+		Rewrite: func(file string) string {
+			return strings.TrimPrefix(file, "/en")
+		},
+	}))
+
+	for _, file := range []string{
+		"/v2/index.html",
+		"/en/index.html",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+	}
+
+	// 重写后的路径越过Path
+	e = elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		Rewrite: func(file string) string {
+			return "/../" + file
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(400, resp.Code)
+}
+
+func TestServeErrorHandler(t *testing.T) {
+	assert := assert.New(t)
+	var onErrors []error
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		ErrorHandler: func(c *elton.Context, err error) error {
+			if err == ErrNotFound {
+				c.StatusCode = http.StatusNotFound
+				c.SetContentTypeByExt(".html")
+				c.BodyBuffer = bytes.NewBufferString("<html>not found</html>")
+				return nil
+			}
+			return err
+		},
+		OnError: func(c *elton.Context, info ServeInfo, err error) {
+			onErrors = append(onErrors, err)
+		},
+	}), func(c *elton.Context) error {
+		return errors.New("next error")
+	})
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.html", nil))
+	assert.Equal(404, resp.Code)
+	assert.Equal("<html>not found</html>", resp.Body.String())
+	assert.Equal([]error{ErrNotFound}, onErrors)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/../index.html", nil))
+	assert.Equal(400, resp.Code)
+
+	// next之后的出错不处理
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(500, resp.Code)
+	assert.Contains(resp.Body.String(), "next error")
+}
+
+func TestServeAuthorize(t *testing.T) {
+	assert := assert.New(t)
+	files := make([]string, 0)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		Authorize: func(c *elton.Context, file string) error {
+			files = append(files, file)
+			if strings.HasPrefix(file, "/private/") && c.GetRequestHeader("Authorization") == "" {
+				return hes.NewWithStatusCode("unauthorized", 401)
+			}
+			return nil
+		},
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/private/report.pdf", nil))
+	assert.Equal(401, resp.Code)
+
+	req := httptest.NewRequest("GET", "/private/report.pdf", nil)
+	req.Header.Set("Authorization", "token")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal([]string{
+		"/private/report.pdf",
+		"/private/report.pdf",
+		"/index.html",
+	}, files)
+}
+
+type streamStaticFile struct {
+	MockStaticFile
+}
+type notSeekStaticFile struct {
+	streamStaticFile
+}
+type contextStaticFile struct {
+	MockStaticFile
+	ctx context.Context
+}
+
+func (m *contextStaticFile) NewReaderContext(ctx context.Context, file string) (io.Reader, error) {
+	m.ctx = ctx
+	return m.NewReader(file)
+}
+
+func (m *streamStaticFile) NewReader(file string) (io.Reader, error) {
+	buf, err := m.Get(file)
+	if err != nil {
+		return nil, err
+	}
+	return &notSeekReader{
+		Reader: bytes.NewReader(buf),
+	}, nil
+}
+
+func (m *notSeekStaticFile) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	if file == staticPath+"/stream.txt" {
+		return nil, ErrNotSeekable
+	}
+	buf, err := m.Get(file)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
+
+func TestNewReader(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	csf := &contextStaticFile{}
+	r, err := newReader(ctx, csf, staticPath+"/index.html")
+	assert.Nil(err)
+	assert.NotNil(r)
+	assert.Equal(ctx, csf.ctx)
+
+	// 支持seek的reader不处理
+	r, err = newReader(ctx, &MockStaticFile{}, staticPath+"/index.html")
+	assert.Nil(err)
+	_, ok := r.(io.ReadSeeker)
+	assert.True(ok)
+
+	r, err = newReader(ctx, &streamStaticFile{}, staticPath+"/index.html")
+	assert.Nil(err)
+	p := make([]byte, 6)
+	n, err := r.Read(p)
+	assert.Nil(err)
+	assert.Equal("<html>", string(p[:n]))
+	cancel()
+	_, err = r.Read(p)
+	assert.Equal(context.Canceled, err)
+
+	_, err = newReader(ctx, &streamStaticFile{}, staticPath+"/error")
+	assert.NotNil(err)
+}
+
+func TestServeReadSeeker(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&notSeekStaticFile{}, Config{
+		Path:        staticPath,
+		EnableRange: true,
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("16", resp.Header().Get(elton.HeaderContentLength))
+	assert.Equal("bytes", resp.Header().Get(HeaderAcceptRanges))
+
+	req := httptest.NewRequest("GET", "/index.html", nil)
+	req.Header.Set(HeaderRange, "bytes=0-5")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(206, resp.Code)
+	assert.Equal("<html>", resp.Body.String())
+
+	// 不支持seek时使用NewReader
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/stream.txt", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("abcd", resp.Body.String())
+	assert.Empty(resp.Header().Get(elton.HeaderContentLength))
+	assert.Equal("none", resp.Header().Get(HeaderAcceptRanges))
+}
+
+func TestServeMaxConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:           staticPath,
+		MaxConcurrency: 1,
+		RetryAfter:     1500 * time.Millisecond,
+	}))
+	var r io.ReadCloser
+	e.Use(func(c *elton.Context) error {
+		err := c.Next()
+		// 保存reader，模拟仍在返回数据
+		if closer, ok := c.Body.(io.ReadCloser); ok && r == nil {
+			r = closer
+			c.Body = nil
+		}
+		return err
+	})
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.NotNil(r)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(503, resp.Code)
+	assert.Equal("2", resp.Header().Get(HeaderRetryAfter))
+
+	assert.Nil(r.Close())
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+}
+
+func TestServeRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:           staticPath,
+		RateLimit:      20,
+		RateLimitBurst: 10,
+	}))
+	start := time.Now()
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("<html>xxx</html>", resp.Body.String())
+	// 16字节，首次10字节，余下的6字节需要0.3秒
+	assert.True(time.Since(start) >= 250*time.Millisecond)
+}
+
+func TestServeIPRules(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		IPRules: []IPRule{
+			{
+				Pattern: "/internal/",
+				Allow:   []string{"10.0.0.0/8"},
+			},
+		},
+	}))
+	fn := func(url, remoteAddr string) int {
+		req := httptest.NewRequest("GET", url, nil)
+		req.RemoteAddr = remoteAddr
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	assert.Equal(403, fn("/internal/index.html", "1.1.1.1:1234"))
+	assert.Equal(200, fn("/internal/index.html", "10.0.0.1:1234"))
+	assert.Equal(200, fn("/index.html", "1.1.1.1:1234"))
+
+	// 使用代理转发的ip
+	e = elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		IPRules: []IPRule{
+			{
+				Pattern: "/internal/",
+				Allow:   []string{"10.0.0.0/8"},
+			},
+		},
+		ClientIP: func(c *elton.Context) string {
+			return c.RealIP()
+		},
+	}))
+	req := httptest.NewRequest("GET", "/internal/index.html", nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+
+	assert.Panics(func() {
+		New(&MockStaticFile{}, Config{
+			IPRules: []IPRule{
+				{
+					Allow: []string{"abc"},
+				},
+			},
+		})
+	})
+}
+
+func TestServeSignedURL(t *testing.T) {
+	assert := assert.New(t)
+	signed := &SignedURLConfig{
+		Secret:   []byte("secret"),
+		Patterns: []string{"/private/"},
+	}
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:            staticPath,
+		DenyQueryString: true,
+		SignedURL:       signed,
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/private/report.pdf", nil))
+	assert.Equal(403, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", signed.Sign("/private/report.pdf", time.Now().Add(time.Minute)), nil))
+	assert.Equal(200, resp.Code)
+
+	// 签名不影响其它query的限制
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", signed.Sign("/private/report.pdf", time.Now().Add(time.Minute))+"&a=1", nil))
+	assert.Equal(400, resp.Code)
+
+	// 无需签名的文件
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+}
+
+func TestServeHotlink(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:   staticPath,
+		MaxAge: 3600,
+		Hotlink: &HotlinkConfig{
+			AllowHosts: []string{"example.com"},
+		},
+	}))
+	fn := func(url, referer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if referer != "" {
+			req.Header.Set(HeaderReferer, referer)
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := fn("/banner.jpg", "https://a.com/")
+	assert.Equal(403, resp.Code)
+
+	resp = fn("/banner.jpg", "")
+	assert.Equal(403, resp.Code)
+
+	resp = fn("/banner.jpg", "https://example.com/")
+	assert.Equal(200, resp.Code)
+	assert.Equal("image data", resp.Body.String())
+
+	// 非防盗链的文件
+	resp = fn("/index.html", "https://a.com/")
+	assert.Equal(200, resp.Code)
+
+	e = elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:   staticPath,
+		MaxAge: 3600,
+		Hotlink: &HotlinkConfig{
+			Placeholder: "hotlink.png",
+		},
+	}))
+	resp = fn("/banner.jpg", "https://a.com/")
+	assert.Equal(200, resp.Code)
+	assert.Equal("abcd", resp.Body.String())
+	assert.Equal("image/png", resp.Header().Get(elton.HeaderContentType))
+	assert.Equal("no-cache", resp.Header().Get(elton.HeaderCacheControl))
+}
+
+func TestServePreload(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		Preload: map[string][]string{
+			"/index.html": {
+				"/app.css",
+				"/app.js",
+			},
+		},
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("</app.css>; rel=preload; as=style, </app.js>; rel=preload; as=script", resp.Header().Get(HeaderLink))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Empty(resp.Header().Get(HeaderLink))
+}
+
+func TestServeEarlyHints(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:       staticPath,
+		EarlyHints: true,
+		Preload: map[string][]string{
+			"/index.html": {
+				"/app.css",
+			},
+		},
+	}))
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	earlyHints := make([]string, 0)
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				earlyHints = append(earlyHints, header.Get(HeaderLink))
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequest("GET", server.URL+"/index.html", nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(err)
+	defer resp.Body.Close()
+	assert.Equal(200, resp.StatusCode)
+	assert.Equal("</app.css>; rel=preload; as=style", resp.Header.Get(HeaderLink))
+	if earlyHintsSupported {
+		assert.Equal([]string{
+			"</app.css>; rel=preload; as=style",
+		}, earlyHints)
+	} else {
+		assert.Empty(earlyHints)
+	}
+}
+
+func TestServeCORS(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	fn := New(&MockStaticFile{}, Config{
+		Path:    staticPath,
+		Methods: []string{"GET", "HEAD"},
+		CORS: &CORSConfig{
+			AllowOrigins: []string{"https://a.com"},
+			Patterns:     []string{"*.js"},
+		},
+	})
+	e.GET("/*file", fn)
+	e.OPTIONS("/*file", fn)
+
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("https://a.com", resp.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Contains(resp.Header().Get(HeaderVary), HeaderOrigin)
+
+	req = httptest.NewRequest("GET", "/index.html", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Empty(resp.Header().Get(HeaderAccessControlAllowOrigin))
+
+	// 预检请求不受Methods限制
+	req = httptest.NewRequest("OPTIONS", "/app.js", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	req.Header.Set(HeaderAccessControlRequestMethod, "GET")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(204, resp.Code)
+	assert.Equal("https://a.com", resp.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Equal("GET, HEAD", resp.Header().Get(HeaderAccessControlAllowMethods))
+
+	// 非预检的OPTIONS请求
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("OPTIONS", "/app.js", nil))
+	assert.Equal(405, resp.Code)
+}
+
+func TestServeOptions(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.ALL("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		CORS: &CORSConfig{
+			AllowOrigins: []string{"https://a.com"},
+		},
+	}))
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("OPTIONS", "/notfound.js", nil))
+	assert.Equal(204, resp.Code)
+	assert.Equal("GET, HEAD, OPTIONS", resp.Header().Get(HeaderAllow))
+	assert.Empty(resp.Header().Get(HeaderAccessControlAllowOrigin))
+
+	req := httptest.NewRequest("OPTIONS", "/app.js", nil)
+	req.Header.Set(HeaderOrigin, "https://a.com")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(204, resp.Code)
+	assert.Equal("GET, HEAD, OPTIONS", resp.Header().Get(HeaderAllow))
+	assert.Equal("https://a.com", resp.Header().Get(HeaderAccessControlAllowOrigin))
+
+	e = elton.New()
+	e.ALL("/*file", New(&MockStaticFile{}, Config{
+		Path:    staticPath,
+		Methods: []string{"GET", "OPTIONS"},
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("OPTIONS", "/app.js", nil))
+	assert.Equal(204, resp.Code)
+	assert.Equal("GET, OPTIONS", resp.Header().Get(HeaderAllow))
+}
+
+func TestServeSecurityHeaders(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:                  staticPath,
+		EnableSecurityHeaders: true,
+		SecurityHeaders: SecurityHeaders{
+			FrameOptions: "-",
+		},
+		Header: map[string]string{
+			HeaderReferrerPolicy: "no-referrer",
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("nosniff", resp.Header().Get(HeaderXContentTypeOptions))
+	assert.Empty(resp.Header().Get(HeaderXFrameOptions))
+	// Header的配置优先
+	assert.Equal("no-referrer", resp.Header().Get(HeaderReferrerPolicy))
+}
+
+// statCountFile count the stat calls of static file
+type statCountFile struct {
+	MockStaticFile
+	count       int32
+	existsCount int32
+}
+
+func (f *statCountFile) Exists(file string) bool {
+	atomic.AddInt32(&f.existsCount, 1)
+	return f.MockStaticFile.Exists(file)
+}
+
+func (f *statCountFile) Stat(file string) os.FileInfo {
+	atomic.AddInt32(&f.count, 1)
+	return f.MockStaticFile.Stat(file)
+}
+
+func TestServeSingleStat(t *testing.T) {
+	assert := assert.New(t)
+	sf := &statCountFile{}
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Path:              staticPath,
+		EnableStrongETag:  true,
+		ETagCacheSize:     10,
+		ContentCacheSize:  10 * 1024,
+		CompressCacheSize: 10 * 1024,
+		MaxFileSize:       10 * 1024,
+		Compressors:       []Compressor{NewGzipCompressor(0)},
+		SetHeaders: func(c *elton.Context, path string, info os.FileInfo) {
+			assert.NotNil(info)
+		},
+	}))
+	req := httptest.NewRequest("GET", "/index.html", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(int32(1), atomic.LoadInt32(&sf.count))
+	// 已获取文件信息，无需调用Exists
+	assert.Equal(int32(0), atomic.LoadInt32(&sf.existsCount))
+}
+
+func TestServeHeaderNotShared(t *testing.T) {
+	assert := assert.New(t)
+	fn := New(&MockStaticFile{}, Config{
+		Path:   staticPath,
+		MaxAge: 60,
+	})
+	serve := func() *elton.Context {
+		c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/index.html", nil))
+		c.Next = func() error {
+			return nil
+		}
+		assert.Nil(fn(c))
+		return c
+	}
+	// 其它中间件修改响应头不影响后续的请求
+	c := serve()
+	c.Headers[elton.HeaderCacheControl][0] = "no-store"
+	c.Headers[elton.HeaderContentType][0] = "text/plain"
+
+	c = serve()
+	assert.Equal("public, max-age=60", c.GetHeader(elton.HeaderCacheControl))
+	assert.Equal("text/html; charset=utf-8", c.GetHeader(elton.HeaderContentType))
+}
+
+func TestServeCrossOriginIsolation(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:                 staticPath,
+		CrossOriginIsolation: true,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("same-origin", resp.Header().Get(HeaderCrossOriginOpenerPolicy))
+	assert.Equal("require-corp", resp.Header().Get(HeaderCrossOriginEmbedderPolicy))
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.wasm", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("application/wasm", resp.Header().Get(elton.HeaderContentType))
+	assert.Empty(resp.Header().Get(HeaderCrossOriginOpenerPolicy))
+	assert.Empty(resp.Header().Get(HeaderCrossOriginEmbedderPolicy))
+}
+
+func TestServeReadSingleflight(t *testing.T) {
+	assert := assert.New(t)
+	sf := &slowStaticFile{}
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Path:             staticPath,
+		EnableStrongETag: true,
+	}))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+			assert.Equal(200, resp.Code)
+			assert.Equal(generateETag([]byte("<html>xxx</html>")), resp.Header().Get(elton.HeaderETag))
+		}()
+	}
+	wg.Wait()
+	// 并发的读取合并为一次
+	assert.Equal(int32(1), atomic.LoadInt32(&sf.getCount))
+}
+
+func TestStaticServe(t *testing.T) {
+	staticFile := &MockStaticFile{}
+	t.Run("not allow query string", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:            staticPath,
+			DenyQueryString: true,
+		})
+		req := httptest.NewRequest("GET", "/index.html?a=1", nil)
+		c := elton.NewContext(nil, req)
+		err := fn(c)
+		assert.Equal(err, ErrNotAllowQueryString, "should return not allow query string error")
+	})
+
+	t.Run("allow query keys", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:            staticPath,
+			DenyQueryString: true,
+			AllowQueryKeys: []string{
+				"v",
+				"hash",
+			},
+		})
+		for _, url := range []string{
+			"/index.html?v=abc",
+			"/index.html?v=1.2.3&hash=3f2a9c1d",
+		} {
+			req := httptest.NewRequest("GET", url, nil)
+			c := elton.NewContext(httptest.NewRecorder(), req)
+			c.Next = func() error {
+				return nil
+			}
+			err := fn(c)
+			assert.Nil(err, url)
+		}
+
+		req := httptest.NewRequest("GET", "/index.html?v=abc&a=1", nil)
+		c := elton.NewContext(nil, req)
+		err := fn(c)
+		assert.Equal(ErrNotAllowQueryString, err)
+	})
+
+	t.Run("strip query string", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:                   staticPath,
+			MaxAge:                 3600,
+			DenyQueryString:        true,
+			StripQueryString:       true,
+			StripQueryCacheControl: "public, max-age=60",
+			AllowQueryKeys: []string{
+				"v",
+			},
+		})
+		req := httptest.NewRequest("GET", "/index.html?v=abc&_=1634567890", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+		assert.Equal("v=abc", req.URL.RawQuery)
+		assert.Equal("public, max-age=60", c.GetHeader(elton.HeaderCacheControl))
+
+		req = httptest.NewRequest("GET", "/index.html?v=abc", nil)
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Nil(err)
+		assert.Equal("public, max-age=3600", c.GetHeader(elton.HeaderCacheControl))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path: staticPath,
+			Methods: []string{
+				"GET",
+				"head",
+			},
+		})
+		e := elton.New()
+		e.ALL("/*file", fn)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("POST", "/index.html", nil))
+		assert.Equal(405, resp.Code)
+		assert.Equal("GET, HEAD", resp.Header().Get(HeaderAllow))
+
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("HEAD", "/index.html", nil))
+		assert.Equal(200, resp.Code)
+	})
+
+	t.Run("not allow dot file", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:    staticPath,
+			DenyDot: true,
+		})
+		req := httptest.NewRequest("GET", "/.index.html", nil)
+		c := elton.NewContext(nil, req)
+		err := fn(c)
+		assert.Equal(err, ErrNotAllowAccessDot, "should return not allow dot error")
+	})
+
+	t.Run("allow dot file", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:    staticPath,
+			DenyDot: true,
+			DotAllow: []string{
+				"/.well-known/",
+			},
+		})
+		req := httptest.NewRequest("GET", "/.well-known/security.txt", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+
+		req = httptest.NewRequest("GET", "/.git/config", nil)
+		c = elton.NewContext(nil, req)
+		err = fn(c)
+		assert.Equal(ErrNotAllowAccessDot, err)
+	})
+
+	t.Run("allow dot segment", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:    staticPath,
+			DenyDot: true,
+		})
+		// 默认允许.well-known
+		req := httptest.NewRequest("GET", "/.well-known/acme-challenge/token", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+
+		req = httptest.NewRequest("GET", "/.well-known/.git/config", nil)
+		c = elton.NewContext(nil, req)
+		err = fn(c)
+		assert.Equal(ErrNotAllowAccessDot, err)
+
+		fn = New(staticFile, Config{
+			Path:            staticPath,
+			DenyDot:         true,
+			DotSegmentAllow: []string{},
+		})
+		req = httptest.NewRequest("GET", "/.well-known/acme-challenge/token", nil)
+		c = elton.NewContext(nil, req)
+		err = fn(c)
+		assert.Equal(ErrNotAllowAccessDot, err)
+	})
+
+	t.Run("extension not allowed", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:            staticPath,
+			AllowExtensions: []string{".html", ".js"},
+			DenyExtensions:  []string{".bak"},
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+
+		for _, file := range []string{"/main.go", "/index.html.bak", "/LICENSE"} {
+			req = httptest.NewRequest("GET", file, nil)
+			c = elton.NewContext(nil, req)
+			err = fn(c)
+			assert.Equal(ErrExtensionNotAllowed, err, file)
+		}
+	})
+
+	t.Run("index file", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path: staticPath,
+			Index: []string{
+				"notfound.html",
+				"index.htm",
+				"default.html",
+			},
+		})
+		req := httptest.NewRequest("GET", "/docs/", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+		assert.Equal("text/html; charset=utf-8", c.GetHeader(elton.HeaderContentType))
+
+		fn = New(staticFile, Config{
+			Path: staticPath,
+			Index: []string{
+				"notfound.html",
+			},
+		})
+		c = elton.NewContext(nil, req)
+		err = fn(c)
+		assert.Equal(ErrNotFound, err)
+
+		// 默认为index.html
+		fn = New(staticFile, Config{
+			Path: staticPath,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+
+		// IndexFile优先
+		fn = New(staticFile, Config{
+			Path:      staticPath,
+			IndexFile: "banner.jpg",
+			Index: []string{
+				"index.html",
+			},
+		})
+		e = elton.New()
+		e.GET("/*file", fn)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		assert.Equal("image data", resp.Body.String())
+	})
+
+	t.Run("strip prefix", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:        staticPath,
+			StripPrefix: "/assets",
+		})
+		// 从url.Path获取文件
+		e := elton.New()
+		e.GET("/assets/index.html", fn)
+		req := httptest.NewRequest("GET", "/assets/index.html", nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+
+		// 从路由参数获取文件
+		e = elton.New()
+		e.GET("/*file", fn)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+
+		// 挂载于group路由时，前缀不会作为文件路径
+		files := make([]string, 0)
+		e = elton.New()
+		g := elton.NewGroup("/static")
+		g.GET("/*file", New(staticFile, Config{
+			Path: staticPath,
+			OnServe: func(c *elton.Context, info ServeInfo) {
+				files = append(files, info.File)
+			},
+		}))
+		e.AddGroup(g)
+		for _, url := range []string{
+			"/static/index.html",
+			"/static/js/app.js",
+		} {
+			resp = httptest.NewRecorder()
+			e.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+			assert.Equal(200, resp.Code)
+		}
+		assert.Equal([]string{
+			"/index.html",
+			"/js/app.js",
+		}, files)
+	})
+
+	t.Run("hide rejection reason", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:                staticPath,
+			DenyDot:             true,
+			DenyQueryString:     true,
+			HideRejectionReason: true,
+		})
+		for _, url := range []string{
+			"/.git/config",
+			"/index.html?a=1",
+		} {
+			req := httptest.NewRequest("GET", url, nil)
+			c := elton.NewContext(nil, req)
+			err := fn(c)
+			he, ok := err.(*hes.Error)
+			assert.True(ok)
+			assert.Equal(404, he.StatusCode)
+			assert.Equal(ErrNotFound.Error(), he.Error())
+			assert.NotNil(he.Err)
+		}
+
+		fn = New(staticFile, Config{
+			Path:                staticPath,
+			HideRejectionReason: true,
+		})
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.URL.Path = "../../index.html"
+		c := elton.NewContext(nil, req)
+		err := fn(c)
+		assert.Equal(ErrOutOfPath, err.(*hes.Error).Err)
 	})
 
-	t.Run("out of path", func(t *testing.T) {
+	t.Run("try extensions", func(t *testing.T) {
 		assert := assert.New(t)
-		tfs := FS{}
-
-		assert.Nil(tfs.Stat("/b"), "out of path should return nil stat")
-		assert.False(tfs.Exists("/b"), "file should be not exists")
+		sf := NewIOFS(fstest.MapFS{
+			"about.htm": &fstest.MapFile{
+				Data: []byte("about"),
+			},
+			"notes.txt": &fstest.MapFile{
+				Data: []byte("notes"),
+			},
+			"notes.htm": &fstest.MapFile{
+				Data: []byte("notes htm"),
+			},
+		})
+		e := elton.New()
+		e.GET("/*file", New(sf, Config{
+			TryExtensions: []string{".html", ".htm", ".txt"},
+		}))
+		for file, body := range map[string]string{
+			"/about": "about",
+			// 按顺序使用首个存在的文件
+			"/notes": "notes htm",
+		} {
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+			assert.Equal(200, resp.Code, file)
+			assert.Equal(body, resp.Body.String(), file)
+		}
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/contact", nil))
+		assert.Equal(404, resp.Code)
 	})
-}
-func TestStaticServe(t *testing.T) {
-	staticFile := &MockStaticFile{}
-	t.Run("not allow query string", func(t *testing.T) {
+
+	t.Run("fallback", func(t *testing.T) {
 		assert := assert.New(t)
 		fn := New(staticFile, Config{
-			Path:            staticPath,
-			DenyQueryString: true,
+			Path:     staticPath,
+			Fallback: "/index.html",
 		})
-		req := httptest.NewRequest("GET", "/index.html?a=1", nil)
-		c := elton.NewContext(nil, req)
+		e := elton.New()
+		e.GET("/*file", fn)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/users/notfound.html", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+
+		fn = New(staticFile, Config{
+			Path:     staticPath,
+			Fallback: "/notfound.html",
+		})
+		c := elton.NewContext(nil, httptest.NewRequest("GET", "/notfound.html", nil))
 		err := fn(c)
-		assert.Equal(err, ErrNotAllowQueryString, "should return not allow query string error")
+		assert.Equal(ErrNotFound, err)
 	})
 
-	t.Run("not allow dot file", func(t *testing.T) {
+	t.Run("not found file", func(t *testing.T) {
 		assert := assert.New(t)
 		fn := New(staticFile, Config{
-			Path:    staticPath,
-			DenyDot: true,
+			Path:         staticPath,
+			MaxAge:       60,
+			NotFoundFile: "/404.html",
 		})
-		req := httptest.NewRequest("GET", "/.index.html", nil)
-		c := elton.NewContext(nil, req)
+		e := elton.New()
+		e.GET("/*file", fn)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/notfound.html", nil))
+		assert.Equal(404, resp.Code)
+		assert.Equal("no-cache", resp.Header().Get(elton.HeaderCacheControl))
+		assert.Equal("text/html; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+		assert.Equal("abcd", resp.Body.String())
+
+		fn = New(staticFile, Config{
+			Path:         staticPath,
+			NotFoundFile: "/error",
+		})
+		c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/notfound.html", nil))
 		err := fn(c)
-		assert.Equal(err, ErrNotAllowAccessDot, "should return not allow dot error")
+		assert.Equal("category=elton-static-serve, message=abcd", err.Error())
+
+		fn = New(staticFile, Config{
+			Path:         staticPath,
+			NotFoundFile: "/notfound.html",
+		})
+		c = elton.NewContext(nil, httptest.NewRequest("GET", "/notfound.html", nil))
+		err = fn(c)
+		assert.Equal(ErrNotFound, err)
 	})
 
 	t.Run("not found return error", func(t *testing.T) {
@@ -166,6 +1816,34 @@ func TestStaticServe(t *testing.T) {
 		assert.True(done)
 	})
 
+	t.Run("not found handler", func(t *testing.T) {
+		assert := assert.New(t)
+		file := ""
+		fn := New(staticFile, Config{
+			Path:         staticPath,
+			NotFoundNext: true,
+			NotFoundHandler: func(c *elton.Context) error {
+				file = GetNotFoundFile(c)
+				c.StatusCode = http.StatusNotFound
+				c.BodyBuffer = bytes.NewBufferString(`{"message":"not found"}`)
+				return nil
+			},
+		})
+		req := httptest.NewRequest("GET", "/notfound.html", nil)
+		c := elton.NewContext(nil, req)
+		done := false
+		c.Next = func() error {
+			done = true
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+		assert.False(done)
+		assert.Equal("/notfound.html", file)
+		assert.Equal(http.StatusNotFound, c.StatusCode)
+		assert.Equal(`{"message":"not found"}`, c.BodyBuffer.String())
+	})
+
 	t.Run("not compresss", func(t *testing.T) {
 		assert := assert.New(t)
 		fn := New(staticFile, Config{
@@ -201,7 +1879,436 @@ func TestStaticServe(t *testing.T) {
 		assert.Equal(c.GetHeader(elton.HeaderETag), `W/"400-5cfb1ad2"`, "generate etag fail")
 		assert.NotEmpty(c.GetHeader(elton.HeaderLastModified), "last modified shouldn't be empty")
 		assert.Equal(c.GetHeader("Content-Type"), "text/html; charset=utf-8")
+		assert.Equal("none", c.GetHeader(HeaderAcceptRanges))
+		assert.True(c.IsReaderBody())
+	})
+
+	t.Run("custom etag function", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			ETagFunc: func(file string, info os.FileInfo) string {
+				return `"build-` + info.Name() + `"`
+			},
+		})
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		res := httptest.NewRecorder()
+		c := elton.NewContext(res, req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+		assert.Equal(`"build-file"`, c.GetHeader(elton.HeaderETag))
+		// 自定义etag时不需要读取文件内容
+		assert.True(c.IsReaderBody())
+	})
+
+	t.Run("file too large for strong etag", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			MaxFileSize:      100,
+		})
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		res := httptest.NewRecorder()
+		c := elton.NewContext(res, req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Equal(ErrFileTooLarge, err)
+
+		// 不使用strong etag时，以stream的形式响应
+		fn = New(staticFile, Config{
+			Path:        staticPath,
+			MaxFileSize: 100,
+		})
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Nil(err)
+		assert.True(c.IsReaderBody())
+
+		// 超出限制时以stream的形式响应，使用weak etag且不压缩
+		fn = New(staticFile, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			MaxFileSize:      100,
+			StreamLargeFile:  true,
+			Compressors: []Compressor{
+				NewGzipCompressor(0),
+			},
+		})
+		req = httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Nil(err)
+		assert.True(c.IsReaderBody())
+		assert.Empty(c.GetHeader(elton.HeaderContentEncoding))
+		assert.True(strings.HasPrefix(c.GetHeader(elton.HeaderETag), "W/"))
+
+		// 需要转换的数据无法以stream的形式响应
+		fn = New(staticFile, Config{
+			Path:            staticPath,
+			MaxFileSize:     100,
+			StreamLargeFile: true,
+			Transform: func(c *elton.Context, file string, buf []byte) ([]byte, error) {
+				return buf, nil
+			},
+		})
+		c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/index.html", nil))
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Equal(ErrFileTooLarge, err)
+
+		// 超过限制的文件不读取至内容缓存
+		cache := NewContentCache(10 * 1024)
+		fn = New(staticFile, Config{
+			Path:         staticPath,
+			MaxFileSize:  100,
+			ContentCache: cache,
+		})
+		c = elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/index.html", nil))
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Nil(err)
+		assert.True(c.IsReaderBody())
+		assert.Equal(0, cache.Stats().Count)
+	})
+
+	t.Run("custom mime types", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path: staticPath,
+			MIMETypes: map[string]string{
+				".wasm":       "application/wasm",
+				"webmanifest": "application/manifest+json",
+			},
+		})
+		for file, contentType := range map[string]string{
+			"/main.WASM":            "application/wasm",
+			"/app.webmanifest":      "application/manifest+json",
+			"/index.html":           "text/html; charset=utf-8",
+			"/file.unknown-ext-xyz": "",
+			"/app.mjs":              "text/javascript; charset=utf-8",
+			"/photo.avif":           "image/avif",
+		} {
+			req := httptest.NewRequest("GET", file, nil)
+			c := elton.NewContext(httptest.NewRecorder(), req)
+			c.Next = func() error {
+				return nil
+			}
+			err := fn(c)
+			assert.Nil(err)
+			assert.Equal(contentType, c.GetHeader(elton.HeaderContentType))
+		}
+
+		// 文本类型添加charset
+		fn = New(staticFile, Config{
+			Path:    staticPath,
+			Charset: "utf-8",
+			MIMETypes: map[string]string{
+				".md": "text/markdown",
+			},
+		})
+		for file, contentType := range map[string]string{
+			"/index.html": "text/html; charset=utf-8",
+			"/README.md":  "text/markdown; charset=utf-8",
+			"/data.json":  "application/json; charset=utf-8",
+			"/banner.jpg": "image/jpeg",
+		} {
+			req := httptest.NewRequest("GET", file, nil)
+			c := elton.NewContext(httptest.NewRecorder(), req)
+			c.Next = func() error {
+				return nil
+			}
+			err := fn(c)
+			assert.Nil(err)
+			assert.Equal(contentType, c.GetHeader(elton.HeaderContentType))
+		}
+
+		// 未知类型使用默认的content type
+		fn = New(staticFile, Config{
+			Path:               staticPath,
+			DefaultContentType: "application/octet-stream",
+		})
+		for file, contentType := range map[string]string{
+			"/index.html":           "text/html; charset=utf-8",
+			"/file.unknown-ext-xyz": "application/octet-stream",
+			"/LICENSE":              "application/octet-stream",
+		} {
+			req := httptest.NewRequest("GET", file, nil)
+			c := elton.NewContext(httptest.NewRecorder(), req)
+			c.Next = func() error {
+				return nil
+			}
+			err := fn(c)
+			assert.Nil(err)
+			assert.Equal(contentType, c.GetHeader(elton.HeaderContentType))
+		}
+	})
+
+	t.Run("content cache", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			ContentCacheSize: 2048,
+		})
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest("GET", "/index.html", nil)
+			c := elton.NewContext(httptest.NewRecorder(), req)
+			c.Next = func() error {
+				return nil
+			}
+			err := fn(c)
+			assert.Nil(err)
+			assert.Equal("<html>xxx</html>", c.BodyBuffer.String())
+		}
+
+		// 自定义的内容缓存，缓存strong etag并统计命中
+		cache := NewContentCache(2048)
+		fn = New(staticFile, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			ContentCache:     cache,
+		})
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest("GET", "/index.html", nil)
+			c := elton.NewContext(httptest.NewRecorder(), req)
+			c.Next = func() error {
+				return nil
+			}
+			err := fn(c)
+			assert.Nil(err)
+			assert.Equal(generateETag([]byte("<html>xxx</html>")), c.GetHeader(elton.HeaderETag))
+		}
+		assert.Equal(ContentCacheStats{
+			Hits:   1,
+			Misses: 1,
+			Count:  1,
+			Size:   16,
+		}, cache.Stats())
+
+		// 文件大小超过单个文件缓存限制，以stream的形式响应
+		fn = New(staticFile, Config{
+			Path:                 staticPath,
+			ContentCacheSize:     2048,
+			ContentCacheFileSize: 100,
+		})
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
 		assert.True(c.IsReaderBody())
+
+		// 读取出错
+		fn = New(staticFile, Config{
+			Path:             staticPath,
+			ContentCacheSize: 2048,
+		})
+		req = httptest.NewRequest("GET", "/error", nil)
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		err = fn(c)
+		assert.Equal("category=elton-static-serve, message=abcd", err.Error())
+	})
+
+	t.Run("client no cache", func(t *testing.T) {
+		assert := assert.New(t)
+		newReq := func() *http.Request {
+			req := httptest.NewRequest("GET", "/index.html", nil)
+			req.Header.Set(elton.HeaderCacheControl, "no-cache")
+			req.Header.Set(elton.HeaderIfNoneMatch, `W/"400-5cfb1ad2"`)
+			return req
+		}
+		fn := New(staticFile, Config{
+			Path: staticPath,
+		})
+		req := newReq()
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+		// 返回完整数据，且不修改请求头
+		assert.NotEqual(304, c.StatusCode)
+		assert.Equal(`W/"400-5cfb1ad2"`, req.Header.Get(elton.HeaderIfNoneMatch))
+
+		fn = New(staticFile, Config{
+			Path:                staticPath,
+			IgnoreClientNoCache: true,
+		})
+		req = newReq()
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Nil(err)
+		assert.Equal(304, c.StatusCode)
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			Transform: func(c *elton.Context, file string, content []byte) ([]byte, error) {
+				if file == staticPath+"/error.html" {
+					return nil, errors.New("transform fail")
+				}
+				return bytes.Replace(content, []byte("xxx"), []byte("abc"), 1), nil
+			},
+		})
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		c := elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err := fn(c)
+		assert.Nil(err)
+		assert.Equal("<html>abc</html>", c.BodyBuffer.String())
+		assert.Equal(generateETag([]byte("<html>abc</html>")), c.GetHeader(elton.HeaderETag))
+
+		// 非html不转换
+		req = httptest.NewRequest("GET", "/banner.jpg", nil)
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		c.Next = func() error {
+			return nil
+		}
+		err = fn(c)
+		assert.Nil(err)
+		assert.Equal("image data", c.BodyBuffer.String())
+
+		req = httptest.NewRequest("GET", "/error.html", nil)
+		c = elton.NewContext(httptest.NewRecorder(), req)
+		err = fn(c)
+		assert.Equal("transform fail", err.Error())
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:   staticPath,
+			MaxAge: 60,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(elton.HeaderIfNoneMatch, `W/"400-5cfb1ad2"`)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(304, resp.Code)
+		assert.Empty(resp.Body.String())
+		assert.Equal("public, max-age=60", resp.Header().Get(elton.HeaderCacheControl))
+		assert.Equal(`W/"400-5cfb1ad2"`, resp.Header().Get(elton.HeaderETag))
+
+		fn = New(staticFile, Config{
+			Path:               staticPath,
+			DisableConditional: true,
+		})
+		e = elton.New()
+		e.GET("/*file", fn)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("<html>xxx</html>", resp.Body.String())
+	})
+
+	t.Run("precondition failed", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(staticFile, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+		})
+		e := elton.New()
+		e.GET("/*file", fn)
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		eTag := resp.Header().Get(elton.HeaderETag)
+
+		req = httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderIfMatch, eTag)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+
+		req = httptest.NewRequest("GET", "/index.html", nil)
+		req.Header.Set(HeaderIfMatch, `"abc"`)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(http.StatusPreconditionFailed, resp.Code)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		assert := assert.New(t)
+		fn := New(&MockStaticFile{}, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			MaxAge:           60,
+		})
+		e := elton.New()
+		e.HEAD("/*file", fn)
+		req := httptest.NewRequest("HEAD", "/index.html", nil)
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Empty(resp.Body.String())
+		assert.Equal("1024", resp.Header().Get(elton.HeaderContentLength))
+		assert.Equal("text/html; charset=utf-8", resp.Header().Get(elton.HeaderContentType))
+		assert.Equal("public, max-age=60", resp.Header().Get(elton.HeaderCacheControl))
+		// 读取文件生成与GET请求一致的strong etag
+		assert.Equal(generateETag([]byte("<html>xxx</html>")), resp.Header().Get(elton.HeaderETag))
+
+		// 支持range时根据reader获取长度
+		fn = New(&MockStaticFile{}, Config{
+			Path:        staticPath,
+			EnableRange: true,
+		})
+		e = elton.New()
+		e.HEAD("/*file", fn)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code)
+		assert.Equal("16", resp.Header().Get(elton.HeaderContentLength))
+		assert.Equal("bytes", resp.Header().Get(HeaderAcceptRanges))
+		assert.Equal(`W/"400-5cfb1ad2"`, resp.Header().Get(elton.HeaderETag))
+
+		// 缓存中的数据
+		fn = New(&MockStaticFile{}, Config{
+			Path:             staticPath,
+			EnableStrongETag: true,
+			ContentCacheSize: 2048,
+		})
+		e = elton.New()
+		e.GET("/*file", fn)
+		e.HEAD("/*file", fn)
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(generateETag([]byte("<html>xxx</html>")), resp.Header().Get(elton.HeaderETag))
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/index.html", nil))
+		resp = httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal("16", resp.Header().Get(elton.HeaderContentLength))
+		assert.Equal(generateETag([]byte("<html>xxx</html>")), resp.Header().Get(elton.HeaderETag))
 	})
 
 	t.Run("set custom header", func(t *testing.T) {
@@ -210,6 +2317,10 @@ func TestStaticServe(t *testing.T) {
 			Path: staticPath,
 			Header: map[string]string{
 				"X-IDC": "GZ",
+				"Vary":  "Accept-Encoding",
+			},
+			Vary: []string{
+				"Origin",
 			},
 		})
 		req := httptest.NewRequest("GET", "/index.html", nil)
@@ -221,6 +2332,30 @@ func TestStaticServe(t *testing.T) {
 		err := fn(c)
 		assert.Nil(err)
 		assert.Equal(c.GetHeader("X-IDC"), "GZ", "set custom header fail")
+		assert.Equal("Accept-Encoding, Origin", c.GetHeader(HeaderVary))
+	})
+
+	t.Run("set headers", func(t *testing.T) {
+		assert := assert.New(t)
+		e := elton.New()
+		e.GET("/*file", New(staticFile, Config{
+			Path:   staticPath,
+			MaxAge: 3600,
+			SetHeaders: func(c *elton.Context, file string, info os.FileInfo) {
+				if strings.HasPrefix(file, "/zh/") {
+					c.SetHeader("Content-Language", "zh")
+				}
+				if info != nil && info.Size() >= 1024 {
+					c.SetHeader(elton.HeaderCacheControl, "no-cache")
+				}
+			},
+		}))
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", "/zh/index.html", nil))
+		assert.Equal(200, resp.Code)
+		assert.Equal("zh", resp.Header().Get("Content-Language"))
+		// 在内置的响应头之后设置
+		assert.Equal("no-cache", resp.Header().Get(elton.HeaderCacheControl))
 	})
 
 	t.Run("set (s)max-age", func(t *testing.T) {
@@ -293,3 +2428,67 @@ func TestMain(m *testing.M) {
 	}
 	os.Exit(rc)
 }
+
+// benchResponseWriter the response writer of benchmark, the header is reused
+type benchResponseWriter struct {
+	header http.Header
+}
+
+func (w *benchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *benchResponseWriter) Write(buf []byte) (int, error) {
+	return len(buf), nil
+}
+
+func (w *benchResponseWriter) WriteHeader(statusCode int) {}
+
+func benchmarkServe(b *testing.B, config Config, header map[string]string) {
+	config.Path = staticPath
+	fn := New(&MockStaticFile{}, config)
+	req := httptest.NewRequest("GET", "/index.html", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	next := func() error {
+		return nil
+	}
+	w := &benchResponseWriter{
+		header: make(http.Header),
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k := range w.header {
+			delete(w.header, k)
+		}
+		c := elton.NewContext(w, req)
+		c.Next = next
+		if err := fn(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkServe(b *testing.B) {
+	benchmarkServe(b, Config{
+		MaxAge: 60,
+	}, nil)
+}
+
+func BenchmarkServeContentCache(b *testing.B) {
+	benchmarkServe(b, Config{
+		MaxAge:           60,
+		EnableStrongETag: true,
+		ContentCacheSize: 10 * 1024,
+	}, nil)
+}
+
+func BenchmarkServeNotModified(b *testing.B) {
+	benchmarkServe(b, Config{
+		MaxAge: 60,
+	}, map[string]string{
+		elton.HeaderIfNoneMatch: `W/"400-5cfb1ad2"`,
+	})
+}
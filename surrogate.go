@@ -0,0 +1,88 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// HeaderSurrogateControl surrogate-control
+	HeaderSurrogateControl = "Surrogate-Control"
+	// HeaderSurrogateKey surrogate-key
+	HeaderSurrogateKey = "Surrogate-Key"
+	// HeaderCacheTag cache-tag
+	HeaderCacheTag = "Cache-Tag"
+)
+
+// SurrogateConfig the config of surrogate headers for CDN
+type SurrogateConfig struct {
+	// Surrogate-Control的值（如 max-age=86400 ），为空时如果设置了SMaxAge则为 max-age=SMaxAge
+	Control string
+	// surrogate key的响应头，默认为Surrogate-Key（也可使用Cache-Tag等）
+	KeyHeader string
+	// 获取文件（相对于Path的路径）的surrogate key，默认为顶层目录（如 /js/app.js 为js），
+	// 用于CDN按分组清除缓存
+	Keys func(file string) []string
+}
+
+// getControl get the value of Surrogate-Control
+func (sc *SurrogateConfig) getControl(sMaxAge int) string {
+	if sc.Control != "" {
+		return sc.Control
+	}
+	if sMaxAge > 0 {
+		return "max-age=" + strconv.Itoa(sMaxAge)
+	}
+	return ""
+}
+
+// getTopLevelDir get the top-level directory of file, it's empty if the file is in root
+func getTopLevelDir(file string) string {
+	file = strings.TrimPrefix(file, "/")
+	index := strings.Index(file, "/")
+	if index <= 0 {
+		return ""
+	}
+	return file[:index]
+}
+
+// setHeaders set the surrogate headers of file
+func (sc *SurrogateConfig) setHeaders(header http.Header, file string, sMaxAge int) {
+	if control := sc.getControl(sMaxAge); control != "" {
+		header.Set(HeaderSurrogateControl, control)
+	}
+	var keys []string
+	if sc.Keys != nil {
+		keys = sc.Keys(file)
+	} else if dir := getTopLevelDir(file); dir != "" {
+		keys = []string{dir}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	keyHeader := sc.KeyHeader
+	if keyHeader == "" {
+		keyHeader = HeaderSurrogateKey
+	}
+	// Surrogate-Key以空格分隔，Cache-Tag等以逗号分隔
+	sep := " "
+	if !strings.EqualFold(keyHeader, HeaderSurrogateKey) {
+		sep = ","
+	}
+	header.Set(keyHeader, strings.Join(keys, sep))
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestGetTopLevelDir(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("js", getTopLevelDir("/js/app.js"))
+	assert.Equal("js", getTopLevelDir("/js/vendor/vue.js"))
+	assert.Empty(getTopLevelDir("/index.html"))
+	assert.Empty(getTopLevelDir("/"))
+}
+
+func TestSurrogateConfig(t *testing.T) {
+	assert := assert.New(t)
+	sc := &SurrogateConfig{}
+	assert.Empty(sc.getControl(0))
+	assert.Equal("max-age=600", sc.getControl(600))
+	sc.Control = "max-age=86400"
+	assert.Equal("max-age=86400", sc.getControl(600))
+
+	header := http.Header{}
+	sc.setHeaders(header, "/js/app.js", 0)
+	assert.Equal("max-age=86400", header.Get(HeaderSurrogateControl))
+	assert.Equal("js", header.Get(HeaderSurrogateKey))
+
+	header = http.Header{}
+	sc.setHeaders(header, "/index.html", 0)
+	assert.Empty(header.Get(HeaderSurrogateKey))
+
+	sc = &SurrogateConfig{
+		KeyHeader: HeaderCacheTag,
+		Keys: func(file string) []string {
+			return []string{"static", getTopLevelDir(file)}
+		},
+	}
+	header = http.Header{}
+	sc.setHeaders(header, "/css/app.css", 0)
+	assert.Empty(header.Get(HeaderSurrogateControl))
+	assert.Equal("static,css", header.Get(HeaderCacheTag))
+}
+
+func TestServeSurrogate(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path:    staticPath,
+		MaxAge:  60,
+		SMaxAge: 3600,
+		Surrogate: &SurrogateConfig{
+			Keys: func(file string) []string {
+				return []string{"assets", strings.TrimPrefix(file, "/")}
+			},
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("max-age=3600", resp.Header().Get(HeaderSurrogateControl))
+	assert.Equal("assets index.html", resp.Header().Get(HeaderSurrogateKey))
+	assert.Equal("public, max-age=60, s-maxage=3600", resp.Header().Get(elton.HeaderCacheControl))
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy the policy of symbolic link
+type SymlinkPolicy string
+
+const (
+	// SymlinkFollow follow the symbolic link(default)
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkDeny deny the file whose path contains symbolic link
+	SymlinkDeny SymlinkPolicy = "deny"
+	// SymlinkRestrictRoot follow the symbolic link only if the target is in the root path
+	SymlinkRestrictRoot SymlinkPolicy = "restrict-root"
+)
+
+// ErrSymlinkNotAllowed the symbolic link is not allowed
+var ErrSymlinkNotAllowed = getStaticServeError("static file symbolic link is not allowed", http.StatusForbidden)
+
+// validate check the policy is valid
+func (policy SymlinkPolicy) validate() error {
+	switch policy {
+	case "", SymlinkFollow, SymlinkDeny, SymlinkRestrictRoot:
+		return nil
+	}
+	return fmt.Errorf("invalid symlink policy: %s", policy)
+}
+
+// isSymlinkAllowed check the file(os path) is allowed by the policy, the symbolic link of
+// root itself is always allowed. If the file can't be resolved(such as not exists), it's allowed
+// and handled by the following process.
+func isSymlinkAllowed(policy SymlinkPolicy, root, file string) bool {
+	if policy == "" || policy == SymlinkFollow {
+		return true
+	}
+	realFile, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return true
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	if policy == SymlinkDeny {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return false
+		}
+		return realFile == filepath.Join(realRoot, rel)
+	}
+	return realFile == realRoot || strings.HasPrefix(realFile, realRoot+string(filepath.Separator))
+}
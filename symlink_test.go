@@ -0,0 +1,115 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestSymlinkPolicy(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(SymlinkPolicy("").validate())
+	assert.Nil(SymlinkRestrictRoot.validate())
+	assert.NotNil(SymlinkPolicy("none").validate())
+}
+
+func TestIsSymlinkAllowed(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	assert.Nil(os.MkdirAll(filepath.Join(root, "assets"), 0755))
+	assert.Nil(os.MkdirAll(outside, 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "assets", "app.js"), []byte("app"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(outside, "passwd"), []byte("secret"), 0644))
+	assert.Nil(os.Symlink(filepath.Join(outside, "passwd"), filepath.Join(root, "passwd")))
+	assert.Nil(os.Symlink(filepath.Join(root, "assets"), filepath.Join(root, "static")))
+	// 根目录为符号链接
+	link := filepath.Join(dir, "current")
+	assert.Nil(os.Symlink(root, link))
+
+	assert.True(isSymlinkAllowed(SymlinkFollow, root, filepath.Join(root, "passwd")))
+
+	assert.True(isSymlinkAllowed(SymlinkDeny, root, filepath.Join(root, "assets/app.js")))
+	assert.True(isSymlinkAllowed(SymlinkDeny, link, filepath.Join(link, "assets/app.js")))
+	assert.False(isSymlinkAllowed(SymlinkDeny, root, filepath.Join(root, "passwd")))
+	assert.False(isSymlinkAllowed(SymlinkDeny, root, filepath.Join(root, "static/app.js")))
+	// 不存在的文件由后续流程处理
+	assert.True(isSymlinkAllowed(SymlinkDeny, root, filepath.Join(root, "notfound.js")))
+
+	assert.True(isSymlinkAllowed(SymlinkRestrictRoot, root, filepath.Join(root, "static/app.js")))
+	assert.True(isSymlinkAllowed(SymlinkRestrictRoot, link, filepath.Join(link, "static/app.js")))
+	assert.False(isSymlinkAllowed(SymlinkRestrictRoot, root, filepath.Join(root, "passwd")))
+	assert.False(isSymlinkAllowed(SymlinkRestrictRoot, link, filepath.Join(link, "passwd")))
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:          root,
+		SymlinkPolicy: SymlinkRestrictRoot,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/static/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("app", resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/passwd", nil))
+	assert.Equal(403, resp.Code)
+}
+
+func TestServeSymlinkVariants(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	assert.Nil(os.MkdirAll(root, 0755))
+	assert.Nil(os.MkdirAll(outside, 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "app.js"), []byte("app"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "index.html"), []byte("index"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644))
+	// 预压缩文件与语言文件为指向根目录之外的符号链接
+	assert.Nil(os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "app.js.br")))
+	assert.Nil(os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "index.en.html")))
+
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:          root,
+		SymlinkPolicy: SymlinkRestrictRoot,
+		Precompressed: []string{"br"},
+		Languages:     []string{"en"},
+	}))
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(403, resp.Code)
+
+	req = httptest.NewRequest("GET", "/index.html", nil)
+	req.Header.Set(HeaderAcceptLanguage, "en")
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(403, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("app", resp.Body.String())
+}
@@ -0,0 +1,234 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+type (
+	// TarGz static file of tar.gz bundle, the bundle is decompressed into memory
+	// and the entries are served by the index of offsets
+	TarGz struct {
+		data []byte
+		// 文件（或目录）对应的记录
+		entries map[string]*tarEntry
+		// 目录下的文件名
+		children map[string][]string
+	}
+	tarEntry struct {
+		info   os.FileInfo
+		offset int64
+		size   int64
+	}
+	// tarDirInfo file info of the directory which doesn't have a header in tar
+	tarDirInfo struct {
+		name string
+	}
+)
+
+func (info *tarDirInfo) Name() string       { return info.name }
+func (info *tarDirInfo) Size() int64        { return 0 }
+func (info *tarDirInfo) Mode() os.FileMode  { return os.ModeDir | 0755 }
+func (info *tarDirInfo) ModTime() time.Time { return time.Time{} }
+func (info *tarDirInfo) IsDir() bool        { return true }
+func (info *tarDirInfo) Sys() interface{}   { return nil }
+
+// NewTarGz create a static file of tar.gz bundle
+func NewTarGz(r io.Reader) (*TarGz, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	data, err := ioutil.ReadAll(gr)
+	if err != nil {
+		return nil, err
+	}
+	t := &TarGz{
+		data:     data,
+		entries:  make(map[string]*tarEntry),
+		children: make(map[string][]string),
+	}
+	t.entries["."] = &tarEntry{
+		info: &tarDirInfo{
+			name: ".",
+		},
+	}
+	br := bytes.NewReader(data)
+	tr := tar.NewReader(br)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := toFSPath(header.Name)
+		if name == "." {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			t.addDir(name, header.FileInfo())
+		case tar.TypeReg:
+			t.addDir(path.Dir(name), nil)
+			t.add(name, &tarEntry{
+				info: header.FileInfo(),
+				// tar reader未预读数据，当前位置即为文件内容的起始位置
+				offset: br.Size() - int64(br.Len()),
+				size:   header.Size,
+			})
+		}
+	}
+	for _, names := range t.children {
+		sort.Strings(names)
+	}
+	return t, nil
+}
+
+// OpenTarGz open the tar.gz bundle and create a static file of it
+func OpenTarGz(file string) (*TarGz, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewTarGz(f)
+}
+
+// add add the entry and append it to the children of parent
+func (t *TarGz) add(name string, entry *tarEntry) {
+	if _, ok := t.entries[name]; !ok {
+		parent := path.Dir(name)
+		t.children[parent] = append(t.children[parent], name)
+	}
+	t.entries[name] = entry
+}
+
+// addDir add the directory and its parents, the info is nil for implicit directory
+func (t *TarGz) addDir(name string, info os.FileInfo) {
+	if name == "." {
+		return
+	}
+	if entry, ok := t.entries[name]; ok {
+		if info != nil {
+			entry.info = info
+		}
+		return
+	}
+	t.addDir(path.Dir(name), nil)
+	if info == nil {
+		info = &tarDirInfo{
+			name: path.Base(name),
+		}
+	}
+	t.add(name, &tarEntry{
+		info: info,
+	})
+}
+
+// Exists check the file exists
+func (t *TarGz) Exists(file string) bool {
+	_, ok := t.entries[toFSPath(file)]
+	return ok
+}
+
+// Stat get stat of file
+func (t *TarGz) Stat(file string) os.FileInfo {
+	entry, ok := t.entries[toFSPath(file)]
+	if !ok {
+		return nil
+	}
+	return entry.info
+}
+
+// getRegular get the entry of regular file
+func (t *TarGz) getRegular(file string) (*tarEntry, error) {
+	entry, ok := t.entries[toFSPath(file)]
+	if !ok || entry.info.IsDir() {
+		return nil, os.ErrNotExist
+	}
+	return entry, nil
+}
+
+// Get get the file's content
+func (t *TarGz) Get(file string) ([]byte, error) {
+	entry, err := t.getRegular(file)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, entry.size)
+	copy(buf, t.data[entry.offset:entry.offset+entry.size])
+	return buf, nil
+}
+
+// NewReader new a reader for file
+func (t *TarGz) NewReader(file string) (io.Reader, error) {
+	entry, err := t.getRegular(file)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(t.data[entry.offset : entry.offset+entry.size]), nil
+}
+
+// NewReadSeeker new a read seeker for file
+func (t *TarGz) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	entry, err := t.getRegular(file)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(t.data[entry.offset : entry.offset+entry.size]), nil
+}
+
+// ReadDirEntries read the entries of directory
+func (t *TarGz) ReadDirEntries(dir string) ([]fs.DirEntry, error) {
+	infos, err := t.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, &fileInfoDirEntry{
+			info: info,
+		})
+	}
+	return entries, nil
+}
+
+// ReadDir read the file infos of directory
+func (t *TarGz) ReadDir(dir string) ([]os.FileInfo, error) {
+	name := toFSPath(dir)
+	entry, ok := t.entries[name]
+	if !ok || !entry.info.IsDir() {
+		return nil, os.ErrNotExist
+	}
+	names := t.children[name]
+	infos := make([]os.FileInfo, 0, len(names))
+	for _, item := range names {
+		infos = append(infos, t.entries[item].info)
+	}
+	return infos, nil
+}
@@ -0,0 +1,119 @@
+package staticserve
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func newTestTarGz(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{
+		Name:     "./assets/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+	}))
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"./index.html", "<html>index</html>"},
+		{"./assets/app.js", "console.log('app')"},
+		{"./assets/css/app.css", "body {}"},
+	}
+	for _, item := range files {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{
+			Name:     item.name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(item.content)),
+		}))
+		_, err := tw.Write([]byte(item.content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestTarGz(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewTarGz(bytes.NewReader([]byte("abc")))
+	assert.NotNil(err)
+
+	tg, err := NewTarGz(bytes.NewReader(newTestTarGz(t)))
+	assert.Nil(err)
+
+	assert.True(tg.Exists("/"))
+	assert.True(tg.Exists("/index.html"))
+	assert.True(tg.Exists("/assets/css"))
+	assert.False(tg.Exists("/notfound.html"))
+	assert.True(tg.Stat("/assets").IsDir())
+	assert.True(tg.Stat("/assets/css").IsDir())
+	assert.Equal(int64(7), tg.Stat("/assets/css/app.css").Size())
+	assert.Nil(tg.Stat("/notfound.html"))
+
+	buf, err := tg.Get("/assets/app.js")
+	assert.Nil(err)
+	assert.Equal("console.log('app')", string(buf))
+	_, err = tg.Get("/assets")
+	assert.Equal(os.ErrNotExist, err)
+
+	r, err := tg.NewReader("/assets/css/app.css")
+	assert.Nil(err)
+	buf, _ = ioutil.ReadAll(r)
+	assert.Equal("body {}", string(buf))
+	_, err = tg.NewReader("/notfound.html")
+	assert.Equal(os.ErrNotExist, err)
+
+	rs, err := tg.NewReadSeeker("/assets/css/app.css")
+	assert.Nil(err)
+	size, _ := getSeekerSize(rs)
+	assert.Equal(int64(7), size)
+	_, err = tg.NewReadSeeker("/notfound.html")
+	assert.Equal(os.ErrNotExist, err)
+
+	infos, err := tg.ReadDir("/")
+	assert.Nil(err)
+	assert.Equal(2, len(infos))
+	assert.Equal("assets", infos[0].Name())
+	assert.Equal("index.html", infos[1].Name())
+	infos, err = tg.ReadDir("/assets")
+	assert.Nil(err)
+	assert.Equal(2, len(infos))
+	assert.Equal("app.js", infos[0].Name())
+	assert.Equal("css", infos[1].Name())
+	_, err = tg.ReadDir("/index.html")
+	assert.Equal(os.ErrNotExist, err)
+
+	e := elton.New()
+	e.GET("/*file", New(tg, Config{}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("<html>index</html>", resp.Body.String())
+}
+
+func TestOpenTarGz(t *testing.T) {
+	assert := assert.New(t)
+	_, err := OpenTarGz(filepath.Join(t.TempDir(), "notfound.tar.gz"))
+	assert.NotNil(err)
+
+	file := filepath.Join(t.TempDir(), "assets.tar.gz")
+	assert.Nil(ioutil.WriteFile(file, newTestTarGz(t), 0644))
+	tg, err := OpenTarGz(file)
+	assert.Nil(err)
+	buf, err := tg.Get("/index.html")
+	assert.Nil(err)
+	assert.Equal("<html>index</html>", string(buf))
+}
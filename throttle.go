@@ -0,0 +1,106 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/vicanso/elton"
+)
+
+type (
+	// throttledReader the reader limits the read rate by token bucket
+	throttledReader struct {
+		r io.Reader
+		// 每秒的字节数
+		rate int64
+		// 最多可连续读取的字节数
+		burst  int64
+		tokens float64
+		last   time.Time
+		now    func() time.Time
+		sleep  func(time.Duration)
+	}
+)
+
+// newThrottledReader new a reader which limits the read rate(bytes per second),
+// the burst is the same as rate if it's not greater than 0
+func newThrottledReader(r io.Reader, rate, burst int64) *throttledReader {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &throttledReader{
+		r:      r,
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// refill add the tokens by the elapsed time
+func (tr *throttledReader) refill() {
+	now := tr.now()
+	if !tr.last.IsZero() {
+		tr.tokens += now.Sub(tr.last).Seconds() * float64(tr.rate)
+		if tr.tokens > float64(tr.burst) {
+			tr.tokens = float64(tr.burst)
+		}
+	}
+	tr.last = now
+}
+
+// Read read data from the reader, it blocks after reading until the
+// tokens are not negative, so the read rate is limited
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > tr.burst {
+		p = p[:tr.burst]
+	}
+	tr.refill()
+	n, err := tr.r.Read(p)
+	tr.tokens -= float64(n)
+	if tr.tokens < 0 {
+		d := time.Duration(-tr.tokens / float64(tr.rate) * float64(time.Second))
+		tr.sleep(d)
+		tr.tokens = 0
+		tr.last = tr.last.Add(d)
+	}
+	return n, err
+}
+
+// throttle limit the rate of response body, the body buffer is changed to
+// stream reader with the content length
+func throttle(c *elton.Context, rate, burst int64) {
+	if c.BodyBuffer != nil {
+		buf := c.BodyBuffer.Bytes()
+		c.BodyBuffer = nil
+		c.SetHeader(elton.HeaderContentLength, strconv.Itoa(len(buf)))
+		c.Body = newThrottledReader(bytes.NewReader(buf), rate, burst)
+		return
+	}
+	r, ok := c.Body.(io.Reader)
+	if !ok {
+		return
+	}
+	closer, _ := r.(io.Closer)
+	c.Body = &readCloser{
+		Reader: newThrottledReader(r, rate, burst),
+		closer: closer,
+	}
+}
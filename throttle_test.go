@@ -0,0 +1,87 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestThrottledReader(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Unix(0, 0)
+	var slept time.Duration
+	data := bytes.Repeat([]byte("a"), 1000)
+	tr := newThrottledReader(bytes.NewReader(data), 100, 50)
+	tr.now = func() time.Time {
+		return now
+	}
+	tr.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+	buf, err := ioutil.ReadAll(tr)
+	assert.Nil(err)
+	assert.Equal(data, buf)
+	// 首次可读取burst的数据，其余950字节需要9.5秒
+	assert.Equal(9500*time.Millisecond, slept)
+
+	// 读取期间的时间也计算在内
+	slept = 0
+	tr = newThrottledReader(strings.NewReader("abcdefghij"), 5, 0)
+	tr.now = func() time.Time {
+		return now
+	}
+	tr.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+	p := make([]byte, 5)
+	n, _ := tr.Read(p)
+	assert.Equal(5, n)
+	now = now.Add(time.Second)
+	n, _ = tr.Read(p)
+	assert.Equal(5, n)
+	assert.Equal(time.Duration(0), slept)
+}
+
+func TestThrottle(t *testing.T) {
+	assert := assert.New(t)
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.BodyBuffer = bytes.NewBufferString("abcd")
+	throttle(c, 1024, 0)
+	assert.Nil(c.BodyBuffer)
+	assert.Equal("4", c.GetHeader(elton.HeaderContentLength))
+	r, ok := c.Body.(io.Reader)
+	assert.True(ok)
+	buf, _ := ioutil.ReadAll(r)
+	assert.Equal("abcd", string(buf))
+
+	c.Body = ioutil.NopCloser(strings.NewReader("efgh"))
+	throttle(c, 1024, 0)
+	_, ok = c.Body.(io.Closer)
+	assert.True(ok)
+	r, _ = c.Body.(io.Reader)
+	buf, _ = ioutil.ReadAll(r)
+	assert.Equal("efgh", string(buf))
+}
@@ -0,0 +1,183 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTimeout the calling of static file is timeout
+var ErrTimeout = getStaticServeError("read static file timeout", http.StatusGatewayTimeout)
+
+type (
+	// timeoutFile the static file with timeout of Exists, Stat, Get and NewReader.
+	// The Exists and Stat return false and nil when timeout(they can't return error),
+	// and the timeout is recorded if it's created by withTimeoutRecord.
+	timeoutFile struct {
+		StaticFile
+		timeout time.Duration
+		// Exists或Stat超时的次数，用于单个请求判断是否超时
+		timeouts *int32
+	}
+)
+
+// newTimeoutFile create a static file with timeout
+func newTimeoutFile(staticFile StaticFile, timeout time.Duration) *timeoutFile {
+	return &timeoutFile{
+		StaticFile: staticFile,
+		timeout:    timeout,
+	}
+}
+
+// withTimeoutRecord create a copy of timeout file which records the timeout of Exists and Stat,
+// it's created for each request, so the timeout is not affected by the other requests
+func (tf *timeoutFile) withTimeoutRecord() *timeoutFile {
+	return &timeoutFile{
+		StaticFile: tf.StaticFile,
+		timeout:    tf.timeout,
+		timeouts:   new(int32),
+	}
+}
+
+// recordTimeout record the timeout of Exists or Stat
+func (tf *timeoutFile) recordTimeout() {
+	if tf.timeouts != nil {
+		atomic.AddInt32(tf.timeouts, 1)
+	}
+}
+
+// isTimeout check any calling of Exists or Stat is timeout
+func (tf *timeoutFile) isTimeout() bool {
+	return tf != nil && tf.timeouts != nil && atomic.LoadInt32(tf.timeouts) != 0
+}
+
+// do call the function in goroutine, it returns false if timeout.
+// The done function is called with the result after timeout(e.g. close the reader).
+func (tf *timeoutFile) do(fn func() interface{}, done func(interface{})) (interface{}, bool) {
+	ch := make(chan interface{}, 1)
+	go func() {
+		ch <- fn()
+	}()
+	timer := time.NewTimer(tf.timeout)
+	defer timer.Stop()
+	select {
+	case v := <-ch:
+		return v, true
+	case <-timer.C:
+		if done != nil {
+			go func() {
+				done(<-ch)
+			}()
+		}
+		return nil, false
+	}
+}
+
+// Exists check the file exists, it returns false if timeout
+func (tf *timeoutFile) Exists(file string) bool {
+	v, ok := tf.do(func() interface{} {
+		return tf.StaticFile.Exists(file)
+	}, nil)
+	if !ok {
+		tf.recordTimeout()
+		return false
+	}
+	return v.(bool)
+}
+
+// Stat get the stat of file, it returns nil if timeout
+func (tf *timeoutFile) Stat(file string) os.FileInfo {
+	v, ok := tf.do(func() interface{} {
+		return tf.StaticFile.Stat(file)
+	}, nil)
+	if !ok {
+		tf.recordTimeout()
+		return nil
+	}
+	info, _ := v.(os.FileInfo)
+	return info
+}
+
+type timeoutResult struct {
+	value interface{}
+	err   error
+}
+
+// closeResult close the reader of result
+func closeResult(v interface{}) {
+	if result, ok := v.(*timeoutResult); ok {
+		if r, ok := result.value.(io.Reader); ok {
+			closeReader(r)
+		}
+	}
+}
+
+// doWithError call the function with timeout, it returns ErrTimeout if timeout
+func (tf *timeoutFile) doWithError(fn func() (interface{}, error)) (interface{}, error) {
+	v, ok := tf.do(func() interface{} {
+		value, err := fn()
+		return &timeoutResult{
+			value: value,
+			err:   err,
+		}
+	}, closeResult)
+	if !ok {
+		return nil, ErrTimeout
+	}
+	result := v.(*timeoutResult)
+	return result.value, result.err
+}
+
+// Get get the content of file, it returns ErrTimeout if timeout
+func (tf *timeoutFile) Get(file string) ([]byte, error) {
+	v, err := tf.doWithError(func() (interface{}, error) {
+		return tf.StaticFile.Get(file)
+	})
+	buf, _ := v.([]byte)
+	return buf, err
+}
+
+// NewReader new a reader of file, it returns ErrTimeout if timeout
+func (tf *timeoutFile) NewReader(file string) (io.Reader, error) {
+	return tf.NewReaderContext(context.Background(), file)
+}
+
+// NewReaderContext new a reader of file with context, it returns ErrTimeout if timeout
+func (tf *timeoutFile) NewReaderContext(ctx context.Context, file string) (io.Reader, error) {
+	v, err := tf.doWithError(func() (interface{}, error) {
+		return newReaderContext(ctx, tf.StaticFile, file)
+	})
+	r, _ := v.(io.Reader)
+	return r, err
+}
+
+// NewReadSeeker new a read seeker of file, it returns ErrNotSeekable
+// if the static file doesn't support, and returns ErrTimeout if timeout
+func (tf *timeoutFile) NewReadSeeker(file string) (io.ReadSeeker, error) {
+	rsf, ok := tf.StaticFile.(ReadSeekerFile)
+	if !ok {
+		return nil, ErrNotSeekable
+	}
+	v, err := tf.doWithError(func() (interface{}, error) {
+		return rsf.NewReadSeeker(file)
+	})
+	rs, _ := v.(io.ReadSeeker)
+	return rs, err
+}
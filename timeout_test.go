@@ -0,0 +1,204 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+type hangStaticFile struct {
+	MockStaticFile
+	delay  time.Duration
+	closed int32
+}
+type hangReader struct {
+	io.Reader
+	sf *hangStaticFile
+}
+
+func (r *hangReader) Close() error {
+	atomic.AddInt32(&r.sf.closed, 1)
+	return nil
+}
+
+func (sf *hangStaticFile) hang(file, kind string) {
+	if strings.Contains(file, "hang-"+kind) {
+		time.Sleep(sf.delay)
+	}
+}
+
+func (sf *hangStaticFile) Exists(file string) bool {
+	sf.hang(file, "exists")
+	return sf.MockStaticFile.Exists(file)
+}
+
+func (sf *hangStaticFile) Stat(file string) os.FileInfo {
+	sf.hang(file, "stat")
+	return sf.MockStaticFile.Stat(file)
+}
+
+func (sf *hangStaticFile) Get(file string) ([]byte, error) {
+	sf.hang(file, "get")
+	return sf.MockStaticFile.Get(file)
+}
+
+func (sf *hangStaticFile) NewReader(file string) (io.Reader, error) {
+	sf.hang(file, "reader")
+	return &hangReader{
+		Reader: bytes.NewBufferString("abcd"),
+		sf:     sf,
+	}, nil
+}
+
+func TestTimeoutFile(t *testing.T) {
+	assert := assert.New(t)
+	sf := &hangStaticFile{
+		delay: 50 * time.Millisecond,
+	}
+	tf := newTimeoutFile(sf, 10*time.Millisecond)
+
+	assert.True(tf.Exists("/index.html"))
+	assert.False(tf.Exists("/hang-exists.html"))
+	assert.NotNil(tf.Stat("/hang.html"))
+	assert.Nil(tf.Stat("/hang-stat.html"))
+	// 未记录超时
+	assert.False(tf.isTimeout())
+
+	// 每个请求独立记录超时
+	rtf := tf.withTimeoutRecord()
+	assert.True(rtf.Exists("/index.html"))
+	assert.False(rtf.isTimeout())
+	assert.False(rtf.Exists("/hang-exists.html"))
+	assert.True(rtf.isTimeout())
+	assert.False(tf.withTimeoutRecord().isTimeout())
+	var nilFile *timeoutFile
+	assert.False(nilFile.isTimeout())
+
+	buf, err := tf.Get("/hang.html")
+	assert.Nil(err)
+	assert.Equal("abcd", string(buf))
+	_, err = tf.Get("/hang-get.html")
+	assert.Equal(ErrTimeout, err)
+
+	r, err := tf.NewReader("/hang.html")
+	assert.Nil(err)
+	assert.NotNil(r)
+	_, err = tf.NewReader("/hang-reader.html")
+	assert.Equal(ErrTimeout, err)
+	// 超时后返回的reader会被关闭
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(int32(1), atomic.LoadInt32(&sf.closed))
+
+	_, err = tf.NewReadSeeker("/index.html")
+	assert.Equal(ErrNotSeekable, err)
+	rs, err := newTimeoutFile(&FS{}, time.Second).NewReadSeeker(os.Args[0])
+	assert.Nil(err)
+	closeReader(rs)
+}
+
+func TestServeTimeout(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&hangStaticFile{
+		delay: 100 * time.Millisecond,
+	}, Config{
+		Path:             staticPath,
+		Timeout:          20 * time.Millisecond,
+		EnableStrongETag: true,
+		NotFoundCacheTTL: time.Minute,
+	}))
+
+	for _, file := range []string{
+		// 已获取stat的文件不再调用Exists，因此使用目录的index文件
+		"/hang-exists/",
+		"/hang-stat.html",
+		"/hang-get.html",
+	} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest("GET", file, nil))
+		assert.Equal(504, resp.Code, file)
+	}
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+
+	// 超时的文件不缓存为不存在
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/hang-exists/", nil))
+	assert.Equal(504, resp.Code)
+
+	// 使用stat缓存时也记录超时
+	e = elton.New()
+	e.GET("/*file", New(&hangStaticFile{
+		delay: 100 * time.Millisecond,
+	}, Config{
+		Path:         staticPath,
+		Timeout:      20 * time.Millisecond,
+		StatCacheTTL: time.Minute,
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/hang-stat.html", nil))
+	assert.Equal(504, resp.Code)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+
+	e = elton.New()
+	e.GET("/*file", New(&hangStaticFile{
+		delay: 100 * time.Millisecond,
+	}, Config{
+		Path:    staticPath,
+		Timeout: 20 * time.Millisecond,
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/hang-reader.html", nil))
+	assert.Equal(504, resp.Code)
+}
+
+func TestWarmUpTimeout(t *testing.T) {
+	assert := assert.New(t)
+	done := make(chan WarmUpProgress, 1)
+	// 预热时Stat超时不会panic
+	New(&hangStaticFile{
+		delay: 100 * time.Millisecond,
+	}, Config{
+		Path:             staticPath,
+		Timeout:          20 * time.Millisecond,
+		ContentCacheSize: 1024 * 1024,
+		WarmUp: &WarmUpConfig{
+			Files: []string{"/hang-stat.html"},
+			OnProgress: func(progress WarmUpProgress) {
+				done <- progress
+			},
+		},
+	})
+	select {
+	case progress := <-done:
+		assert.Equal(ErrNotFound, progress.Err)
+	case <-time.After(time.Second):
+		assert.Fail("warm up is not done")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vicanso/elton"
+)
+
+// NewPlaceholderTransform create a transform function of Config.Transform, which replaces the
+// placeholders(such as {{BASE_URL}} or __CSP_NONCE__) with the values of request, it's used to
+// inject runtime config, <base> or nonce into the html of SPA. The values are not escaped.
+func NewPlaceholderTransform(fn func(c *elton.Context) map[string]string) func(c *elton.Context, file string, content []byte) ([]byte, error) {
+	return func(c *elton.Context, file string, content []byte) ([]byte, error) {
+		values := fn(c)
+		if len(values) == 0 {
+			return content, nil
+		}
+		// 按占位符排序，保证相同的值替换的结果一致
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			if k != "" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, 2*len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, k, values[k])
+		}
+		return []byte(strings.NewReplacer(pairs...).Replace(string(content))), nil
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestNewPlaceholderTransform(t *testing.T) {
+	assert := assert.New(t)
+	fn := NewPlaceholderTransform(func(c *elton.Context) map[string]string {
+		return map[string]string{
+			"__NONCE__": c.GetRequestHeader("X-Nonce"),
+			"{{ENV}}":   `{"api":"/api"}`,
+			"":          "empty",
+		}
+	})
+	c := elton.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.Request.Header.Set("X-Nonce", "abc")
+	buf, err := fn(c, "index.html", []byte(`<script nonce="__NONCE__">window.env={{ENV}}</script>`))
+	assert.Nil(err)
+	assert.Equal(`<script nonce="abc">window.env={"api":"/api"}</script>`, string(buf))
+
+	fn = NewPlaceholderTransform(func(c *elton.Context) map[string]string {
+		return nil
+	})
+	buf, err = fn(c, "index.html", []byte("<html></html>"))
+	assert.Nil(err)
+	assert.Equal("<html></html>", string(buf))
+}
+
+func TestServeTransformETag(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"index.html": &fstest.MapFile{
+			Data: []byte(`<base href="{{BASE}}">`),
+		},
+		"app.js": &fstest.MapFile{
+			Data: []byte("app"),
+		},
+	})
+	base := "/v1/"
+	fn := New(sf, Config{
+		Transform: NewPlaceholderTransform(func(c *elton.Context) map[string]string {
+			return map[string]string{
+				"{{BASE}}": base,
+			}
+		}),
+	})
+	e := elton.New()
+	e.GET("/*file", fn)
+	e.HEAD("/*file", fn)
+
+	req := httptest.NewRequest("GET", "/index.html", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(`<base href="/v1/">`, resp.Body.String())
+	eTag := resp.Header().Get(elton.HeaderETag)
+	assert.Equal(generateETag([]byte(`<base href="/v1/">`)), eTag)
+
+	req = httptest.NewRequest("GET", "/index.html", nil)
+	req.Header.Set(elton.HeaderIfNoneMatch, eTag)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(304, resp.Code)
+
+	// 注入的值变化后etag也变化
+	base = "/v2/"
+	req = httptest.NewRequest("GET", "/index.html", nil)
+	req.Header.Set(elton.HeaderIfNoneMatch, eTag)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal(`<base href="/v2/">`, resp.Body.String())
+	assert.NotEqual(eTag, resp.Header().Get(elton.HeaderETag))
+
+	// HEAD请求不转换，不设置etag
+	req = httptest.NewRequest("HEAD", "/index.html", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Empty(resp.Header().Get(elton.HeaderETag))
+
+	// 非转换的文件使用weak etag
+	req = httptest.NewRequest("GET", "/app.js", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Contains(resp.Header().Get(elton.HeaderETag), "W/")
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vicanso/elton"
+)
+
+// ErrConfigInvalid the config of static serve is invalid
+var ErrConfigInvalid = errors.New("static serve config is invalid")
+
+// configError create the error of invalid config
+func configError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrConfigInvalid, fmt.Sprintf(format, args...))
+}
+
+// validateConfig check the config is valid, such as the root path is a readable
+// directory and the conflicting options
+func validateConfig(staticFile StaticFile, config *Config) error {
+	roots := []string{config.Path}
+	for _, root := range config.Hosts {
+		roots = append(roots, root)
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		if err := checkRoot(staticFile, filepath.Join(root, "")); err != nil {
+			return fmt.Errorf("%w: %s is not a readable directory", ErrRootInvalid, root)
+		}
+	}
+	if config.Fallback != "" && config.Path != "" && !staticFile.Exists(filepath.Join(config.Path, config.Fallback)) {
+		return configError("fallback file %s does not exist", config.Fallback)
+	}
+	values := []struct {
+		name  string
+		value int
+	}{
+		{"MaxAge", config.MaxAge},
+		{"SMaxAge", config.SMaxAge},
+		{"StaleWhileRevalidate", config.StaleWhileRevalidate},
+		{"StaleIfError", config.StaleIfError},
+	}
+	for _, item := range values {
+		if item.value < 0 {
+			return configError("%s(%d) should not be negative", item.name, item.value)
+		}
+	}
+	if config.MaxFileSize < 0 {
+		return configError("MaxFileSize(%d) should not be negative", config.MaxFileSize)
+	}
+	if config.DisableETag && (config.EnableStrongETag || config.ETagFunc != nil) {
+		return configError("DisableETag conflicts with EnableStrongETag and ETagFunc")
+	}
+	if config.SMaxAge > 0 && (config.Private || config.CacheControlMode == CacheControlPrivate) {
+		return configError("SMaxAge is ignored by shared caches for private response")
+	}
+	if config.CacheControl != "" && (config.MaxAge > 0 || config.SMaxAge > 0) {
+		return configError("CacheControl conflicts with MaxAge and SMaxAge")
+	}
+	if len(config.PrecompressedOnly) != 0 && len(config.Precompressed) == 0 {
+		return configError("PrecompressedOnly requires Precompressed")
+	}
+	if config.NotFoundNext && config.Fallback != "" {
+		return configError("NotFoundNext conflicts with Fallback")
+	}
+	if _, err := newIPRules(config.IPRules); err != nil {
+		return configError("%s", err.Error())
+	}
+	if err := config.SymlinkPolicy.validate(); err != nil {
+		return configError("%s", err.Error())
+	}
+	if _, err := newSourceMapRule(config.SourceMapPolicy, config.SourceMapAllowIPs); err != nil {
+		return configError("%s", err.Error())
+	}
+	return nil
+}
+
+// NewE create a static serve middleware, it returns error instead of panicking or
+// misbehaving at request time if the config is invalid
+func NewE(staticFile StaticFile, config Config) (elton.Handler, error) {
+	if err := validateConfig(staticFile, &config); err != nil {
+		return nil, err
+	}
+	return newE(staticFile, config)
+}
+
+// NewDefaultE create a static server middleware use FS, it returns error if the config is invalid
+func NewDefaultE(config Config) (elton.Handler, error) {
+	return NewE(&FS{}, config)
+}
+
+// Validate check the config is valid and the static files are ready, such as the root path
+// is readable, the index files of indexDirs(url path, such as / and /docs) exist and the files
+// of manifest exist(the size is also checked if specified). It's used for the startup self-check
+// and readiness probe, so that the misconfigured static files fail the deployment.
+func Validate(staticFile StaticFile, config Config, indexDirs ...string) error {
+	if err := validateConfig(staticFile, &config); err != nil {
+		return err
+	}
+	// 目录配置在创建中间件时才加载，此处单独校验
+	if config.DirConfigFile != "" {
+		if _, err := newDirConfigs(staticFile, filepath.Join(config.Path, ""), config.DirConfigFile); err != nil {
+			return configError("%s", err.Error())
+		}
+	}
+	indexes := getIndexes(&config)
+	for _, dir := range indexDirs {
+		if _, ok := findIndexFile(staticFile, filepath.Join(config.Path, dir), indexes); !ok {
+			return configError("index file of %s does not exist", dir)
+		}
+	}
+	var files []string
+	for file, entry := range config.Manifest {
+		info := staticFile.Stat(filepath.Join(config.Path, file))
+		if info == nil || info.IsDir() || (entry != nil && entry.Size > 0 && entry.Size != info.Size()) {
+			files = append(files, file)
+		}
+	}
+	if len(files) != 0 {
+		sort.Strings(files)
+		return configError("files of manifest do not match: %s", strings.Join(files, ", "))
+	}
+	return nil
+}
+
+// NewHealthCheck create a health check handler(such as readiness probe) of static serve,
+// it responds 200 if Validate passes, otherwise 503 with the reason
+func NewHealthCheck(staticFile StaticFile, config Config, indexDirs ...string) elton.Handler {
+	return func(c *elton.Context) error {
+		c.NoCache()
+		if err := Validate(staticFile, config, indexDirs...); err != nil {
+			return getStaticServeError(err.Error(), http.StatusServiceUnavailable)
+		}
+		c.SetHeader(elton.HeaderContentType, "text/plain; charset=utf-8")
+		c.BodyBuffer = bytes.NewBufferString("ok")
+		return nil
+	}
+}
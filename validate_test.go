@@ -0,0 +1,272 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestValidateConfig(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(root, "index.html"), []byte("index"), 0644))
+	sf := &FS{}
+
+	assert.Nil(validateConfig(sf, &Config{
+		Path:     root,
+		MaxAge:   3600,
+		Fallback: "/index.html",
+	}))
+
+	tests := []struct {
+		config Config
+		err    error
+	}{
+		{
+			config: Config{
+				Path: filepath.Join(root, "notfound"),
+			},
+			err: ErrRootInvalid,
+		},
+		{
+			config: Config{
+				Path: filepath.Join(root, "index.html"),
+			},
+			err: ErrRootInvalid,
+		},
+		{
+			config: Config{
+				Path: root,
+				Hosts: map[string]string{
+					"a.example.com": filepath.Join(root, "a"),
+				},
+			},
+			err: ErrRootInvalid,
+		},
+		{
+			config: Config{
+				Path:     root,
+				Fallback: "/app.html",
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:   root,
+				MaxAge: -1,
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:             root,
+				DisableETag:      true,
+				EnableStrongETag: true,
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:    root,
+				Private: true,
+				SMaxAge: 60,
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:         root,
+				CacheControl: "no-cache",
+				MaxAge:       60,
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:         root,
+				Fallback:     "/index.html",
+				NotFoundNext: true,
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path: root,
+				IPRules: []IPRule{
+					{
+						Allow: []string{"1.1.1"},
+					},
+				},
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:          root,
+				SymlinkPolicy: "none",
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:              root,
+				PrecompressedOnly: []string{".js"},
+			},
+			err: ErrConfigInvalid,
+		},
+		{
+			config: Config{
+				Path:            root,
+				SourceMapPolicy: "none",
+			},
+			err: ErrConfigInvalid,
+		},
+	}
+	for _, tt := range tests {
+		err := validateConfig(sf, &tt.config)
+		assert.True(errors.Is(err, tt.err), err)
+	}
+}
+
+func TestNewE(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	fn, err := NewDefaultE(Config{
+		Path: root,
+	})
+	assert.Nil(err)
+	assert.NotNil(fn)
+
+	fn, err = NewDefaultE(Config{
+		Path: filepath.Join(root, "notfound"),
+	})
+	assert.True(errors.Is(err, ErrRootInvalid))
+	assert.Nil(fn)
+
+	// watcher与目录配置出错时返回error而非panic
+	assert.NotPanics(func() {
+		fn, err = NewDefaultE(Config{
+			Path:    root,
+			Watcher: &errorWatcher{},
+		})
+	})
+	assert.Equal(ErrRootInvalid, err)
+	assert.Nil(fn)
+
+	assert.Nil(os.WriteFile(filepath.Join(root, ".staticserve.json"), []byte(`{"header": 1}`), 0644))
+	assert.NotPanics(func() {
+		fn, err = NewDefaultE(Config{
+			Path:          root,
+			DirConfigFile: ".staticserve.json",
+		})
+	})
+	assert.True(errors.Is(err, ErrConfigInvalid))
+	assert.Nil(fn)
+
+	fn, err = NewDefaultE(Config{
+		Hosts: map[string]string{
+			"example.com": root,
+		},
+		DirConfigFile: ".staticserve.json",
+	})
+	assert.True(errors.Is(err, ErrConfigInvalid))
+	assert.Nil(fn)
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	assert.Nil(os.MkdirAll(filepath.Join(root, "js"), 0755))
+	assert.Nil(os.WriteFile(filepath.Join(root, "index.html"), []byte("index"), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "js/app.js"), []byte("app"), 0644))
+	sf := &FS{}
+
+	assert.Nil(Validate(sf, Config{
+		Path: root,
+		Manifest: Manifest{
+			"js/app.js":  {Size: 3},
+			"index.html": {},
+		},
+	}, "/"))
+
+	err := Validate(sf, Config{
+		Path: filepath.Join(root, "notfound"),
+	})
+	assert.True(errors.Is(err, ErrRootInvalid))
+
+	err = Validate(sf, Config{
+		Path: root,
+	}, "/", "/docs")
+	assert.True(errors.Is(err, ErrConfigInvalid))
+	assert.True(strings.Contains(err.Error(), "index file of /docs does not exist"))
+
+	// 配置的index文件
+	assert.Nil(os.WriteFile(filepath.Join(root, "docs/README.md"), []byte("readme"), 0644))
+	assert.Nil(Validate(sf, Config{
+		Path:  root,
+		Index: []string{"index.html", "README.md"},
+	}, "/", "/docs"))
+
+	err = Validate(sf, Config{
+		Path: root,
+		Manifest: Manifest{
+			"js/app.js":    {Size: 10},
+			"js/vendor.js": {},
+			"js":           {},
+			"index.html":   {},
+		},
+	})
+	assert.True(errors.Is(err, ErrConfigInvalid))
+	assert.True(strings.HasSuffix(err.Error(), "files of manifest do not match: js, js/app.js, js/vendor.js"))
+
+	assert.Nil(os.WriteFile(filepath.Join(root, "docs/.staticserve.json"), []byte(`{"header": 1}`), 0644))
+	err = Validate(sf, Config{
+		Path:          root,
+		DirConfigFile: ".staticserve.json",
+	})
+	assert.True(errors.Is(err, ErrConfigInvalid))
+}
+
+func TestNewHealthCheck(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	config := Config{
+		Path: root,
+	}
+	e := elton.New()
+	e.GET("/ping", NewHealthCheck(&FS{}, config, "/"))
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(503, resp.Code)
+	assert.Equal("no-cache", resp.Header().Get(elton.HeaderCacheControl))
+
+	assert.Nil(os.WriteFile(filepath.Join(root, "index.html"), []byte("index"), 0644))
+	req = httptest.NewRequest("GET", "/ping", nil)
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("ok", resp.Body.String())
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vicanso/hes"
+)
+
+// ErrPathTooDeep the depth of path is larger than the limit
+var ErrPathTooDeep = getStaticServeError("static file path is too deep", http.StatusBadRequest)
+
+// NewDotValidator create a path validator which rejects the path with "."-prefixed segment,
+// the path matches allows(path prefix or glob, the same as Config.DotAllow) and the segments
+// in allowSegments(such as .well-known) are allowed
+func NewDotValidator(allows, allowSegments []string) func(string) error {
+	segments := make(map[string]bool)
+	for _, item := range allowSegments {
+		segments[item] = true
+	}
+	return func(file string) error {
+		if !hasDotSegment(filepath.ToSlash(file), segments) || isDotAllowed(file, allows) {
+			return nil
+		}
+		return ErrNotAllowAccessDot
+	}
+}
+
+// hasDotSegment check the path has the segment starts with dot and not in the allow segments,
+// it scans the path without allocation
+func hasDotSegment(file string, allowSegments map[string]bool) bool {
+	for file != "" {
+		segment := file
+		if index := strings.IndexByte(file, '/'); index >= 0 {
+			segment = file[:index]
+			file = file[index+1:]
+		} else {
+			file = ""
+		}
+		if segment != "" && segment[0] == '.' && !allowSegments[segment] {
+			return true
+		}
+	}
+	return false
+}
+
+// NewExtensionValidator create a path validator which checks the extension of path,
+// the same as Config.AllowExtensions and Config.DenyExtensions
+func NewExtensionValidator(allows, denies []string) func(string) error {
+	allowExtensions := newExtensionSet(allows)
+	denyExtensions := newExtensionSet(denies)
+	return func(file string) error {
+		if !isExtensionAllowed(file, allowExtensions, denyExtensions) {
+			return ErrExtensionNotAllowed
+		}
+		return nil
+	}
+}
+
+// TraversalValidator the path validator which rejects the path out of root(such as /../etc/passwd)
+func TraversalValidator(file string) error {
+	if isPathEscaped(file) {
+		return ErrOutOfPath
+	}
+	return nil
+}
+
+// NewMaxDepthValidator create a path validator which rejects the path whose depth
+// (the count of segments) is larger than max
+func NewMaxDepthValidator(max int) func(string) error {
+	return func(file string) error {
+		file = strings.Trim(path.Clean("/"+filepath.ToSlash(file)), "/")
+		if file != "" && strings.Count(file, "/")+1 > max {
+			return ErrPathTooDeep
+		}
+		return nil
+	}
+}
+
+// isPathEscaped check the path is out of root after cleaned as relative url path, or it contains NUL
+func isPathEscaped(file string) bool {
+	if strings.IndexByte(file, 0) != -1 {
+		return true
+	}
+	file = path.Clean(strings.TrimLeft(filepath.ToSlash(file), "/"))
+	return file == ".." || strings.HasPrefix(file, "../")
+}
+
+// validatePath validate the path by all validators, the error which is not *hes.Error
+// is converted to bad request error
+func validatePath(file string, validators []func(string) error) *hes.Error {
+	for _, validate := range validators {
+		err := validate(file)
+		if err == nil {
+			continue
+		}
+		if he, ok := err.(*hes.Error); ok {
+			return he
+		}
+		he := getStaticServeError(err.Error(), http.StatusBadRequest)
+		he.Err = err
+		return he
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestHasDotSegment(t *testing.T) {
+	assert := assert.New(t)
+	segments := map[string]bool{
+		".well-known": true,
+	}
+	assert.False(hasDotSegment("", segments))
+	assert.False(hasDotSegment("/js/app.js", segments))
+	assert.False(hasDotSegment("/.well-known/security.txt", segments))
+	assert.False(hasDotSegment("//a//b/", segments))
+	assert.True(hasDotSegment("/.git/config", segments))
+	assert.True(hasDotSegment("/a/.env", segments))
+	assert.True(hasDotSegment(".env", segments))
+}
+
+func TestNewDotValidator(t *testing.T) {
+	assert := assert.New(t)
+	validate := NewDotValidator([]string{"/.config/public/"}, []string{".well-known"})
+	assert.Nil(validate("/index.html"))
+	assert.Nil(validate("/.well-known/security.txt"))
+	assert.Nil(validate("/.config/public/app.json"))
+	assert.Equal(ErrNotAllowAccessDot, validate("/.git/config"))
+	assert.Equal(ErrNotAllowAccessDot, validate("/.well-known/.htpasswd"))
+}
+
+func TestNewExtensionValidator(t *testing.T) {
+	assert := assert.New(t)
+	validate := NewExtensionValidator([]string{".js"}, []string{".bak"})
+	assert.Nil(validate("/app.js"))
+	assert.Equal(ErrExtensionNotAllowed, validate("/app.js.bak"))
+	assert.Equal(ErrExtensionNotAllowed, validate("/main.go"))
+}
+
+func TestTraversalValidator(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(TraversalValidator("/js/../index.html"))
+	assert.Equal(ErrOutOfPath, TraversalValidator("/../etc/passwd"))
+	assert.Equal(ErrOutOfPath, TraversalValidator("/index.html\x00"))
+}
+
+func TestNewMaxDepthValidator(t *testing.T) {
+	assert := assert.New(t)
+	validate := NewMaxDepthValidator(2)
+	assert.Nil(validate("/"))
+	assert.Nil(validate("/js/app.js"))
+	assert.Nil(validate("/js/"))
+	assert.Equal(ErrPathTooDeep, validate("/a/b/c.js"))
+}
+
+func TestValidatePath(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(validatePath("/index.html", nil))
+
+	customErr := errors.New("path is not normalized")
+	he := validatePath("/index.html", []func(string) error{
+		TraversalValidator,
+		func(file string) error {
+			return customErr
+		},
+	})
+	assert.Equal(400, he.StatusCode)
+	assert.Equal(customErr.Error(), he.Message)
+	assert.Equal(customErr, he.Err)
+
+	assert.Equal(ErrOutOfPath, validatePath("/../index.html", []func(string) error{
+		TraversalValidator,
+	}))
+}
+
+func TestPathValidators(t *testing.T) {
+	assert := assert.New(t)
+	e := elton.New()
+	e.GET("/*file", New(&MockStaticFile{}, Config{
+		Path: staticPath,
+		PathValidators: []func(string) error{
+			NewMaxDepthValidator(1),
+			func(file string) error {
+				if strings.ToLower(file) != file {
+					return errors.New("upper case is not allowed")
+				}
+				return nil
+			},
+		},
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/js/app.js", nil))
+	assert.Equal(400, resp.Code)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/Index.html", nil))
+	assert.Equal(400, resp.Code)
+}
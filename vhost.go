@@ -0,0 +1,113 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/vicanso/elton"
+)
+
+type (
+	// hostHandler the handler of host
+	hostHandler struct {
+		pattern string
+		handler elton.Handler
+	}
+	// emptyStaticFile the static file without any file, it's used for the unmatched host
+	// if Config.Path is not set, so the request is handled as not found by the normal handler
+	emptyStaticFile struct{}
+)
+
+func (*emptyStaticFile) Exists(string) bool                  { return false }
+func (*emptyStaticFile) Get(string) ([]byte, error)          { return nil, os.ErrNotExist }
+func (*emptyStaticFile) Stat(string) os.FileInfo             { return nil }
+func (*emptyStaticFile) NewReader(string) (io.Reader, error) { return nil, os.ErrNotExist }
+
+// newHostHandler create the middleware which serves the files of different path by the
+// host of request, each path uses its own handler(the cache is not shared, and the watcher
+// and warm up are started once per path), and the handler of Config.Path is used if no host
+// matched, the request is handled as not found if Config.Path is not set
+func newHostHandler(staticFile StaticFile, config Config) (elton.Handler, error) {
+	hosts := config.Hosts
+	config.Hosts = nil
+	// 相同目录的Host共用handler
+	rootHandlers := make(map[string]elton.Handler)
+	getHandler := func(root string) (elton.Handler, error) {
+		if handler, ok := rootHandlers[root]; ok {
+			return handler, nil
+		}
+		cfg := config
+		cfg.Path = root
+		handler, err := newE(staticFile, cfg)
+		if err != nil {
+			return nil, err
+		}
+		rootHandlers[root] = handler
+		return handler, nil
+	}
+	var defaultHandler elton.Handler
+	var err error
+	if config.Path != "" {
+		defaultHandler, err = getHandler(config.Path)
+	} else {
+		// 未指定Path时不使用根目录，以不存在的文件处理（Skipper、NotFoundHandler、ErrorHandler等仍有效）
+		cfg := config
+		cfg.Watcher = nil
+		cfg.WarmUp = nil
+		cfg.StrictRoot = false
+		defaultHandler, err = newE(&emptyStaticFile{}, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	exactHandlers := make(map[string]elton.Handler)
+	wildcardHandlers := make([]*hostHandler, 0)
+	for host, root := range hosts {
+		handler, err := getHandler(root)
+		if err != nil {
+			return nil, err
+		}
+		host = strings.ToLower(host)
+		if strings.HasPrefix(host, "*.") {
+			wildcardHandlers = append(wildcardHandlers, &hostHandler{
+				pattern: host,
+				handler: handler,
+			})
+			continue
+		}
+		exactHandlers[host] = handler
+	}
+	// 优先匹配更长（更具体）的通配域名
+	sort.Slice(wildcardHandlers, func(i, j int) bool {
+		return len(wildcardHandlers[i].pattern) > len(wildcardHandlers[j].pattern)
+	})
+	return func(c *elton.Context) error {
+		host := strings.ToLower(getHostname(c.Request.Host))
+		if handler, ok := exactHandlers[host]; ok {
+			return handler(c)
+		}
+		for _, item := range wildcardHandlers {
+			if isHostMatched(item.pattern, host) {
+				return item.handler(c)
+			}
+		}
+		return defaultHandler(c)
+	}, nil
+}
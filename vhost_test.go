@@ -0,0 +1,181 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestHostHandler(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"default/index.html": &fstest.MapFile{
+			Data: []byte("default"),
+		},
+		"a/index.html": &fstest.MapFile{
+			Data: []byte("a"),
+		},
+		"b/index.html": &fstest.MapFile{
+			Data: []byte("b"),
+		},
+		"c/index.html": &fstest.MapFile{
+			Data: []byte("c"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Path: "/default",
+		Hosts: map[string]string{
+			"a.example.com":     "/a",
+			"*.example.com":     "/b",
+			"*.api.example.com": "/c",
+		},
+	}))
+
+	tests := []struct {
+		host   string
+		result string
+	}{
+		{
+			host:   "a.example.com",
+			result: "a",
+		},
+		// 忽略大小写与端口
+		{
+			host:   "A.Example.com:8080",
+			result: "a",
+		},
+		{
+			host:   "b.example.com",
+			result: "b",
+		},
+		// 优先匹配更具体的通配域名
+		{
+			host:   "v1.api.example.com",
+			result: "c",
+		},
+		{
+			host:   "example.org",
+			result: "default",
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/index.html", nil)
+		req.Host = tt.host
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, req)
+		assert.Equal(200, resp.Code, tt.host)
+		assert.Equal(tt.result, resp.Body.String(), tt.host)
+	}
+
+	// 不能越过Host对应的目录
+	req := httptest.NewRequest("GET", "/../b/index.html", nil)
+	req.Host = "a.example.com"
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.NotEqual("b", resp.Body.String())
+}
+
+type rootsWatcher struct {
+	roots []string
+}
+
+func (w *rootsWatcher) Watch(root string, onChange func(string)) error {
+	w.roots = append(w.roots, root)
+	return nil
+}
+
+func TestHostHandlerWithoutPath(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"a/index.html": &fstest.MapFile{
+			Data: []byte("a"),
+		},
+	})
+	w := &rootsWatcher{}
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Hosts: map[string]string{
+			"a.example.com":   "/a",
+			"www.example.com": "/a",
+		},
+		Watcher: w,
+	}))
+	// 相同目录只监听一次
+	assert.Equal([]string{"/a"}, w.roots)
+
+	req := httptest.NewRequest("GET", "/a/index.html", nil)
+	req.Host = "example.org"
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(404, resp.Code)
+
+	req = httptest.NewRequest("GET", "/index.html", nil)
+	req.Host = "www.example.com"
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("a", resp.Body.String())
+}
+
+func TestHostHandlerNotFoundHandler(t *testing.T) {
+	assert := assert.New(t)
+	sf := NewIOFS(fstest.MapFS{
+		"a/index.html": &fstest.MapFile{
+			Data: []byte("a"),
+		},
+	})
+	e := elton.New()
+	e.GET("/*file", New(sf, Config{
+		Hosts: map[string]string{
+			"a.example.com": "/a",
+		},
+		NotFoundHandler: func(c *elton.Context) error {
+			c.StatusCode = 404
+			c.BodyBuffer = bytes.NewBufferString("custom not found")
+			return c.Next()
+		},
+	}))
+
+	// 未匹配的Host也使用NotFoundHandler
+	req := httptest.NewRequest("GET", "/a/index.html", nil)
+	req.Host = "example.org"
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(404, resp.Code)
+	assert.Equal("custom not found", resp.Body.String())
+
+	// 未匹配的Host的出错也触发OnError
+	var notFoundErr error
+	e = elton.New()
+	e.GET("/*file", New(sf, Config{
+		Hosts: map[string]string{
+			"a.example.com": "/a",
+		},
+		OnError: func(c *elton.Context, info ServeInfo, err error) {
+			notFoundErr = err
+		},
+	}))
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(404, resp.Code)
+	assert.Equal(ErrNotFound, notFoundErr)
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const defaultWarmUpWorkers = 4
+
+type (
+	// WarmUpConfig the config of warm up, the files are loaded at startup to
+	// generate the strong etags and compressed data of caches
+	WarmUpConfig struct {
+		// 预热的文件（相对于Path，如 /js/app.js ），为空则使用Manifest中的文件，
+		// 无Manifest时遍历目录（需要StaticFile实现DirLister）
+		Files []string
+		// 并发数，默认为4
+		Workers int
+		// 每个文件预热之后的回调
+		OnProgress func(WarmUpProgress)
+	}
+	// WarmUpProgress the progress of warm up
+	WarmUpProgress struct {
+		// 预热的文件（相对于Path）
+		File string
+		// 已完成的文件数
+		Done int
+		// 总文件数
+		Total int
+		// 预热出错
+		Err error
+	}
+)
+
+// listFiles list all files of directory recursively, the paths are relative to root
+func listFiles(dirLister DirLister, root string) ([]string, error) {
+	var result []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		infos, err := dirLister.ReadDir(filepath.Join(root, dir))
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			file := filepath.ToSlash(filepath.Join(dir, info.Name()))
+			if info.IsDir() {
+				if err := walk(file); err != nil {
+					return err
+				}
+				continue
+			}
+			result = append(result, file)
+		}
+		return nil
+	}
+	if err := walk("/"); err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// getWarmUpFiles get the files of warm up
+func getWarmUpFiles(staticFile StaticFile, config *Config, root string) ([]string, error) {
+	if len(config.WarmUp.Files) != 0 {
+		return config.WarmUp.Files, nil
+	}
+	if len(config.Manifest) != 0 {
+		files := make([]string, 0, len(config.Manifest))
+		for file := range config.Manifest {
+			files = append(files, "/"+file)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+	dirLister, ok := getDirLister(staticFile)
+	if !ok {
+		return nil, nil
+	}
+	return listFiles(dirLister, root)
+}
+
+// warmUp warm up the files concurrently, the progress is reported after each file,
+// and it's reported once if there is no file
+func warmUp(config *WarmUpConfig, files []string, fn func(string) error) {
+	if len(files) == 0 {
+		if config.OnProgress != nil {
+			config.OnProgress(WarmUpProgress{})
+		}
+		return
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = defaultWarmUpWorkers
+	}
+	ch := make(chan string)
+	mutex := sync.Mutex{}
+	done := 0
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range ch {
+				err := fn(file)
+				mutex.Lock()
+				done++
+				progress := WarmUpProgress{
+					File:  file,
+					Done:  done,
+					Total: len(files),
+					Err:   err,
+				}
+				if config.OnProgress != nil {
+					config.OnProgress(progress)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, file := range files {
+		ch <- file
+	}
+	close(ch)
+	wg.Wait()
+}
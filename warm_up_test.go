@@ -0,0 +1,155 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func TestListFiles(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "js", "vendor"), 0755))
+	for _, file := range []string{"index.html", "js/app.js", "js/vendor/vue.js"} {
+		assert.Nil(os.WriteFile(filepath.Join(root, file), []byte(file), 0644))
+	}
+	files, err := listFiles(&FS{}, root)
+	assert.Nil(err)
+	assert.Equal([]string{"/index.html", "/js/app.js", "/js/vendor/vue.js"}, files)
+
+	_, err = listFiles(&FS{}, filepath.Join(root, "notfound"))
+	assert.NotNil(err)
+}
+
+func TestGetWarmUpFiles(t *testing.T) {
+	assert := assert.New(t)
+	files, err := getWarmUpFiles(&MockStaticFile{}, &Config{
+		WarmUp: &WarmUpConfig{
+			Files: []string{"/index.html"},
+		},
+	}, staticPath)
+	assert.Nil(err)
+	assert.Equal([]string{"/index.html"}, files)
+
+	files, err = getWarmUpFiles(&MockStaticFile{}, &Config{
+		WarmUp:   &WarmUpConfig{},
+		Manifest: NewManifestFromPaths("js/app.js", "index.html"),
+	}, staticPath)
+	assert.Nil(err)
+	assert.Equal([]string{"/index.html", "/js/app.js"}, files)
+
+	// 不支持遍历目录
+	files, err = getWarmUpFiles(&MockStaticFile{}, &Config{
+		WarmUp: &WarmUpConfig{},
+	}, staticPath)
+	assert.Nil(err)
+	assert.Empty(files)
+}
+
+func TestWarmUp(t *testing.T) {
+	assert := assert.New(t)
+	mutex := sync.Mutex{}
+	warmed := make([]string, 0)
+	var progresses []WarmUpProgress
+	files := []string{"/a.js", "/b.js", "/c.js", "/d.js", "/e.js"}
+	warmUp(&WarmUpConfig{
+		Workers: 2,
+		OnProgress: func(progress WarmUpProgress) {
+			progresses = append(progresses, progress)
+		},
+	}, files, func(file string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		warmed = append(warmed, file)
+		if file == "/e.js" {
+			return ErrNotFound
+		}
+		return nil
+	})
+	assert.ElementsMatch(files, warmed)
+	assert.Equal(5, len(progresses))
+	for i, progress := range progresses {
+		assert.Equal(i+1, progress.Done)
+		assert.Equal(5, progress.Total)
+		if progress.File == "/e.js" {
+			assert.Equal(ErrNotFound, progress.Err)
+		}
+	}
+
+	// 无文件时也回调
+	progresses = nil
+	warmUp(&WarmUpConfig{
+		OnProgress: func(progress WarmUpProgress) {
+			progresses = append(progresses, progress)
+		},
+	}, nil, nil)
+	assert.Equal([]WarmUpProgress{{}}, progresses)
+}
+
+func TestServeWarmUp(t *testing.T) {
+	assert := assert.New(t)
+	root := t.TempDir()
+	assert.Nil(os.MkdirAll(filepath.Join(root, "js"), 0755))
+	appJS := strings.Repeat("console.log('app');", 100)
+	assert.Nil(os.WriteFile(filepath.Join(root, "js", "app.js"), []byte(appJS), 0644))
+	assert.Nil(os.WriteFile(filepath.Join(root, "index.html"), []byte("<html></html>"), 0644))
+
+	done := make(chan struct{})
+	var errs []error
+	cache := NewContentCache(1024 * 1024)
+	e := elton.New()
+	e.GET("/*file", NewDefault(Config{
+		Path:              root,
+		EnableStrongETag:  true,
+		ContentCache:      cache,
+		ETagCacheSize:     10,
+		CompressCacheSize: 1024 * 1024,
+		Compressors: []Compressor{
+			NewGzipCompressor(0),
+		},
+		WarmUp: &WarmUpConfig{
+			OnProgress: func(progress WarmUpProgress) {
+				if progress.Err != nil {
+					errs = append(errs, progress.Err)
+				}
+				if progress.Done == progress.Total {
+					close(done)
+				}
+			},
+		},
+	}))
+	<-done
+	assert.Empty(errs)
+	// 两个文件的内容与一个压缩数据
+	assert.Equal(2, cache.Stats().Count)
+
+	req := httptest.NewRequest("GET", "/js/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("gzip", resp.Header().Get(elton.HeaderContentEncoding))
+	assert.Equal(appendETagEncoding(generateETag([]byte(appJS)), "gzip"), resp.Header().Get(elton.HeaderETag))
+	assert.Equal(uint64(1), cache.Stats().Hits)
+	assert.NotEqual(appJS, resp.Body.String())
+}
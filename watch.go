@@ -0,0 +1,39 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Watcher the watcher of file changes(such as the fsnotify subpackage), onChange should be
+// called with the os path of the changed file, then the caches of the file are removed
+type Watcher interface {
+	Watch(root string, onChange func(file string)) error
+}
+
+// isCacheKeyOf check the cache key is the file, the variant of file(such as compressed, file:gzip)
+// or the file in the directory
+func isCacheKeyOf(key, file string) bool {
+	if !strings.HasPrefix(key, file) {
+		return false
+	}
+	if len(key) == len(file) {
+		return true
+	}
+	c := key[len(file)]
+	return c == ':' || c == filepath.Separator
+}
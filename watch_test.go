@@ -0,0 +1,92 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+type mockWatcher struct {
+	root     string
+	onChange func(string)
+}
+
+func (w *mockWatcher) Watch(root string, onChange func(string)) error {
+	w.root = root
+	w.onChange = onChange
+	return nil
+}
+
+func TestIsCacheKeyOf(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(isCacheKeyOf("/local/app.js", "/local/app.js"))
+	assert.True(isCacheKeyOf("/local/app.js:br", "/local/app.js"))
+	assert.True(isCacheKeyOf("/local/js/app.js", "/local/js"))
+	assert.False(isCacheKeyOf("/local/app.json", "/local/app.js"))
+	assert.False(isCacheKeyOf("/local/app.js", "/local/app.json"))
+}
+
+func TestWatcher(t *testing.T) {
+	assert := assert.New(t)
+	cf := &countStaticFile{}
+	w := &mockWatcher{}
+	cache := NewContentCache(1024)
+	e := elton.New()
+	e.GET("/*file", New(cf, Config{
+		Path:             staticPath,
+		ContentCache:     cache,
+		StatCacheTTL:     time.Minute,
+		NotFoundCacheTTL: time.Minute,
+		Watcher:          w,
+	}))
+	assert.Equal(staticPath, w.root)
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal(1, cache.Stats().Count)
+	statCount := cf.statCount
+
+	w.onChange(staticPath + "/index.html")
+	assert.Equal(0, cache.Stats().Count)
+
+	resp = httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/index.html", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal(1, cache.Stats().Count)
+	// stat缓存已删除，重新获取
+	assert.True(cf.statCount > statCount)
+}
+
+func TestWatcherError(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() {
+		New(&MockStaticFile{}, Config{
+			Path:    staticPath,
+			Watcher: &errorWatcher{},
+		})
+	})
+}
+
+type errorWatcher struct{}
+
+func (w *errorWatcher) Watch(root string, onChange func(string)) error {
+	return ErrRootInvalid
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staticserve
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+type (
+	// Zip static file of zip archive
+	Zip struct {
+		*IOFS
+		closer io.Closer
+	}
+)
+
+// NewZip create a static file of zip reader
+func NewZip(r *zip.Reader) *Zip {
+	return &Zip{
+		IOFS: NewIOFS(r),
+	}
+}
+
+// OpenZip open the zip archive and create a static file of it,
+// the archive is held open until Close is called
+func OpenZip(file string) (*Zip, error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Zip{
+		IOFS:   NewIOFS(&r.Reader),
+		closer: r,
+	}, nil
+}
+
+// Close close the zip archive
+func (z *Zip) Close() error {
+	if z.closer == nil {
+		return nil
+	}
+	return z.closer.Close()
+}
+
+// ETag generate the etag of file by the crc32 of zip entry,
+// it can be used as Config.ETagFunc
+func (z *Zip) ETag(file string, info os.FileInfo) string {
+	if info == nil {
+		return ""
+	}
+	header, ok := info.Sys().(*zip.FileHeader)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(`"%x-%08x"`, header.UncompressedSize64, header.CRC32)
+}
@@ -0,0 +1,84 @@
+package staticserve
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+)
+
+func newTestZip(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	files := map[string]string{
+		"index.html":  "<html>index</html>",
+		"js/app.js":   "console.log('app')",
+		"css/app.css": "body {}",
+	}
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestZip(t *testing.T) {
+	assert := assert.New(t)
+	data := newTestZip(t)
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.Nil(err)
+	z := NewZip(r)
+	assert.Nil(z.Close())
+
+	assert.True(z.Exists("/index.html"))
+	assert.True(z.Exists("/js"))
+	assert.False(z.Exists("/notfound.html"))
+	buf, err := z.Get("/js/app.js")
+	assert.Nil(err)
+	assert.Equal("console.log('app')", string(buf))
+
+	eTag := fmt.Sprintf(`"12-%08x"`, crc32.ChecksumIEEE([]byte("<html>index</html>")))
+	info := z.Stat("/index.html")
+	assert.Equal(eTag, z.ETag("/index.html", info))
+	assert.Empty(z.ETag("/js", z.Stat("/js")))
+	assert.Empty(z.ETag("/notfound.html", nil))
+
+	infos, err := z.ReadDir("/")
+	assert.Nil(err)
+	assert.Equal(3, len(infos))
+
+	e := elton.New()
+	e.GET("/*file", New(z, Config{
+		ETagFunc: z.ETag,
+	}))
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(200, resp.Code)
+	assert.Equal("<html>index</html>", resp.Body.String())
+	assert.Equal(eTag, resp.Header().Get(elton.HeaderETag))
+}
+
+func TestOpenZip(t *testing.T) {
+	assert := assert.New(t)
+	_, err := OpenZip(filepath.Join(t.TempDir(), "notfound.zip"))
+	assert.NotNil(err)
+
+	file := filepath.Join(t.TempDir(), "assets.zip")
+	assert.Nil(ioutil.WriteFile(file, newTestZip(t), 0644))
+	z, err := OpenZip(file)
+	assert.Nil(err)
+	defer z.Close()
+	buf, err := z.Get("/css/app.css")
+	assert.Nil(err)
+	assert.Equal("body {}", string(buf))
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 tree xie
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zstd provides the zstd compressor of runtime compression,
+// the encoder is shared and safe for concurrent use
+package zstd
+
+import (
+	"github.com/klauspost/compress/zstd"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+type compressor struct {
+	encoder *zstd.Encoder
+}
+
+// NewCompressor create a zstd compressor, the default level is used if level is 0
+func NewCompressor(level zstd.EncoderLevel) (staticServe.Compressor, error) {
+	if level == 0 {
+		level = zstd.SpeedDefault
+	}
+	// 无writer的encoder仅用于EncodeAll，可并发使用
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	return &compressor{
+		encoder: encoder,
+	}, nil
+}
+
+// Encoding get the encoding of zstd
+func (zc *compressor) Encoding() string {
+	return "zstd"
+}
+
+// Compress compress the data by zstd
+func (zc *compressor) Compress(buf []byte) ([]byte, error) {
+	return zc.encoder.EncodeAll(buf, make([]byte, 0, len(buf)/2)), nil
+}
@@ -0,0 +1,62 @@
+package zstd
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/vicanso/elton"
+	staticServe "github.com/vicanso/elton-static-serve"
+)
+
+func TestCompressor(t *testing.T) {
+	assert := assert.New(t)
+	c, err := NewCompressor(0)
+	assert.Nil(err)
+	assert.Equal("zstd", c.Encoding())
+	data := strings.Repeat("hello world", 100)
+	buf, err := c.Compress([]byte(data))
+	assert.Nil(err)
+	assert.True(len(buf) < len(data))
+
+	decoder, err := zstd.NewReader(nil)
+	assert.Nil(err)
+	defer decoder.Close()
+	result, err := decoder.DecodeAll(buf, nil)
+	assert.Nil(err)
+	assert.Equal(data, string(result))
+}
+
+func TestServeZstd(t *testing.T) {
+	assert := assert.New(t)
+	data := strings.Repeat("console.log('app');", 100)
+	sf := staticServe.NewIOFS(fstest.MapFS{
+		"app.js": &fstest.MapFile{
+			Data: []byte(data),
+		},
+	})
+	c, err := NewCompressor(zstd.SpeedFastest)
+	assert.Nil(err)
+	e := elton.New()
+	e.GET("/*file", staticServe.New(sf, staticServe.Config{
+		Compressors: []staticServe.Compressor{
+			c,
+			staticServe.NewGzipCompressor(0),
+		},
+	}))
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set(elton.HeaderAcceptEncoding, "gzip, br, zstd")
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+	assert.Equal(200, resp.Code)
+	assert.Equal("zstd", resp.Header().Get(elton.HeaderContentEncoding))
+	decoder, err := zstd.NewReader(nil)
+	assert.Nil(err)
+	defer decoder.Close()
+	result, err := decoder.DecodeAll(resp.Body.Bytes(), nil)
+	assert.Nil(err)
+	assert.Equal(data, string(result))
+}